@@ -0,0 +1,5 @@
+// Package usersv1 holds the gRPC API of the operations that are not part of the
+// user-service-contracts yet. The code is generated from the .proto files of this directory.
+package usersv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative users/v1/users.proto users/v1/organizations.proto users/v1/groups.proto users/v1/authz.proto users/v1/events.proto
@@ -2,15 +2,19 @@ package main
 
 import (
 	"Users/internal/app"
+	"Users/internal/cli"
 	"Users/internal/config"
 	"Users/pkg/logging"
 	"Users/pkg/shutdown"
 	"context"
 	"errors"
+	"fmt"
 	"google.golang.org/grpc"
 	"net/http"
 	"os"
 	"syscall"
+	// profile timezones are validated in alpine images that have no zoneinfo
+	_ "time/tzdata"
 )
 
 // @Title		User-service API
@@ -35,6 +39,13 @@ func main() {
 	logger.Info("config initializing")
 	cfg := config.GetConfig()
 
+	if len(os.Args) > 1 {
+		if err := runCommand(ctx, cfg, logger, os.Args[1], os.Args[2:]); err != nil {
+			logger.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	a, err := app.NewApp(ctx, cfg, logger)
 	if err != nil {
 		logger.Fatalf("failed to create application: %v", err)
@@ -51,3 +62,14 @@ func main() {
 	}
 	logger.Info("gracefully stopped")
 }
+
+func runCommand(ctx context.Context, cfg *config.Config, logger *logging.Logger, command string, args []string) error {
+	switch command {
+	case "import":
+		return cli.Import(ctx, cfg, logger, args)
+	case "export":
+		return cli.Export(ctx, cfg, logger, args)
+	default:
+		return fmt.Errorf("unknown command")
+	}
+}
@@ -1,15 +1,25 @@
 package app
 
 import (
+	usersv1 "Users/api/users/v1"
 	_ "Users/docs"
 	"Users/internal/config"
 	grpcv1 "Users/internal/user/controller/grpc/v1"
 	"Users/internal/user/controller/rest"
+	"Users/internal/user/dispatcher"
 	"Users/internal/user/domain/service"
+	"Users/internal/user/purger"
+	"Users/internal/user/relay"
 	"Users/internal/user/repository/postgres"
+	"Users/pkg/auth"
+	"Users/pkg/blob"
+	"Users/pkg/events"
 	"Users/pkg/logging"
+	"Users/pkg/mail"
 	"Users/pkg/metric"
 	"Users/pkg/postgresql"
+	"Users/pkg/sms"
+	"Users/pkg/webhook"
 	"context"
 	"errors"
 	"fmt"
@@ -27,11 +37,22 @@ import (
 )
 
 type App struct {
-	cfg               *config.Config
-	router            *httprouter.Router
-	httpServer        *http.Server
-	grpcServer        *grpc.Server
-	userServiceServer protoUserService.UserServiceServer
+	cfg                 *config.Config
+	router              *httprouter.Router
+	httpServer          *http.Server
+	grpcServer          *grpc.Server
+	userServiceServer   protoUserService.UserServiceServer
+	usersServer         usersv1.UsersServiceServer
+	organizationsServer usersv1.OrganizationsServiceServer
+	groupsServer        usersv1.GroupsServiceServer
+	authzServer         usersv1.AuthzServiceServer
+	eventsServer        usersv1.EventsServiceServer
+	purger              *purger.Purger
+	relay               *relay.Relay
+	dispatcher          *dispatcher.Dispatcher
+	listener            *postgresql.Listener
+	publisher           events.Publisher
+	verifier            *auth.Verifier
 
 	logger *logging.Logger
 }
@@ -48,6 +69,13 @@ func NewApp(ctx context.Context, cfg *config.Config, logger *logging.Logger) (Ap
 	metricHandler := metric.Handler{Logger: logger}
 	metricHandler.Register(router)
 
+	logger.Info("token verifier initializing")
+	verifier, err := auth.NewVerifier(*cfg)
+	if err != nil {
+		logger.Fatal(err)
+		return App{}, fmt.Errorf("failed to init token verifier: %w", err)
+	}
+
 	logger.Info("storage initializing")
 	postgresClient, err := postgresql.NewClient(ctx, 5, *cfg)
 	if err != nil {
@@ -55,19 +83,65 @@ func NewApp(ctx context.Context, cfg *config.Config, logger *logging.Logger) (Ap
 		return App{}, fmt.Errorf("failed to init storage: %w", err)
 	}
 
-	userStorage := postgres.NewRepository(postgresClient, logger)
-	userService := service.NewService(userStorage, logger)
+	logger.Info("blob storage initializing")
+	blobStorage, err := blob.NewStorage(ctx, *cfg)
+	if err != nil {
+		logger.Fatal(err)
+		return App{}, fmt.Errorf("failed to init blob storage: %w", err)
+	}
+
+	logger.Info("mail sender initializing")
+	mailer, err := mail.NewSender(*cfg, logger)
+	if err != nil {
+		logger.Fatal(err)
+		return App{}, fmt.Errorf("failed to init mail sender: %w", err)
+	}
+
+	logger.Info("sms sender initializing")
+	smsSender, err := sms.NewSender(*cfg, logger)
+	if err != nil {
+		logger.Fatal(err)
+		return App{}, fmt.Errorf("failed to init sms sender: %w", err)
+	}
+
+	logger.Info("event publisher initializing")
+	publisher, err := events.NewPublisher(*cfg, logger)
+	if err != nil {
+		logger.Fatal(err)
+		return App{}, fmt.Errorf("failed to init event publisher: %w", err)
+	}
+
+	eventsListener := postgresql.NewListener(postgresClient, postgres.EventsChannel, logger)
+
+	userStorage := postgres.NewRepository(postgresql.NewTenantClient(postgresClient), logger)
+	userService := service.NewService(userStorage, blobStorage, mailer, smsSender, eventsListener,
+		service.NewOptions(*cfg), logger)
 
 	usersHandler := rest.NewHandler(userService, logger)
 	usersHandler.Register(router)
 
 	usersGRPCServer := grpcv1.NewServer(protoUserService.UnimplementedUserServiceServer{}, userService, logger)
 
+	eventRelay := relay.NewRelay(userService, publisher, cfg.Events.RelayInterval, cfg.Events.RelayBatchSize, logger)
+	webhookDispatcher := dispatcher.NewDispatcher(userService, webhook.NewClient(cfg.Webhooks.Timeout),
+		cfg.Webhooks.DispatchInterval, cfg.Webhooks.DispatchBatchSize, logger)
+
 	return App{
-		cfg:               cfg,
-		router:            router,
-		userServiceServer: usersGRPCServer,
-		logger:            logger,
+		cfg:                 cfg,
+		router:              router,
+		userServiceServer:   usersGRPCServer,
+		usersServer:         grpcv1.NewUsersServer(userService, logger),
+		organizationsServer: grpcv1.NewOrganizationsServer(userService, logger),
+		groupsServer:        grpcv1.NewGroupsServer(userService, logger),
+		authzServer:         grpcv1.NewAuthzServer(userService, logger),
+		eventsServer:        grpcv1.NewEventsServer(userService, logger),
+		purger:              purger.NewPurger(userService, cfg.Users.PurgeInterval, logger),
+		relay:               eventRelay,
+		dispatcher:          webhookDispatcher,
+		listener:            eventsListener,
+		publisher:           publisher,
+		verifier:            verifier,
+		logger:              logger,
 	}, nil
 }
 
@@ -82,6 +156,22 @@ func (a *App) Run(ctx context.Context) error {
 		return a.startGRPC(a.userServiceServer)
 	})
 
+	group.Go(func() error {
+		return a.purger.Run(ctx)
+	})
+
+	group.Go(func() error {
+		return a.relay.Run(ctx)
+	})
+
+	group.Go(func() error {
+		return a.dispatcher.Run(ctx)
+	})
+
+	group.Go(func() error {
+		return a.listener.Run(ctx)
+	})
+
 	return group.Wait()
 }
 
@@ -94,9 +184,23 @@ func (a *App) startGRPC(server protoUserService.UserServiceServer) error {
 		a.logger.Fatalf("failed to create listener: %v", err)
 	}
 
-	var serverOptions []grpc.ServerOption
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			grpcv1.AuthInterceptor(a.verifier),
+			grpcv1.AuditInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			grpcv1.AuthStreamInterceptor(a.verifier),
+			grpcv1.AuditStreamInterceptor(),
+		),
+	}
 	a.grpcServer = grpc.NewServer(serverOptions...)
 	protoUserService.RegisterUserServiceServer(a.grpcServer, server)
+	usersv1.RegisterUsersServiceServer(a.grpcServer, a.usersServer)
+	usersv1.RegisterOrganizationsServiceServer(a.grpcServer, a.organizationsServer)
+	usersv1.RegisterGroupsServiceServer(a.grpcServer, a.groupsServer)
+	usersv1.RegisterAuthzServiceServer(a.grpcServer, a.authzServer)
+	usersv1.RegisterEventsServiceServer(a.grpcServer, a.eventsServer)
 	reflection.Register(a.grpcServer)
 
 	a.logger.Info("gRPC server started")
@@ -130,7 +234,8 @@ func (a *App) startHTTP() error {
 		ExposedHeaders:   a.cfg.HTTP.CORS.ExposedHeaders,
 	})
 
-	handler := c.Handler(a.router)
+	handler := c.Handler(rest.AuthMiddleware(
+		rest.AuditMiddleware(a.router, a.cfg.HTTP.TrustProxyHeaders), a.verifier))
 
 	a.httpServer = &http.Server{
 		Handler: handler,
@@ -161,5 +266,9 @@ func (a *App) Close() error {
 
 	a.grpcServer.GracefulStop()
 
+	if pubErr := a.publisher.Close(); pubErr != nil {
+		a.logger.Errorf("failed to close event publisher: %v", pubErr)
+	}
+
 	return err
 }
@@ -2,9 +2,19 @@ package apperror
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 )
 
+const (
+	unauthorizedCode       = "US-000401"
+	forbiddenCode          = "US-000403"
+	conflictCode           = "US-000409"
+	preconditionFailedCode = "US-000412"
+	tooLargeCode           = "US-000413"
+	tooManyRequestsCode    = "US-000429"
+)
+
 var (
 	ErrNotFound = NewAppError("US-000404", "not found", "not found")
 )
@@ -45,6 +55,60 @@ func BadRequestError(message string) *AppError {
 	return NewAppError("US-000400", message, "something wrong with user data")
 }
 
+func UnauthorizedError(message string) *AppError {
+	return NewAppError(unauthorizedCode, message, "further credentials are required")
+}
+
+func IsUnauthorized(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == unauthorizedCode
+}
+
+func ForbiddenError(message string) *AppError {
+	return NewAppError(forbiddenCode, message, "action is not allowed for the user")
+}
+
+func IsForbidden(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == forbiddenCode
+}
+
+func ConflictError(message string) *AppError {
+	return NewAppError(conflictCode, message, "request conflicts with the current state of the resource")
+}
+
+func IsConflict(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == conflictCode
+}
+
+func PreconditionFailedError(message string) *AppError {
+	return NewAppError(preconditionFailedCode, message, "resource version does not match the expected one")
+}
+
+func IsPreconditionFailed(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == preconditionFailedCode
+}
+
+func TooLargeError(message string) *AppError {
+	return NewAppError(tooLargeCode, message, "request payload exceeds the allowed size")
+}
+
+func IsTooLarge(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == tooLargeCode
+}
+
+func TooManyRequestsError(message string) *AppError {
+	return NewAppError(tooManyRequestsCode, message, "rate limit exceeded")
+}
+
+func IsTooManyRequests(err error) bool {
+	var appErr *AppError
+	return errors.As(err, &appErr) && appErr.Code == tooManyRequestsCode
+}
+
 func systemError(developerMessage string) *AppError {
 	return NewAppError("US-000418", "internal system error", developerMessage)
 }
@@ -20,6 +20,36 @@ func Middleware(h appHandler) http.HandlerFunc {
 					_, _ = w.Write(ErrNotFound.Marshal())
 					return
 				}
+				if IsUnauthorized(err) {
+					w.WriteHeader(http.StatusUnauthorized)
+					_, _ = w.Write(appErr.Marshal())
+					return
+				}
+				if IsForbidden(err) {
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write(appErr.Marshal())
+					return
+				}
+				if IsConflict(err) {
+					w.WriteHeader(http.StatusConflict)
+					_, _ = w.Write(appErr.Marshal())
+					return
+				}
+				if IsPreconditionFailed(err) {
+					w.WriteHeader(http.StatusPreconditionFailed)
+					_, _ = w.Write(appErr.Marshal())
+					return
+				}
+				if IsTooLarge(err) {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					_, _ = w.Write(appErr.Marshal())
+					return
+				}
+				if IsTooManyRequests(err) {
+					w.WriteHeader(http.StatusTooManyRequests)
+					_, _ = w.Write(appErr.Marshal())
+					return
+				}
 
 				w.WriteHeader(http.StatusBadRequest)
 				_, _ = w.Write(appErr.Marshal())
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"Users/internal/config"
+	"Users/internal/user/controller"
+	"Users/internal/user/domain/service"
+	"Users/internal/user/repository/postgres"
+	"Users/pkg/blob"
+	"Users/pkg/logging"
+	"Users/pkg/mail"
+	"Users/pkg/postgresql"
+	"Users/pkg/sms"
+	"context"
+	"fmt"
+)
+
+func newUserService(ctx context.Context, cfg *config.Config, logger *logging.Logger) (controller.Service, func(), error) {
+	postgresClient, err := postgresql.NewClient(ctx, 5, *cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to init storage: %w", err)
+	}
+
+	blobStorage, err := blob.NewStorage(ctx, *cfg)
+	if err != nil {
+		postgresClient.Close()
+		return nil, nil, fmt.Errorf("failed to init blob storage: %w", err)
+	}
+
+	mailer, err := mail.NewSender(*cfg, logger)
+	if err != nil {
+		postgresClient.Close()
+		return nil, nil, fmt.Errorf("failed to init mail sender: %w", err)
+	}
+
+	smsSender, err := sms.NewSender(*cfg, logger)
+	if err != nil {
+		postgresClient.Close()
+		return nil, nil, fmt.Errorf("failed to init sms sender: %w", err)
+	}
+
+	// commands do not watch events, the listener is never run
+	eventsListener := postgresql.NewListener(postgresClient, postgres.EventsChannel, logger)
+
+	userStorage := postgres.NewRepository(postgresql.NewTenantClient(postgresClient), logger)
+	return service.NewService(userStorage, blobStorage, mailer, smsSender, eventsListener,
+		service.NewOptions(*cfg), logger), postgresClient.Close, nil
+}
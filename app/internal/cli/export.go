@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"Users/internal/config"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/logging"
+	"Users/pkg/tenant"
+	"Users/pkg/utils"
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Export runs the "export" subcommand:
+//
+//	app export -format csv|ndjson|parquet -tenant uuid [-columns uuid,name] [-organization uuid]
+//		[-name ...] [-email ...] [-{created,updated,last-login}-{after,before} RFC3339] -o file
+//
+// The output goes to a file because the logger writes to stdout.
+func Export(ctx context.Context, cfg *config.Config, logger *logging.Logger, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", string(dto.ExportFormatCSV), "output format: csv, ndjson or parquet")
+	columns := flags.String("columns", "", "comma separated columns: "+strings.Join(dto.ExportColumns, ","))
+	tenantID := flags.String("tenant", "", "tenant uuid to export the users of, required")
+	organization := flags.String("organization", "", "organization uuid to export the members of")
+	name := flags.String("name", "", "name substring")
+	email := flags.String("email", "", "email substring")
+	var filter dto.UserFilter
+	timeFlags := map[string]*time.Time{
+		"created-after":     &filter.CreatedAfter,
+		"created-before":    &filter.CreatedBefore,
+		"updated-after":     &filter.UpdatedAfter,
+		"updated-before":    &filter.UpdatedBefore,
+		"last-login-after":  &filter.LastLoginAfter,
+		"last-login-before": &filter.LastLoginBefore,
+	}
+	for name, value := range timeFlags {
+		flags.Func(name, "RFC 3339 timestamp", func(s string) error {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return fmt.Errorf("must be an RFC 3339 timestamp")
+			}
+			*value = t
+			return nil
+		})
+	}
+	output := flags.String("o", "", "output file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *output == "" {
+		return fmt.Errorf("output file must be set with -o")
+	}
+	if !utils.IsValidUUID(*tenantID) {
+		return fmt.Errorf("tenant must be set to a uuid with -tenant")
+	}
+
+	filter.OrganizationUUID = *organization
+	filter.Name = *name
+	filter.Email = *email
+	input := dto.ExportUsersDTO{
+		Format: dto.ExportFormat(*format),
+		Filter: filter,
+	}
+	if *columns != "" {
+		input.Columns = strings.Split(*columns, ",")
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", *output, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Errorf("failed to close %s: %v", *output, err)
+		}
+	}()
+	buffered := bufio.NewWriter(file)
+
+	userService, closeStorage, err := newUserService(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	if err = userService.Export(tenant.WithID(ctx, *tenantID), input, buffered); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
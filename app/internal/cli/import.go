@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"Users/internal/config"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/logging"
+	"Users/pkg/tenant"
+	"Users/pkg/utils"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Import runs the "import" subcommand:
+//
+//	app import [-format csv|ndjson] -tenant uuid [-dry-run] <file|->
+func Import(ctx context.Context, cfg *config.Config, logger *logging.Logger, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "input format: csv or ndjson (defaults to the file extension)")
+	tenantID := flags.String("tenant", "", "tenant uuid to import the users into, required")
+	dryRun := flags.Bool("dry-run", false, "validate rows without saving them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: import [-format csv|ndjson] -tenant uuid [-dry-run] <file|->")
+	}
+	if !utils.IsValidUUID(*tenantID) {
+		return fmt.Errorf("tenant must be set to a uuid with -tenant")
+	}
+	path := flags.Arg(0)
+
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			*format = string(dto.ImportFormatCSV)
+		case ".ndjson", ".jsonl":
+			*format = string(dto.ImportFormatNDJSON)
+		default:
+			return fmt.Errorf("cannot detect format of %q, use -format", path)
+		}
+	}
+
+	var source io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				logger.Errorf("failed to close %s: %v", path, err)
+			}
+		}()
+		source = file
+	}
+
+	userService, closeStorage, err := newUserService(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	report, err := userService.Import(tenant.WithID(ctx, *tenantID), dto.ImportUsersDTO{
+		Format: dto.ImportFormat(*format),
+		DryRun: *dryRun,
+		Source: source,
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
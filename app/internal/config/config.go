@@ -4,6 +4,7 @@ import (
 	"Users/pkg/logging"
 	"github.com/ilyakaznacheev/cleanenv"
 	"sync"
+	"time"
 )
 
 type Config struct {
@@ -15,6 +16,16 @@ type Config struct {
 		Password string `yaml:"password"`
 	} `yaml:"postgres" env-required:"true"`
 
+	Auth struct {
+		// Secret verifies the HS256 signature of the bearer tokens, the tenant and the
+		// subject of a request are taken from them.
+		Secret   string `yaml:"secret" env:"AUTH_SECRET" env-required:"true"`
+		Issuer   string `yaml:"issuer"`
+		Audience string `yaml:"audience"`
+		// AdminScope is the scope a token needs for the /api/admin endpoints and their RPCs.
+		AdminScope string `yaml:"admin_scope" env-default:"users:admin"`
+	} `yaml:"auth"`
+
 	GRPC struct {
 		IP   string `yaml:"ip"`
 		Port int    `yaml:"port"`
@@ -30,7 +41,132 @@ type Config struct {
 			AllowedHeaders   []string `yaml:"allowed_headers"`
 			ExposedHeaders   []string `yaml:"exposed_headers"`
 		} `yaml:"cors"`
+		// PublicURL is the address clients reach the HTTP API at, links in emails start with it.
+		PublicURL string `yaml:"public_url" env-default:"http://localhost:10001"`
+		// TrustProxyHeaders takes the client address from X-Forwarded-For, only safe behind a proxy setting it.
+		TrustProxyHeaders bool `yaml:"trust_proxy_headers"`
 	} `yaml:"http"`
+
+	Users struct {
+		DeletedGracePeriod time.Duration `yaml:"deleted_grace_period" env-default:"720h"`
+		PurgeInterval      time.Duration `yaml:"purge_interval" env-default:"1h"`
+		// new users stay pending until an admin or a verification flow activates them
+		RequireVerification bool `yaml:"require_verification"`
+		// EmailChangeTTL is how long the new address can be confirmed.
+		EmailChangeTTL time.Duration `yaml:"email_change_ttl" env-default:"24h"`
+		// EmailChangeRevertPeriod is how long the old address can revert an email change.
+		EmailChangeRevertPeriod time.Duration `yaml:"email_change_revert_period" env-default:"168h"`
+		// UsernameReclaimPeriod is how long a released username is kept from other users.
+		UsernameReclaimPeriod time.Duration `yaml:"username_reclaim_period" env-default:"720h"`
+	} `yaml:"users"`
+
+	Organizations struct {
+		// InvitationTTL is how long an invitation to an organization can be accepted.
+		InvitationTTL time.Duration `yaml:"invitation_ttl" env-default:"168h"`
+	} `yaml:"organizations"`
+
+	Mail struct {
+		// Driver is either log, which only logs messages, or smtp
+		Driver string `yaml:"driver" env-default:"log"`
+		From   string `yaml:"from" env-default:"no-reply@localhost"`
+		SMTP   struct {
+			Host     string `yaml:"host"`
+			Port     int    `yaml:"port" env-default:"587"`
+			Username string `yaml:"username" env:"MAIL_SMTP_USERNAME"`
+			Password string `yaml:"password" env:"MAIL_SMTP_PASSWORD"`
+		} `yaml:"smtp"`
+	} `yaml:"mail"`
+
+	SMS struct {
+		// Driver is either log, which only logs messages, or file, which appends them to File.Path
+		Driver string `yaml:"driver" env-default:"log"`
+		File   struct {
+			Path string `yaml:"path" env-default:"data/sms.ndjson"`
+		} `yaml:"file"`
+	} `yaml:"sms"`
+
+	Phone struct {
+		// CodeTTL is how long a one-time code sent by SMS can be used.
+		CodeTTL time.Duration `yaml:"code_ttl" env-default:"10m"`
+		// CodeMaxAttempts is how many wrong guesses invalidate a code.
+		CodeMaxAttempts int `yaml:"code_max_attempts" env-default:"5"`
+		// ResendInterval is the least time between two codes sent to a user or a number.
+		ResendInterval time.Duration `yaml:"resend_interval" env-default:"1m"`
+		// MaxSendsPerHour limits the codes sent to a user or a number within an hour.
+		MaxSendsPerHour int `yaml:"max_sends_per_hour" env-default:"5"`
+	} `yaml:"phone"`
+
+	Authz struct {
+		// CheckCacheTTL bounds how stale a cached check result can be on other instances,
+		// zero disables the cache.
+		CheckCacheTTL time.Duration `yaml:"check_cache_ttl" env-default:"10s"`
+		// CheckCacheSize is the number of check results kept in memory.
+		CheckCacheSize int `yaml:"check_cache_size" env-default:"10000"`
+	} `yaml:"authz"`
+
+	Events struct {
+		// Driver is log, which only logs events, file, which appends them to File.Path, kafka or nats
+		Driver string `yaml:"driver" env-default:"log"`
+		// Encoding of the events sent to kafka and nats, either json or protobuf
+		Encoding string `yaml:"encoding" env-default:"json"`
+		File     struct {
+			Path string `yaml:"path" env-default:"data/events.ndjson"`
+		} `yaml:"file"`
+		Kafka struct {
+			Brokers []string `yaml:"brokers" env-default:"localhost:9092"`
+			Topic   string   `yaml:"topic" env-default:"users.events"`
+		} `yaml:"kafka"`
+		NATS struct {
+			URL string `yaml:"url" env-default:"nats://localhost:4222"`
+			// Subject is followed by the event type, e.g. users.events.UserCreated. A JetStream
+			// stream has to capture the subjects.
+			Subject string `yaml:"subject" env-default:"users.events"`
+		} `yaml:"nats"`
+		// RelayInterval is how often the outbox is polled for events to publish.
+		RelayInterval time.Duration `yaml:"relay_interval" env-default:"1s"`
+		// RelayBatchSize is the most events of a tenant published per poll.
+		RelayBatchSize int `yaml:"relay_batch_size" env-default:"100"`
+		// Retention is how long published events are kept in the outbox.
+		Retention time.Duration `yaml:"retention" env-default:"168h"`
+	} `yaml:"events"`
+
+	Webhooks struct {
+		// DispatchInterval is how often due deliveries are sent.
+		DispatchInterval time.Duration `yaml:"dispatch_interval" env-default:"1s"`
+		// DispatchBatchSize is the most deliveries of a tenant sent per poll.
+		DispatchBatchSize int `yaml:"dispatch_batch_size" env-default:"50"`
+		// Timeout bounds a single delivery request.
+		Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+		// MaxAttempts failed attempts move a delivery to the dead letter state.
+		MaxAttempts int `yaml:"max_attempts" env-default:"8"`
+		// InitialBackoff is the wait after the first failed attempt, it doubles after every
+		// further failure up to MaxBackoff.
+		InitialBackoff time.Duration `yaml:"initial_backoff" env-default:"30s"`
+		MaxBackoff     time.Duration `yaml:"max_backoff" env-default:"6h"`
+	} `yaml:"webhooks"`
+
+	Avatars struct {
+		// BaseURL is the public address of the HTTP API that avatar URLs point to.
+		BaseURL string `yaml:"base_url" env-default:"http://localhost:10001"`
+		MaxSize int64  `yaml:"max_size" env-default:"5242880"`
+		Sizes   []int  `yaml:"sizes" env-default:"64,128,256,512"`
+	} `yaml:"avatars"`
+
+	Blob struct {
+		// Driver is either local or s3
+		Driver string `yaml:"driver" env-default:"local"`
+		Local  struct {
+			Path string `yaml:"path" env-default:"data/blobs"`
+		} `yaml:"local"`
+		S3 struct {
+			Endpoint  string `yaml:"endpoint"`
+			Region    string `yaml:"region"`
+			Bucket    string `yaml:"bucket"`
+			AccessKey string `yaml:"access_key" env:"BLOB_S3_ACCESS_KEY"`
+			SecretKey string `yaml:"secret_key" env:"BLOB_S3_SECRET_KEY"`
+			UseSSL    bool   `yaml:"use_ssl"`
+		} `yaml:"s3"`
+	} `yaml:"blob"`
 }
 
 var instance *Config
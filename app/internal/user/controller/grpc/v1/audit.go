@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"Users/pkg/audit"
+	"Users/pkg/auth"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+)
+
+const (
+	requestIDMetadataKey = "x-request-id"
+
+	maxRequestIDLength = 128
+)
+
+// AuditInterceptor records the source of the call for the audit log: the subject of the
+// bearer token AuthInterceptor authenticated it with, the peer address and user agent and
+// the x-request-id metadata, which is generated when missing and returned in the response
+// header.
+func AuditInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		source, err := auditSource(ctx)
+		if err != nil {
+			return nil, err
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadataKey, source.RequestID))
+		return handler(audit.WithSource(ctx, source), req)
+	}
+}
+
+// AuditStreamInterceptor is the AuditInterceptor of streaming calls.
+func AuditStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		source, err := auditSource(ss.Context())
+		if err != nil {
+			return err
+		}
+		_ = ss.SetHeader(metadata.Pairs(requestIDMetadataKey, source.RequestID))
+		return handler(srv, &serverStream{ServerStream: ss, ctx: audit.WithSource(ss.Context(), source)})
+	}
+}
+
+func auditSource(ctx context.Context) (audit.Source, error) {
+	var source audit.Source
+	if identity, ok := auth.FromContext(ctx); ok {
+		source.Actor = identity.Subject
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		source.UserAgent = firstValue(md, "user-agent")
+		source.RequestID = firstValue(md, requestIDMetadataKey)
+	}
+	if source.RequestID == "" || len(source.RequestID) > maxRequestIDLength {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return source, status.Errorf(codes.Internal, "failed to generate request id: %v", err)
+		}
+		source.RequestID = hex.EncodeToString(b)
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		source.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(source.IP); err == nil {
+			source.IP = host
+		}
+	}
+	return source, nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	usersv1 "Users/api/users/v1"
+	"Users/pkg/auth"
+	"Users/pkg/tenant"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+const authorizationMetadataKey = "authorization"
+
+// reflectionMethodPrefix is the service describing the API, it serves no tenant data
+const reflectionMethodPrefix = "/grpc.reflection."
+
+// adminMethods are the RPCs of the /api/admin endpoints, they need a token with the admin scope
+var adminMethods = map[string]struct{}{
+	usersv1.UsersService_ChangeUserStatus_FullMethodName:     {},
+	usersv1.GroupsService_CreateGroup_FullMethodName:         {},
+	usersv1.GroupsService_ListGroups_FullMethodName:          {},
+	usersv1.GroupsService_GetGroup_FullMethodName:            {},
+	usersv1.GroupsService_UpdateGroup_FullMethodName:         {},
+	usersv1.GroupsService_DeleteGroup_FullMethodName:         {},
+	usersv1.GroupsService_ListGroupMembers_FullMethodName:    {},
+	usersv1.GroupsService_AddGroupMember_FullMethodName:      {},
+	usersv1.GroupsService_RemoveGroupMember_FullMethodName:   {},
+	usersv1.GroupsService_ListSubgroups_FullMethodName:       {},
+	usersv1.GroupsService_AddSubgroup_FullMethodName:         {},
+	usersv1.GroupsService_RemoveSubgroup_FullMethodName:      {},
+	usersv1.AuthzService_ListRelationTuples_FullMethodName:   {},
+	usersv1.AuthzService_WriteRelationTuples_FullMethodName:  {},
+	usersv1.AuthzService_DeleteRelationTuples_FullMethodName: {},
+	usersv1.AuthzService_GetSchema_FullMethodName:            {},
+	usersv1.AuthzService_UpdateSchema_FullMethodName:         {},
+	usersv1.EventsService_WatchEvents_FullMethodName:         {},
+}
+
+// AuthInterceptor authenticates the call by the bearer token of its authorization metadata
+// and scopes it to the tenant of the token, calls without a valid token are rejected.
+// The admin RPCs also require the admin scope in the token.
+func AuthInterceptor(verifier *auth.Verifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authContext(ctx, verifier, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor is the AuthInterceptor of streaming calls.
+func AuthStreamInterceptor(verifier *auth.Verifier) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, reflectionMethodPrefix) {
+			return handler(srv, ss)
+		}
+		ctx, err := authContext(ss.Context(), verifier, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authContext(ctx context.Context, verifier *auth.Verifier, method string) (context.Context, error) {
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		header = firstValue(md, authorizationMetadataKey)
+	}
+	token, ok := auth.BearerToken(header)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "bearer token required")
+	}
+	identity, err := verifier.Verify(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+	if _, ok := adminMethods[method]; ok && !identity.Admin {
+		return nil, status.Error(codes.PermissionDenied, "the token does not carry the admin scope")
+	}
+	return auth.WithIdentity(tenant.WithID(ctx, identity.TenantID), identity), nil
+}
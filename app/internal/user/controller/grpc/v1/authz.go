@@ -0,0 +1,156 @@
+package grpc
+
+import (
+	usersv1 "Users/api/users/v1"
+	"Users/internal/user/controller"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/logging"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxSchemaSize bounds the source of an authorization schema, as the HTTP API does
+const maxSchemaSize = 1 << 20
+
+type AuthzServer struct {
+	usersv1.UnimplementedAuthzServiceServer
+	service controller.Service
+	logger  *logging.Logger
+}
+
+func NewAuthzServer(userService controller.Service, logger *logging.Logger) *AuthzServer {
+	return &AuthzServer{
+		service: userService,
+		logger:  logger,
+	}
+}
+
+func (s *AuthzServer) Check(ctx context.Context, req *usersv1.CheckRequest) (*usersv1.CheckResponse, error) {
+	s.logger.Debug("Check relation")
+	input := dto.CheckDTO{Object: req.Object, Relation: req.Relation, Subject: req.Subject}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := s.service.Check(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.CheckResponse{Allowed: result.Allowed}, nil
+}
+
+func (s *AuthzServer) Expand(ctx context.Context, req *usersv1.ExpandRequest) (*usersv1.SubjectTree, error) {
+	s.logger.Debug("Expand relation")
+	input := dto.ExpandDTO{Object: req.Object, Relation: req.Relation}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	tree, err := s.service.Expand(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return NewProtoSubjectTree(tree), nil
+}
+
+func (s *AuthzServer) ListObjects(
+	ctx context.Context, req *usersv1.ListObjectsRequest,
+) (*usersv1.ListObjectsResponse, error) {
+	s.logger.Debug("List objects")
+	input := dto.ListObjectsDTO{Namespace: req.Namespace, Relation: req.Relation, Subject: req.Subject}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	objects, err := s.service.ListObjects(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.ListObjectsResponse{Objects: objects.Objects}, nil
+}
+
+func (s *AuthzServer) ListRelationTuples(
+	ctx context.Context, req *usersv1.ListRelationTuplesRequest,
+) (*usersv1.ListRelationTuplesResponse, error) {
+	s.logger.Debug("Get relation tuples")
+	if req.Object == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "object must not be empty")
+	}
+
+	tuples, err := s.service.GetTuples(ctx, req.Object, req.Relation)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	resp := &usersv1.ListRelationTuplesResponse{Tuples: make([]*usersv1.RelationTuple, 0, len(tuples))}
+	for _, tuple := range tuples {
+		resp.Tuples = append(resp.Tuples, &usersv1.RelationTuple{
+			Object:   tuple.Object.String(),
+			Relation: tuple.Relation,
+			Subject:  tuple.Subject.String(),
+		})
+	}
+	return resp, nil
+}
+
+func (s *AuthzServer) WriteRelationTuples(
+	ctx context.Context, req *usersv1.WriteRelationTuplesRequest,
+) (*usersv1.WriteRelationTuplesResponse, error) {
+	s.logger.Debug("Write relation tuples")
+	input := NewRelationTuplesDTO(req.Tuples)
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	err := s.service.WriteTuples(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.WriteRelationTuplesResponse{}, nil
+}
+
+func (s *AuthzServer) DeleteRelationTuples(
+	ctx context.Context, req *usersv1.DeleteRelationTuplesRequest,
+) (*usersv1.DeleteRelationTuplesResponse, error) {
+	s.logger.Debug("Delete relation tuples")
+	input := NewRelationTuplesDTO(req.Tuples)
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	err := s.service.DeleteTuples(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.DeleteRelationTuplesResponse{}, nil
+}
+
+func (s *AuthzServer) GetSchema(ctx context.Context, req *usersv1.GetSchemaRequest) (*usersv1.Schema, error) {
+	s.logger.Debug("Get authorization schema")
+	source, err := s.service.GetAuthzSchema(ctx)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.Schema{Source: source}, nil
+}
+
+func (s *AuthzServer) UpdateSchema(ctx context.Context, req *usersv1.Schema) (*usersv1.UpdateSchemaResponse, error) {
+	s.logger.Debug("Update authorization schema")
+	if len(req.Source) > maxSchemaSize {
+		return nil, status.Errorf(codes.InvalidArgument, "schema must not be larger than %d bytes", maxSchemaSize)
+	}
+
+	err := s.service.UpdateAuthzSchema(ctx, req.Source)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.UpdateSchemaResponse{}, nil
+}
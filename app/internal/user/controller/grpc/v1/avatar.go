@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	usersv1 "Users/api/users/v1"
+	"Users/internal/user/domain/dto"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+)
+
+// maxAvatarUploadSize bounds the bytes read from an upload stream, the
+// service then applies the configured avatar size limit.
+const maxAvatarUploadSize = 32 << 20
+
+func (s *UsersServer) UploadAvatar(stream usersv1.UsersService_UploadAvatarServer) error {
+	s.logger.Debug("Upload avatar")
+
+	var input dto.UploadAvatarDTO
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		switch data := req.Data.(type) {
+		case *usersv1.UploadAvatarRequest_Uuid:
+			if input.UUID != "" || len(input.Content) > 0 {
+				return status.Errorf(codes.InvalidArgument, "user's uuid must be sent once, in the first message")
+			}
+			input.UUID = data.Uuid
+		case *usersv1.UploadAvatarRequest_Chunk:
+			if input.UUID == "" {
+				return status.Errorf(codes.InvalidArgument, "user's uuid must be sent in the first message")
+			}
+			if len(input.Content)+len(data.Chunk) > maxAvatarUploadSize {
+				return status.Errorf(codes.ResourceExhausted, "avatar must not be larger than %d bytes",
+					maxAvatarUploadSize)
+			}
+			input.Content = append(input.Content, data.Chunk...)
+		}
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	avatar, err := s.service.UploadAvatar(stream.Context(), input)
+	if err != nil {
+		return HandleServiceError(err)
+	}
+
+	return stream.SendAndClose(&usersv1.Avatar{Id: avatar.ID, Urls: avatar.URLs})
+}
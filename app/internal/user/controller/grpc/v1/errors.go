@@ -14,6 +14,21 @@ func HandleServiceError(err error) error {
 		if errors.Is(err, apperror.ErrNotFound) {
 			return status.Error(codes.NotFound, err.Error())
 		}
+		if apperror.IsUnauthorized(err) {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		if apperror.IsForbidden(err) {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+		if apperror.IsConflict(err) {
+			return status.Error(codes.Aborted, err.Error())
+		}
+		if apperror.IsPreconditionFailed(err) {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if apperror.IsTooLarge(err) || apperror.IsTooManyRequests(err) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
 
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
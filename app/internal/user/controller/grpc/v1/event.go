@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	usersv1 "Users/api/users/v1"
+	"Users/internal/user/controller"
+	"Users/pkg/events"
+	"Users/pkg/logging"
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc/status"
+)
+
+// EventsServer serves the users.v1 events API.
+type EventsServer struct {
+	usersv1.UnimplementedEventsServiceServer
+	service controller.Service
+	logger  *logging.Logger
+}
+
+func NewEventsServer(userService controller.Service, logger *logging.Logger) *EventsServer {
+	return &EventsServer{
+		service: userService,
+		logger:  logger,
+	}
+}
+
+func (s *EventsServer) WatchEvents(
+	req *usersv1.WatchEventsRequest, stream usersv1.EventsService_WatchEventsServer,
+) error {
+	s.logger.Debug("Watch events")
+
+	err := s.service.WatchEvents(stream.Context(), NewWatchEventsDTO(req), func(batch []events.Event) error {
+		protoEvents, err := NewProtoUserEvents(batch)
+		if err != nil {
+			return fmt.Errorf("failed to convert events: %w", err)
+		}
+		return stream.Send(&usersv1.WatchEventsResponse{Events: protoEvents})
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return status.FromContextError(err).Err()
+		}
+		return HandleServiceError(err)
+	}
+	return nil
+}
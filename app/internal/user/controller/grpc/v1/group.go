@@ -0,0 +1,229 @@
+package grpc
+
+import (
+	usersv1 "Users/api/users/v1"
+	"Users/internal/user/controller"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/logging"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type GroupsServer struct {
+	usersv1.UnimplementedGroupsServiceServer
+	service controller.Service
+	logger  *logging.Logger
+}
+
+func NewGroupsServer(userService controller.Service, logger *logging.Logger) *GroupsServer {
+	return &GroupsServer{
+		service: userService,
+		logger:  logger,
+	}
+}
+
+func (s *GroupsServer) CreateGroup(
+	ctx context.Context, req *usersv1.CreateGroupRequest,
+) (*usersv1.CreateGroupResponse, error) {
+	s.logger.Debug("Create group")
+	input := dto.CreateGroupDTO{Name: req.Name, Description: req.Description}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	groupUUID, err := s.service.CreateGroup(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.CreateGroupResponse{Uuid: groupUUID}, nil
+}
+
+func (s *GroupsServer) ListGroups(
+	ctx context.Context, req *usersv1.ListGroupsRequest,
+) (*usersv1.ListGroupsResponse, error) {
+	s.logger.Debug("Get all groups")
+	groups, err := s.service.GetGroups(ctx)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.ListGroupsResponse{Groups: NewProtoGroups(groups)}, nil
+}
+
+func (s *GroupsServer) GetGroup(ctx context.Context, req *usersv1.GetGroupRequest) (*usersv1.Group, error) {
+	s.logger.Debug("Get group")
+	if req.Uuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group uuid must not be empty")
+	}
+
+	group, err := s.service.GetGroup(ctx, req.Uuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return NewProtoGroup(group), nil
+}
+
+func (s *GroupsServer) UpdateGroup(
+	ctx context.Context, req *usersv1.UpdateGroupRequest,
+) (*usersv1.UpdateGroupResponse, error) {
+	s.logger.Debug("Update group")
+	input := dto.UpdateGroupDTO{UUID: req.Uuid, Name: req.Name, Description: req.Description}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	err := s.service.UpdateGroup(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.UpdateGroupResponse{}, nil
+}
+
+func (s *GroupsServer) DeleteGroup(
+	ctx context.Context, req *usersv1.DeleteGroupRequest,
+) (*usersv1.DeleteGroupResponse, error) {
+	s.logger.Debug("Delete group")
+	if req.Uuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group uuid must not be empty")
+	}
+
+	err := s.service.DeleteGroup(ctx, req.Uuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.DeleteGroupResponse{}, nil
+}
+
+func (s *GroupsServer) ListGroupMembers(
+	ctx context.Context, req *usersv1.ListGroupMembersRequest,
+) (*usersv1.ListGroupMembersResponse, error) {
+	s.logger.Debug("Get group members")
+	if req.GroupUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group uuid must not be empty")
+	}
+
+	members, err := s.service.GetGroupMembers(ctx, req.GroupUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	resp := &usersv1.ListGroupMembersResponse{Members: make([]*usersv1.GroupMember, 0, len(members))}
+	for _, member := range members {
+		resp.Members = append(resp.Members, &usersv1.GroupMember{
+			UserUuid: member.UserUUID,
+			Name:     member.Name,
+			Email:    member.Email,
+			Username: member.Username,
+			Direct:   member.Direct,
+		})
+	}
+	return resp, nil
+}
+
+func (s *GroupsServer) AddGroupMember(
+	ctx context.Context, req *usersv1.AddGroupMemberRequest,
+) (*usersv1.AddGroupMemberResponse, error) {
+	s.logger.Debug("Add group member")
+	if req.GroupUuid == "" || req.UserUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group and user uuid must not be empty")
+	}
+
+	err := s.service.AddGroupMember(ctx, req.GroupUuid, req.UserUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.AddGroupMemberResponse{}, nil
+}
+
+func (s *GroupsServer) RemoveGroupMember(
+	ctx context.Context, req *usersv1.RemoveGroupMemberRequest,
+) (*usersv1.RemoveGroupMemberResponse, error) {
+	s.logger.Debug("Remove group member")
+	if req.GroupUuid == "" || req.UserUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group and user uuid must not be empty")
+	}
+
+	err := s.service.RemoveGroupMember(ctx, req.GroupUuid, req.UserUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.RemoveGroupMemberResponse{}, nil
+}
+
+func (s *GroupsServer) ListSubgroups(
+	ctx context.Context, req *usersv1.ListSubgroupsRequest,
+) (*usersv1.ListSubgroupsResponse, error) {
+	s.logger.Debug("Get subgroups")
+	if req.GroupUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group uuid must not be empty")
+	}
+
+	groups, err := s.service.GetSubgroups(ctx, req.GroupUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.ListSubgroupsResponse{Groups: NewProtoGroups(groups)}, nil
+}
+
+func (s *GroupsServer) AddSubgroup(
+	ctx context.Context, req *usersv1.AddSubgroupRequest,
+) (*usersv1.AddSubgroupResponse, error) {
+	s.logger.Debug("Add subgroup")
+	if req.GroupUuid == "" || req.SubgroupUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group and subgroup uuid must not be empty")
+	}
+
+	err := s.service.AddSubgroup(ctx, req.GroupUuid, req.SubgroupUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.AddSubgroupResponse{}, nil
+}
+
+func (s *GroupsServer) RemoveSubgroup(
+	ctx context.Context, req *usersv1.RemoveSubgroupRequest,
+) (*usersv1.RemoveSubgroupResponse, error) {
+	s.logger.Debug("Remove subgroup")
+	if req.GroupUuid == "" || req.SubgroupUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "group and subgroup uuid must not be empty")
+	}
+
+	err := s.service.RemoveSubgroup(ctx, req.GroupUuid, req.SubgroupUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.RemoveSubgroupResponse{}, nil
+}
+
+func (s *GroupsServer) ListUserGroups(
+	ctx context.Context, req *usersv1.ListUserGroupsRequest,
+) (*usersv1.ListUserGroupsResponse, error) {
+	s.logger.Debug("Get user groups")
+	if req.UserUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user uuid must not be empty")
+	}
+
+	groups, err := s.service.GetUserGroups(ctx, req.UserUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	resp := &usersv1.ListUserGroupsResponse{Groups: make([]*usersv1.EffectiveGroup, 0, len(groups))}
+	for _, group := range groups {
+		resp.Groups = append(resp.Groups, &usersv1.EffectiveGroup{
+			Group:  NewProtoGroup(group.Group),
+			Direct: group.Direct,
+		})
+	}
+	return resp, nil
+}
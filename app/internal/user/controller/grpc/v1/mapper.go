@@ -1,9 +1,14 @@
 package grpc
 
 import (
+	usersv1 "Users/api/users/v1"
 	"Users/internal/user/domain/dto"
 	"Users/internal/user/domain/model"
+	"Users/pkg/authz"
+	"Users/pkg/events"
 	protoUserService "github.com/Anton9372/user-service-contracts/gen/go/user_service/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
 )
 
 func NewProtoUser(user model.User) *protoUserService.User {
@@ -39,3 +44,195 @@ func NewUpdateUserDTO(req *protoUserService.UpdateRequest) (dto.UpdateUserDTO, e
 	err := updatedUser.ValidateEmptyFields()
 	return updatedUser, err
 }
+
+// NewMaskedUpdateUserDTO maps an update driven by a field mask, "new_password" is the "password" path.
+func NewMaskedUpdateUserDTO(req *usersv1.UpdateUserRequest) (dto.MaskedUpdateUserDTO, error) {
+	updatedUser := dto.MaskedUpdateUserDTO{
+		UUID:                req.Uuid,
+		Password:            req.Password,
+		UpdateMask:          make([]string, 0, len(req.GetUpdateMask().GetPaths())),
+		Name:                req.Name,
+		Email:               req.Email,
+		Username:            req.Username,
+		NewPassword:         req.NewPassword,
+		RepeatedNewPassword: req.RepeatedNewPassword,
+		ExpectedVersion:     req.ExpectedVersion,
+	}
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		if path == "new_password" {
+			path = "password"
+		}
+		updatedUser.UpdateMask = append(updatedUser.UpdateMask, path)
+	}
+
+	err := updatedUser.ValidateEmptyFields()
+	return updatedUser, err
+}
+
+// NewProtoPublicUser maps a user to the users.v1 API, which never carries the password hash.
+func NewProtoPublicUser(user model.PublicUser) *usersv1.User {
+	return &usersv1.User{
+		Uuid:              user.UUID,
+		Name:              user.Name,
+		Email:             user.Email,
+		Username:          user.Username,
+		Version:           user.Version,
+		Status:            protoStatuses[user.Status],
+		StatusReason:      user.StatusReason,
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+		UpdatedAt:         timestamppb.New(user.UpdatedAt),
+		LastLoginAt:       protoTimestamp(user.LastLoginAt),
+		PasswordChangedAt: protoTimestamp(user.PasswordChangedAt),
+	}
+}
+
+// protoTimestamp maps an unset time to an unset timestamp.
+func protoTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+var protoStatuses = map[model.Status]usersv1.UserStatus{
+	model.StatusPending:   usersv1.UserStatus_USER_STATUS_PENDING,
+	model.StatusActive:    usersv1.UserStatus_USER_STATUS_ACTIVE,
+	model.StatusSuspended: usersv1.UserStatus_USER_STATUS_SUSPENDED,
+	model.StatusLocked:    usersv1.UserStatus_USER_STATUS_LOCKED,
+	model.StatusDeleted:   usersv1.UserStatus_USER_STATUS_DELETED,
+}
+
+func NewChangeUserStatusDTO(req *usersv1.ChangeUserStatusRequest) (dto.ChangeUserStatusDTO, error) {
+	input := dto.ChangeUserStatusDTO{
+		UUID:   req.Uuid,
+		Reason: req.Reason,
+	}
+	for status, protoStatus := range protoStatuses {
+		if protoStatus == req.Status {
+			input.Status = string(status)
+		}
+	}
+	err := input.ValidateEmptyFields()
+	return input, err
+}
+
+func NewProtoPublicUsers(users []model.User) []*usersv1.User {
+	protoUsers := make([]*usersv1.User, 0, len(users))
+	for _, user := range users {
+		protoUsers = append(protoUsers, NewProtoPublicUser(model.NewPublicUser(user)))
+	}
+	return protoUsers
+}
+
+func NewProtoUsersBatch(batch model.UsersBatch) *usersv1.BatchGetUsersResponse {
+	resp := &usersv1.BatchGetUsersResponse{
+		Users:   make([]*usersv1.User, 0, len(batch.Users)),
+		Missing: batch.Missing,
+	}
+	for _, user := range batch.Users {
+		resp.Users = append(resp.Users, NewProtoPublicUser(user))
+	}
+	return resp
+}
+
+func NewStreamUsersDTO(req *usersv1.StreamUsersRequest) dto.StreamUsersDTO {
+	input := dto.StreamUsersDTO{
+		Filter: dto.UserFilter{
+			Name:             req.Name,
+			Email:            req.Email,
+			OrganizationUUID: req.OrganizationUuid,
+		},
+		BatchSize: int(req.BatchSize),
+	}
+	if req.ChangedSince != nil {
+		input.Filter.UpdatedAfter = req.ChangedSince.AsTime()
+	}
+	return input
+}
+
+func NewProtoMembership(membership model.Membership) *usersv1.Membership {
+	return &usersv1.Membership{
+		OrganizationUuid: membership.OrganizationUUID,
+		OrganizationName: membership.OrganizationName,
+		UserUuid:         membership.UserUUID,
+		Role:             string(membership.Role),
+		CreatedAt:        timestamppb.New(membership.CreatedAt),
+		UpdatedAt:        timestamppb.New(membership.UpdatedAt),
+	}
+}
+
+func NewProtoInvitation(invitation model.Invitation) *usersv1.Invitation {
+	return &usersv1.Invitation{
+		Id:               invitation.ID,
+		OrganizationUuid: invitation.OrganizationUUID,
+		Email:            invitation.Email,
+		Role:             string(invitation.Role),
+		InvitedBy:        invitation.InvitedBy,
+		CreatedAt:        timestamppb.New(invitation.CreatedAt),
+		ExpiresAt:        timestamppb.New(invitation.ExpiresAt),
+		AcceptedAt:       protoTimestamp(invitation.AcceptedAt),
+	}
+}
+
+func NewProtoGroup(group model.Group) *usersv1.Group {
+	return &usersv1.Group{
+		Uuid:        group.UUID,
+		Name:        group.Name,
+		Description: group.Description,
+		CreatedAt:   timestamppb.New(group.CreatedAt),
+		UpdatedAt:   timestamppb.New(group.UpdatedAt),
+	}
+}
+
+func NewProtoGroups(groups []model.Group) []*usersv1.Group {
+	protoGroups := make([]*usersv1.Group, 0, len(groups))
+	for _, group := range groups {
+		protoGroups = append(protoGroups, NewProtoGroup(group))
+	}
+	return protoGroups
+}
+
+func NewProtoSubjectTree(node authz.Node) *usersv1.SubjectTree {
+	tree := &usersv1.SubjectTree{
+		Operation: node.Operation,
+		Object:    node.Object.String(),
+		Relation:  node.Relation,
+		Subjects:  node.Subjects,
+	}
+	for _, child := range node.Children {
+		tree.Children = append(tree.Children, NewProtoSubjectTree(child))
+	}
+	return tree
+}
+
+func NewRelationTuplesDTO(tuples []*usersv1.RelationTuple) dto.RelationTuplesDTO {
+	input := dto.RelationTuplesDTO{Tuples: make([]dto.RelationTupleDTO, 0, len(tuples))}
+	for _, tuple := range tuples {
+		input.Tuples = append(input.Tuples, dto.RelationTupleDTO{
+			Object:   tuple.Object,
+			Relation: tuple.Relation,
+			Subject:  tuple.Subject,
+		})
+	}
+	return input
+}
+
+func NewWatchEventsDTO(req *usersv1.WatchEventsRequest) dto.WatchEventsDTO {
+	return dto.WatchEventsDTO{
+		UserUUID:      req.UserUuid,
+		Types:         req.Types,
+		AfterPosition: req.AfterPosition,
+	}
+}
+
+func NewProtoUserEvents(batch []events.Event) ([]*usersv1.UserEvent, error) {
+	protoEvents := make([]*usersv1.UserEvent, 0, len(batch))
+	for _, event := range batch {
+		protoEvent, err := events.NewProto(event)
+		if err != nil {
+			return nil, err
+		}
+		protoEvents = append(protoEvents, protoEvent)
+	}
+	return protoEvents, nil
+}
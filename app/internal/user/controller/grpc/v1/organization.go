@@ -0,0 +1,217 @@
+package grpc
+
+import (
+	usersv1 "Users/api/users/v1"
+	"Users/internal/user/controller"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/logging"
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type OrganizationsServer struct {
+	usersv1.UnimplementedOrganizationsServiceServer
+	service controller.Service
+	logger  *logging.Logger
+}
+
+func NewOrganizationsServer(userService controller.Service, logger *logging.Logger) *OrganizationsServer {
+	return &OrganizationsServer{
+		service: userService,
+		logger:  logger,
+	}
+}
+
+func (s *OrganizationsServer) CreateOrganization(
+	ctx context.Context, req *usersv1.CreateOrganizationRequest,
+) (*usersv1.CreateOrganizationResponse, error) {
+	s.logger.Debug("Create organization")
+	input := dto.CreateOrganizationDTO{Name: req.Name}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	organizationUUID, err := s.service.CreateOrganization(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.CreateOrganizationResponse{Uuid: organizationUUID}, nil
+}
+
+func (s *OrganizationsServer) GetOrganization(
+	ctx context.Context, req *usersv1.GetOrganizationRequest,
+) (*usersv1.Organization, error) {
+	s.logger.Debug("Get organization")
+	if req.Uuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "organization uuid must not be empty")
+	}
+
+	organization, err := s.service.GetOrganization(ctx, req.Uuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.Organization{
+		Uuid:      organization.UUID,
+		Name:      organization.Name,
+		CreatedAt: timestamppb.New(organization.CreatedAt),
+		UpdatedAt: timestamppb.New(organization.UpdatedAt),
+	}, nil
+}
+
+func (s *OrganizationsServer) ListUserOrganizations(
+	ctx context.Context, req *usersv1.ListUserOrganizationsRequest,
+) (*usersv1.ListUserOrganizationsResponse, error) {
+	s.logger.Debug("Get user organizations")
+	if req.UserUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user uuid must not be empty")
+	}
+
+	memberships, err := s.service.GetUserOrganizations(ctx, req.UserUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	resp := &usersv1.ListUserOrganizationsResponse{Memberships: make([]*usersv1.Membership, 0, len(memberships))}
+	for _, membership := range memberships {
+		resp.Memberships = append(resp.Memberships, NewProtoMembership(membership))
+	}
+	return resp, nil
+}
+
+func (s *OrganizationsServer) ListMembers(
+	ctx context.Context, req *usersv1.ListMembersRequest,
+) (*usersv1.ListMembersResponse, error) {
+	s.logger.Debug("Get members")
+	if req.OrganizationUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "organization uuid must not be empty")
+	}
+
+	members, err := s.service.GetMembers(ctx, req.OrganizationUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	resp := &usersv1.ListMembersResponse{Members: make([]*usersv1.Member, 0, len(members))}
+	for _, member := range members {
+		resp.Members = append(resp.Members, &usersv1.Member{
+			UserUuid: member.UserUUID,
+			Name:     member.Name,
+			Email:    member.Email,
+			Username: member.Username,
+			Role:     string(member.Role),
+			JoinedAt: timestamppb.New(member.JoinedAt),
+		})
+	}
+	return resp, nil
+}
+
+func (s *OrganizationsServer) ChangeMemberRole(
+	ctx context.Context, req *usersv1.ChangeMemberRoleRequest,
+) (*usersv1.ChangeMemberRoleResponse, error) {
+	s.logger.Debug("Change member role")
+	input := dto.ChangeMemberRoleDTO{OrganizationUUID: req.OrganizationUuid, UserUUID: req.UserUuid, Role: req.Role}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	err := s.service.ChangeMemberRole(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.ChangeMemberRoleResponse{}, nil
+}
+
+func (s *OrganizationsServer) RemoveMember(
+	ctx context.Context, req *usersv1.RemoveMemberRequest,
+) (*usersv1.RemoveMemberResponse, error) {
+	s.logger.Debug("Remove member")
+	if req.OrganizationUuid == "" || req.UserUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "organization and user uuid must not be empty")
+	}
+
+	err := s.service.RemoveMember(ctx, req.OrganizationUuid, req.UserUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.RemoveMemberResponse{}, nil
+}
+
+func (s *OrganizationsServer) InviteMember(
+	ctx context.Context, req *usersv1.InviteMemberRequest,
+) (*usersv1.Invitation, error) {
+	s.logger.Debug("Invite member")
+	input := dto.InviteMemberDTO{
+		OrganizationUUID: req.OrganizationUuid,
+		Email:            req.Email,
+		Role:             req.Role,
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	invitation, err := s.service.InviteMember(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return NewProtoInvitation(invitation), nil
+}
+
+func (s *OrganizationsServer) ListInvitations(
+	ctx context.Context, req *usersv1.ListInvitationsRequest,
+) (*usersv1.ListInvitationsResponse, error) {
+	s.logger.Debug("Get invitations")
+	if req.OrganizationUuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "organization uuid must not be empty")
+	}
+
+	invitations, err := s.service.GetInvitations(ctx, req.OrganizationUuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	resp := &usersv1.ListInvitationsResponse{Invitations: make([]*usersv1.Invitation, 0, len(invitations))}
+	for _, invitation := range invitations {
+		resp.Invitations = append(resp.Invitations, NewProtoInvitation(invitation))
+	}
+	return resp, nil
+}
+
+func (s *OrganizationsServer) RevokeInvitation(
+	ctx context.Context, req *usersv1.RevokeInvitationRequest,
+) (*usersv1.RevokeInvitationResponse, error) {
+	s.logger.Debug("Revoke invitation")
+	if req.OrganizationUuid == "" || req.Id == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "organization uuid and invitation id must not be empty")
+	}
+
+	err := s.service.RevokeInvitation(ctx, req.OrganizationUuid, req.Id)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.RevokeInvitationResponse{}, nil
+}
+
+func (s *OrganizationsServer) AcceptInvitation(
+	ctx context.Context, req *usersv1.AcceptInvitationRequest,
+) (*usersv1.Membership, error) {
+	s.logger.Debug("Accept invitation")
+	input := dto.AcceptInvitationDTO{Token: req.Token}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	membership, err := s.service.AcceptInvitation(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return NewProtoMembership(membership), nil
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"Users/internal/user/controller"
+	"Users/internal/user/domain/dto"
 	"Users/pkg/logging"
 	"context"
 	protoUserService "github.com/Anton9372/user-service-contracts/gen/go/user_service/v1"
@@ -73,7 +74,8 @@ func (s *Server) GetByEmailAndPassword(
 		return nil, status.Errorf(codes.InvalidArgument, "password must not be empty")
 	}
 
-	user, err := s.service.GetByEmailAndPassword(ctx, req.Email, req.Password)
+	// the email field also takes a username or a phone until the contracts get login and code fields
+	user, err := s.service.GetByLoginAndPassword(ctx, dto.LoginDTO{Login: req.Email, Password: req.Password})
 	if err != nil {
 		return nil, HandleServiceError(err)
 	}
@@ -106,7 +108,7 @@ func (s *Server) Delete(
 		return nil, status.Errorf(codes.InvalidArgument, "user's uuid must not be empty")
 	}
 
-	err := s.service.Delete(ctx, req.Uuid)
+	err := s.service.Delete(ctx, dto.DeleteUserDTO{UUID: req.Uuid})
 	if err != nil {
 		return nil, HandleServiceError(err)
 	}
@@ -0,0 +1,16 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+)
+
+// serverStream replaces the context of a stream, the way unary interceptors pass a new context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	usersv1 "Users/api/users/v1"
+	"Users/internal/user/controller"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/logging"
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UsersServer serves the users.v1 API, the operations missing from the contracts.
+type UsersServer struct {
+	usersv1.UnimplementedUsersServiceServer
+	service controller.Service
+	logger  *logging.Logger
+}
+
+func NewUsersServer(userService controller.Service, logger *logging.Logger) *UsersServer {
+	return &UsersServer{
+		service: userService,
+		logger:  logger,
+	}
+}
+
+func (s *UsersServer) StreamUsers(
+	req *usersv1.StreamUsersRequest, stream usersv1.UsersService_StreamUsersServer,
+) error {
+	s.logger.Debug("Stream users")
+
+	// Send blocks while the client does not receive, so the cursor is read at its pace
+	err := s.service.Stream(stream.Context(), NewStreamUsersDTO(req), func(users []model.User) error {
+		return stream.Send(&usersv1.StreamUsersResponse{Users: NewProtoPublicUsers(users)})
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return status.FromContextError(err).Err()
+		}
+		return HandleServiceError(err)
+	}
+	return nil
+}
+
+func (s *UsersServer) BatchGetUsers(
+	ctx context.Context, req *usersv1.BatchGetUsersRequest,
+) (*usersv1.BatchGetUsersResponse, error) {
+	s.logger.Debug("Get users by uuids")
+
+	batch, err := s.service.GetByUUIDs(ctx, dto.GetUsersByUUIDsDTO{UUIDs: req.Uuids})
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return NewProtoUsersBatch(batch), nil
+}
+
+func (s *UsersServer) GetUser(ctx context.Context, req *usersv1.GetUserRequest) (*usersv1.GetUserResponse, error) {
+	s.logger.Debug("Get user")
+	if req.Uuid == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user's uuid is required")
+	}
+
+	user, err := s.service.GetByUUID(ctx, req.Uuid)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.GetUserResponse{User: NewProtoPublicUser(model.NewPublicUser(user))}, nil
+}
+
+func (s *UsersServer) ChangeUserStatus(
+	ctx context.Context, req *usersv1.ChangeUserStatusRequest,
+) (*usersv1.ChangeUserStatusResponse, error) {
+	s.logger.Debug("Change user status")
+	input, err := NewChangeUserStatusDTO(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	err = s.service.ChangeStatus(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.ChangeUserStatusResponse{}, nil
+}
+
+func (s *UsersServer) UpdateUser(
+	ctx context.Context, req *usersv1.UpdateUserRequest,
+) (*usersv1.UpdateUserResponse, error) {
+	s.logger.Debug("Update user by field mask")
+	input, err := NewMaskedUpdateUserDTO(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	err = s.service.UpdateMasked(ctx, input)
+	if err != nil {
+		return nil, HandleServiceError(err)
+	}
+
+	return &usersv1.UpdateUserResponse{}, nil
+}
@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/pkg/audit"
+	"Users/pkg/auth"
+	"Users/pkg/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// AuditMiddleware records the source of the request for the audit log: the subject of the
+// bearer token AuthMiddleware authenticated it with, the client address and user agent and
+// the X-Request-ID header, which is generated when missing and returned with the response.
+// The client address is taken from X-Forwarded-For only when trustProxy is set.
+func AuditMiddleware(next http.Handler, trustProxy bool) http.Handler {
+	return apperror.Middleware(func(w http.ResponseWriter, r *http.Request) error {
+		identity, _ := auth.FromContext(r.Context())
+
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			var err error
+			if requestID, err = newRequestID(); err != nil {
+				return err
+			}
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		source := audit.Source{
+			Actor:     identity.Subject,
+			IP:        clientIP(r, trustProxy),
+			UserAgent: r.UserAgent(),
+			RequestID: requestID,
+		}
+		next.ServeHTTP(w, r.WithContext(audit.WithSource(r.Context(), source)))
+		return nil
+	})
+}
+
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		// the first address is the client, the others the proxies in between
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func newRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate request id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GetAuditLog
+// @Summary 	Get audit log
+// @Description Returns a page of the audit log of the tenant, newest first. The next page starts
+// @Description before the id of the last entry. Password changes are recorded without their values
+// @Tags 		Admin
+// @Produce 	json
+// @Param 		actor 		query 	 string 	false  "Actor, the subject of the bearer token or anonymous for the email change links"
+// @Param 		action 		query 	 string 	false  "Action, e.g. user.updated or login.failed"
+// @Param 		target_uuid query 	 string 	false  "Uuid of the user acted on"
+// @Param 		from 		query 	 string 	false  "RFC 3339 timestamp, inclusive"
+// @Param 		to 			query 	 string 	false  "RFC 3339 timestamp, exclusive"
+// @Param 		before 		query 	 int 		false  "Id of the last entry of the previous page"
+// @Param 		limit 		query 	 int 		false  "Page size, 50 by default and 200 at most"
+// @Success 	200		{object} []user.AuditEntry "Audit log entries"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/audit	[get]
+func (h *handler) GetAuditLog(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get audit log")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	filter, err := parseAuditFilter(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.service.GetAuditLog(r.Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	entriesBytes, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshall audit log. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(entriesBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get audit log successfully")
+	return nil
+}
+
+// VerifyAuditLog
+// @Summary 	Verify audit log
+// @Description Recomputes the hash chain of the audit log of the tenant. An entry changed or removed
+// @Description breaks the chain, entries removed from the end are only revealed by comparing last_hash
+// @Description with one kept elsewhere
+// @Tags 		Admin
+// @Produce 	json
+// @Success 	200		{object} user.AuditVerification "Result of the verification"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/audit/verify	[get]
+func (h *handler) VerifyAuditLog(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Verify audit log")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	verification, err := h.service.VerifyAuditLog(r.Context())
+	if err != nil {
+		return err
+	}
+
+	verificationBytes, err := json.Marshal(verification)
+	if err != nil {
+		return fmt.Errorf("failed to marshall audit verification. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(verificationBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Verify audit log successfully")
+	return nil
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/pkg/auth"
+	"Users/pkg/metric"
+	"Users/pkg/tenant"
+	"Users/pkg/utils"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// tenantParam names the tenant in links mailed to users, which are opened without a token
+const tenantParam = "tenant"
+
+// adminPathPrefix serves the operations that need a token with the admin scope
+const adminPathPrefix = "/api/admin/"
+
+// publicPathPrefixes serve no tenant data and need no token
+var publicPathPrefixes = []string{"/swagger", metric.URL, "/api/avatars/"}
+
+// AuthMiddleware authenticates the request by the bearer token of its Authorization header
+// and scopes it to the tenant of the token, requests without a valid token are rejected.
+// The admin endpoints also require the admin scope in the token.
+// The email change links are opened without a token: they name their tenant in the
+// tenant parameter and the secret token they carry, which only matches in that tenant,
+// authorizes them.
+func AuthMiddleware(next http.Handler, verifier *auth.Verifier) http.Handler {
+	return apperror.Middleware(func(w http.ResponseWriter, r *http.Request) error {
+		for _, prefix := range publicPathPrefixes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return nil
+			}
+		}
+
+		if r.URL.Path == confirmEmailChangeURL || r.URL.Path == revertEmailChangeURL {
+			id := r.URL.Query().Get(tenantParam)
+			if !utils.IsValidUUID(id) {
+				return apperror.BadRequestError(fmt.Sprintf("%s must be a uuid", tenantParam))
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), id)))
+			return nil
+		}
+
+		token, _ := auth.BearerToken(r.Header.Get("Authorization"))
+		identity, err := verifier.Verify(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			return apperror.UnauthorizedError("a valid bearer token is required")
+		}
+		if strings.HasPrefix(r.URL.Path, adminPathPrefix) && !identity.Admin {
+			return apperror.ForbiddenError("the token does not carry the admin scope")
+		}
+
+		ctx := auth.WithIdentity(tenant.WithID(r.Context(), identity.TenantID), identity)
+		next.ServeHTTP(w, r.WithContext(ctx))
+		return nil
+	})
+}
@@ -0,0 +1,322 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Check
+// @Summary 	Check relation
+// @Description Checks whether the subject (user:42 or a userset such as group:admins#member) has the
+// @Description relation on the object (document:readme), following the rewrites of the schema
+// @Tags 		Authorization
+// @Accept		json
+// @Produce 	json
+// @Param 		input	body 	 user.CheckDTO 	true  "Object, relation and subject"
+// @Success 	200		{object} user.CheckResult "Result"
+// @Failure 	400 	{object} apperror.AppError "Validation error or relation not declared"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/authz/check	[post]
+func (h *handler) Check(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Check relation")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.CheckDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	result, err := h.service.Check(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshall check result. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(resultBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Check relation successfully")
+	return nil
+}
+
+// Expand
+// @Summary 	Expand relation
+// @Description Returns the tree of subjects that have the relation on the object
+// @Tags 		Authorization
+// @Accept		json
+// @Produce 	json
+// @Param 		input	body 	 user.ExpandDTO 	true  "Object and relation"
+// @Success 	200		{object} authz.Node "Subject tree"
+// @Failure 	400 	{object} apperror.AppError "Validation error or relation not declared"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/authz/expand	[post]
+func (h *handler) Expand(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Expand relation")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.ExpandDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	tree, err := h.service.Expand(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	treeBytes, err := json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("failed to marshall subject tree. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(treeBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Expand relation successfully")
+	return nil
+}
+
+// ListObjects
+// @Summary 	List objects
+// @Description Lists the objects of the namespace the subject has the relation on
+// @Tags 		Authorization
+// @Accept		json
+// @Produce 	json
+// @Param 		input	body 	 user.ListObjectsDTO 	true  "Namespace, relation and subject"
+// @Success 	200		{object} user.ObjectList "Objects"
+// @Failure 	400 	{object} apperror.AppError "Validation error or relation not declared"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/authz/list-objects	[post]
+func (h *handler) ListObjects(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("List objects")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.ListObjectsDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	objects, err := h.service.ListObjects(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	objectsBytes, err := json.Marshal(objects)
+	if err != nil {
+		return fmt.Errorf("failed to marshall objects. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(objectsBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("List objects successfully")
+	return nil
+}
+
+// GetTuples
+// @Summary 	Get relation tuples
+// @Description Lists the tuples of the object, of all its relations unless one is given
+// @Tags 		Admin
+// @Produce 	json
+// @Param 		object 	 query 	 string 	true  "Object, e.g. document:readme"
+// @Param 		relation query 	 string 	false "Relation"
+// @Success 	200		{object} []authz.Tuple "Tuples"
+// @Failure 	400 	{object} apperror.AppError "Invalid object"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/authz/tuples	[get]
+func (h *handler) GetTuples(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get relation tuples")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	object := r.URL.Query().Get("object")
+	if object == "" {
+		return apperror.BadRequestError("object must not be empty")
+	}
+
+	tuples, err := h.service.GetTuples(r.Context(), object, r.URL.Query().Get("relation"))
+	if err != nil {
+		return err
+	}
+
+	tuplesBytes, err := json.Marshal(tuples)
+	if err != nil {
+		return fmt.Errorf("failed to marshall relation tuples. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(tuplesBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get relation tuples successfully")
+	return nil
+}
+
+// WriteTuples
+// @Summary 	Write relation tuples
+// @Description Writes the tuples, tuples that already exist are left as they are
+// @Tags 		Admin
+// @Accept		json
+// @Param 		input	body 	 user.RelationTuplesDTO 	true  "Tuples"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error or tuple not allowed by the schema"
+// @Failure 	413 	{object} apperror.AppError "Too many tuples"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/authz/tuples	[post]
+func (h *handler) WriteTuples(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Write relation tuples")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.RelationTuplesDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := h.service.WriteTuples(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Write relation tuples successfully")
+	return nil
+}
+
+// DeleteTuples
+// @Summary 	Delete relation tuples
+// @Description Deletes the tuples, tuples that do not exist are ignored
+// @Tags 		Admin
+// @Accept		json
+// @Param 		input	body 	 user.RelationTuplesDTO 	true  "Tuples"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	413 	{object} apperror.AppError "Too many tuples"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/authz/tuples	[delete]
+func (h *handler) DeleteTuples(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Delete relation tuples")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.RelationTuplesDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := h.service.DeleteTuples(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Delete relation tuples successfully")
+	return nil
+}
+
+// GetAuthzSchema
+// @Summary 	Get authorization schema
+// @Description Returns the source of the schema declaring namespaces, relations and their rewrites
+// @Tags 		Admin
+// @Produce 	plain
+// @Success 	200		{string} string "Schema source"
+// @Failure 	404 	{object} apperror.AppError "No schema saved"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/authz/schema	[get]
+func (h *handler) GetAuthzSchema(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get authorization schema")
+	defer utils.CloseBody(h.logger, r.Body)
+
+	source, err := h.service.GetAuthzSchema(r.Context())
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = io.WriteString(w, source)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get authorization schema successfully")
+	return nil
+}
+
+// UpdateAuthzSchema
+// @Summary 	Update authorization schema
+// @Description Replaces the schema, e.g.
+// @Description namespace document { relation owner relation viewer = this | owner | parent->viewer }.
+// @Description Tuples of relations the new schema does not declare are ignored by checks
+// @Tags 		Admin
+// @Accept		plain
+// @Param 		input	body 	 string 	true  "Schema source"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Invalid schema"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/authz/schema	[put]
+func (h *handler) UpdateAuthzSchema(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Update authorization schema")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	source, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSchemaSize))
+	if err != nil {
+		return apperror.BadRequestError("failed to read authorization schema")
+	}
+
+	err = h.service.UpdateAuthzSchema(r.Context(), string(source))
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Update authorization schema successfully")
+	return nil
+}
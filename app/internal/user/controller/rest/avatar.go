@@ -0,0 +1,184 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// UploadAvatar
+// @Summary 	Upload avatar
+// @Description Uploads a JPEG, PNG, GIF or WebP image as the "avatar" part of a multipart form.
+// @Description The image is cropped to a square, resized to the configured thumbnail sizes and
+// @Description re-encoded as JPEG without metadata. The profile avatar_url points to the largest thumbnail
+// @Tags 		Profile
+// @Accept		multipart/form-data
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "User's uuid"
+// @Param 		avatar 	formData file 		true  "Image"
+// @Success 	201		{object} user.Avatar "Thumbnail URLs by size"
+// @Failure 	400 	{object} apperror.AppError "Not a supported image"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	413 	{object} apperror.AppError "Image is too large"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/avatar	[post]
+func (h *handler) UploadAvatar(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Upload avatar")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAvatarSize)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return apperror.BadRequestError("avatar must be uploaded as multipart/form-data")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input := dto.UploadAvatarDTO{UUID: params.ByName("uuid")}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return avatarReadError(err)
+		}
+		if part.FormName() != "avatar" {
+			continue
+		}
+		if input.Content, err = io.ReadAll(part); err != nil {
+			return avatarReadError(err)
+		}
+		break
+	}
+	if err = input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	avatar, err := h.service.UploadAvatar(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	avatarBytes, err := json.Marshal(avatar)
+	if err != nil {
+		return fmt.Errorf("failed to marshall avatar. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, err = w.Write(avatarBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Upload avatar successfully")
+	return nil
+}
+
+func avatarReadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return apperror.TooLargeError(fmt.Sprintf("avatar upload must not be larger than %d bytes", maxBytesErr.Limit))
+	}
+	return apperror.BadRequestError("failed to read avatar upload")
+}
+
+// DeleteAvatar
+// @Summary 	Delete avatar
+// @Description Deletes the uploaded avatar and its thumbnails
+// @Tags 		Profile
+// @Param 		uuid 	path 	 string 	true  "User's uuid"
+// @Success 	204
+// @Failure 	404 	{object} apperror.AppError "User or avatar not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/avatar	[delete]
+func (h *handler) DeleteAvatar(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Delete avatar")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("uuid")
+	if userUUID == "" {
+		return apperror.BadRequestError("user uuid must not be empty")
+	}
+
+	err := h.service.DeleteAvatar(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Delete avatar successfully")
+	return nil
+}
+
+// GetAvatar
+// @Summary 	Get avatar thumbnail
+// @Description Serves an avatar thumbnail. Thumbnail URLs are content addressed and cached forever
+// @Tags 		Profile
+// @Produce 	image/jpeg
+// @Param 		uuid 	path 	 string 	true  "User's uuid"
+// @Param 		id 		path 	 string 	true  "Avatar id"
+// @Param 		file 	path 	 string 	true  "Thumbnail size with the .jpg extension, e.g. 256.jpg"
+// @Success 	200
+// @Success 	304		"Thumbnail not modified"
+// @Failure 	404 	{object} apperror.AppError "Thumbnail not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/avatars/{uuid}/{id}/{file}	[get]
+func (h *handler) GetAvatar(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get avatar")
+	defer utils.CloseBody(h.logger, r.Body)
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	avatarID := params.ByName("id")
+	sizeText, ok := strings.CutSuffix(params.ByName("file"), ".jpg")
+	size, err := strconv.Atoi(sizeText)
+	if !ok || err != nil {
+		return apperror.ErrNotFound
+	}
+
+	etag := fmt.Sprintf(`"%s-%d"`, avatarID, size)
+	if matchesIfNoneMatch(r, etag) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		h.logger.Info("Get avatar not modified")
+		return nil
+	}
+
+	image, err := h.service.OpenAvatar(r.Context(), params.ByName("uuid"), avatarID, size)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := image.Content.Close(); err != nil {
+			h.logger.Errorf("failed to close avatar: %v", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", image.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(image.Size, 10))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", image.ModTime.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+	if _, err = io.Copy(w, image.Content); err != nil {
+		// headers are already sent, the client sees a truncated image
+		h.logger.Errorf("failed to send avatar: %v", err)
+		return nil
+	}
+
+	h.logger.Info("Get avatar successfully")
+	return nil
+}
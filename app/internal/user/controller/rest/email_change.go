@@ -0,0 +1,198 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/model"
+	"Users/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// emailChangePage is served for the mailed links. Opening a link changes nothing,
+// the page posts the token back, so link scanners and prefetching cannot act on it.
+var emailChangePage = template.Must(template.New("email-change").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="referrer" content="no-referrer">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Text}}</p>
+{{if .Button}}<form method="post" action="{{.Action}}"><button type="submit">{{.Button}}</button></form>{{end}}
+</body>
+</html>
+`))
+
+type emailChangePageData struct {
+	Title  string
+	Text   string
+	Button string
+	Action string
+}
+
+func (h *handler) writeEmailChangePage(w http.ResponseWriter, status int, data emailChangePageData) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// the page URL carries the token
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+	return emailChangePage.Execute(w, data)
+}
+
+// writeEmailChange answers the form of the page with a page, other clients with JSON.
+func (h *handler) writeEmailChange(w http.ResponseWriter, r *http.Request, change model.EmailChange,
+	page emailChangePageData) error {
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		return h.writeEmailChangePage(w, http.StatusOK, page)
+	}
+
+	changeBytes, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("failed to marshall email change. error: %w", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(changeBytes)
+	return err
+}
+
+// ConfirmEmailChangePage
+// @Summary 	Email change confirmation page
+// @Description The page of the link mailed to the new address, it posts the token to confirm the change
+// @Tags 		User
+// @Produce 	html
+// @Param 		token 	query 	 string 	true  "Confirmation token"
+// @Param 		tenant 	query 	 string 	true  "Tenant of the mailed link"
+// @Success 	200		"Confirmation page"
+// @Failure 	400 	{object} apperror.AppError "Token is empty"
+// @Router 		/email-change/confirm	[get]
+func (h *handler) ConfirmEmailChangePage(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Confirm email change page")
+	defer utils.CloseBody(h.logger, r.Body)
+
+	if r.URL.Query().Get("token") == "" {
+		return apperror.BadRequestError("token must not be empty")
+	}
+
+	return h.writeEmailChangePage(w, http.StatusOK, emailChangePageData{
+		Title:  "Confirm your new email address",
+		Text:   "Confirm that this address becomes the email address of your account.",
+		Button: "Confirm",
+		Action: r.URL.RequestURI(),
+	})
+}
+
+// ConfirmEmailChange
+// @Summary 	Confirm email change
+// @Description Switches the user to the new email, the token is the one mailed to the new address
+// @Tags 		User
+// @Accept		x-www-form-urlencoded
+// @Produce 	json
+// @Param 		token 	query 	 string 	true  "Confirmation token, also accepted as a form field"
+// @Param 		tenant 	query 	 string 	true  "Tenant of the mailed link"
+// @Success 	200		{object} user.EmailChange "Confirmed email change"
+// @Failure 	400 	{object} apperror.AppError "Token is empty or the new email is taken"
+// @Failure 	404 	{object} apperror.AppError "Unknown, expired or superseded token"
+// @Failure 	409 	{object} apperror.AppError "User email has been changed since the request"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/email-change/confirm	[post]
+func (h *handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Confirm email change")
+	defer utils.CloseBody(h.logger, r.Body)
+
+	token := r.FormValue("token")
+	if token == "" {
+		return apperror.BadRequestError("token must not be empty")
+	}
+
+	change, err := h.service.ConfirmEmailChange(r.Context(), token)
+	if err != nil {
+		return err
+	}
+
+	err = h.writeEmailChange(w, r, change, emailChangePageData{
+		Title: "Email address changed",
+		Text:  fmt.Sprintf("The email address of your account is now %s.", change.NewEmail),
+	})
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Confirm email change successfully")
+	return nil
+}
+
+// RevertEmailChangePage
+// @Summary 	Email change revert page
+// @Description The page of the link mailed to the old address, it posts the token to revert the change
+// @Tags 		User
+// @Produce 	html
+// @Param 		token 	query 	 string 	true  "Revert token"
+// @Param 		tenant 	query 	 string 	true  "Tenant of the mailed link"
+// @Success 	200		"Revert page"
+// @Failure 	400 	{object} apperror.AppError "Token is empty"
+// @Router 		/email-change/revert	[get]
+func (h *handler) RevertEmailChangePage(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Revert email change page")
+	defer utils.CloseBody(h.logger, r.Body)
+
+	if r.URL.Query().Get("token") == "" {
+		return apperror.BadRequestError("token must not be empty")
+	}
+
+	return h.writeEmailChangePage(w, http.StatusOK, emailChangePageData{
+		Title:  "Revert the email address change",
+		Text:   "Keep this address as the email address of your account.",
+		Button: "Revert",
+		Action: r.URL.RequestURI(),
+	})
+}
+
+// RevertEmailChange
+// @Summary 	Revert email change
+// @Description Cancels a pending email change or switches the user back to the old email,
+// @Description the token is the one mailed to the old address
+// @Tags 		User
+// @Accept		x-www-form-urlencoded
+// @Produce 	json
+// @Param 		token 	query 	 string 	true  "Revert token, also accepted as a form field"
+// @Param 		tenant 	query 	 string 	true  "Tenant of the mailed link"
+// @Success 	200		{object} user.EmailChange "Reverted email change"
+// @Failure 	400 	{object} apperror.AppError "Token is empty or the old email is taken"
+// @Failure 	404 	{object} apperror.AppError "Unknown or expired token"
+// @Failure 	409 	{object} apperror.AppError "User email has been changed again since"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/email-change/revert	[post]
+func (h *handler) RevertEmailChange(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Revert email change")
+	defer utils.CloseBody(h.logger, r.Body)
+
+	token := r.FormValue("token")
+	if token == "" {
+		return apperror.BadRequestError("token must not be empty")
+	}
+
+	change, err := h.service.RevertEmailChange(r.Context(), token)
+	if err != nil {
+		return err
+	}
+
+	err = h.writeEmailChange(w, r, change, emailChangePageData{
+		Title: "Email address change reverted",
+		Text:  fmt.Sprintf("The email address of your account is %s.", change.OldEmail),
+	})
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Revert email change successfully")
+	return nil
+}
@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/model"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// userETag is a strong entity tag derived from the user's version.
+func userETag(user model.User) string {
+	return `"` + strconv.FormatInt(user.Version, 10) + `"`
+}
+
+// parseIfMatch returns the user version required by the If-Match header,
+// nil if the header is absent or "*".
+func parseIfMatch(r *http.Request) (*int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+	if strings.Contains(value, ",") {
+		return nil, apperror.BadRequestError("If-Match must contain a single entity tag")
+	}
+	if strings.HasPrefix(value, "W/") {
+		return nil, apperror.BadRequestError("If-Match requires a strong entity tag")
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+	if err != nil {
+		return nil, apperror.PreconditionFailedError("If-Match does not match any user version")
+	}
+	return &version, nil
+}
+
+// matchesIfNoneMatch reports whether the If-None-Match header matches etag
+// using the weak comparison.
+func matchesIfNoneMatch(r *http.Request, etag string) bool {
+	value := r.Header.Get("If-None-Match")
+	if value == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(value, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
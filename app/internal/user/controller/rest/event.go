@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"Users/pkg/events"
+	"Users/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WatchEvents
+// @Summary 	Watch user events
+// @Description Streams the user events of the tenant as Server-Sent Events as they happen, on any replica.
+// @Description Events are sent in the order their changes were committed. Every event has its position as
+// @Description id, its type as event name and the event as JSON data. A reconnecting EventSource resumes after
+// @Description the Last-Event-ID it sends, as long as the event is kept in the outbox.
+// @Description Comments are sent on idle streams to keep the connection open
+// @Tags 		Admin
+// @Produce 	text/event-stream
+// @Param 		user_uuid 		query 	 string 	false  "Only events of this user"
+// @Param 		type 			query 	 string 	false  "Comma separated event types among UserCreated, UserUpdated, UserDeleted, UserRestored, UserPurged, UserStatusChanged, PasswordChanged and EmailChanged"
+// @Param 		last_event_id 	query 	 int 		false  "Resume after the event at this position, the Last-Event-ID header takes precedence"
+// @Success 	200		{object} events.Event "Stream of events"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/events/stream	[get]
+func (h *handler) WatchEvents(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Watch events")
+	defer utils.CloseBody(h.logger, r.Body)
+
+	filter, err := parseWatchFilter(r.URL.Query(), r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		return err
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warnf("failed to disable write deadline: %v", err)
+	}
+
+	started := false
+	err = h.service.WatchEvents(r.Context(), filter, func(batch []events.Event) error {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("X-Accel-Buffering", "no")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if len(batch) == 0 {
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		for _, event := range batch {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("failed to marshall event. error: %w", err)
+			}
+			if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Position, event.Type, data); err != nil {
+				return err
+			}
+		}
+		return rc.Flush()
+	})
+	if err != nil {
+		if started {
+			// headers are already sent, the client reconnects and resumes
+			h.logger.Errorf("events stream interrupted: %v", err)
+			return nil
+		}
+		return err
+	}
+
+	h.logger.Info("Watch events finished")
+	return nil
+}
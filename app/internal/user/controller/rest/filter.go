@@ -0,0 +1,127 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseUserFilter reads the listing filter from query parameters organization, name,
+// email and the created_, updated_ and last_login_ after/before pairs.
+func parseUserFilter(query url.Values) (dto.UserFilter, error) {
+	filter := dto.UserFilter{
+		Name:             query.Get("name"),
+		Email:            query.Get("email"),
+		OrganizationUUID: query.Get("organization"),
+	}
+
+	timeParams := map[string]*time.Time{
+		"created_after":     &filter.CreatedAfter,
+		"created_before":    &filter.CreatedBefore,
+		"updated_after":     &filter.UpdatedAfter,
+		"updated_before":    &filter.UpdatedBefore,
+		"last_login_after":  &filter.LastLoginAfter,
+		"last_login_before": &filter.LastLoginBefore,
+	}
+	for name, value := range timeParams {
+		t, err := parseTimeParam(query, name)
+		if err != nil {
+			return dto.UserFilter{}, err
+		}
+		*value = t
+	}
+	return filter, nil
+}
+
+func parseTimeParam(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, apperror.BadRequestError(name + " must be an RFC 3339 timestamp")
+	}
+	return t, nil
+}
+
+// parseDeliveryFilter reads the page of webhook deliveries from query parameters status,
+// before and limit.
+func parseDeliveryFilter(query url.Values) (dto.DeliveryFilter, error) {
+	filter := dto.DeliveryFilter{
+		Status: query.Get("status"),
+	}
+	if value := query.Get("before"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return dto.DeliveryFilter{}, apperror.BadRequestError("before must be a delivery id")
+		}
+		filter.Before = before
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return dto.DeliveryFilter{}, apperror.BadRequestError("limit must be a number")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// parseAuditFilter reads the page of the audit log from query parameters actor, action,
+// target_uuid, from, to, before and limit.
+func parseAuditFilter(query url.Values) (dto.AuditFilter, error) {
+	filter := dto.AuditFilter{
+		Actor:      query.Get("actor"),
+		Action:     query.Get("action"),
+		TargetUUID: query.Get("target_uuid"),
+	}
+	var err error
+	if filter.From, err = parseTimeParam(query, "from"); err != nil {
+		return dto.AuditFilter{}, err
+	}
+	if filter.To, err = parseTimeParam(query, "to"); err != nil {
+		return dto.AuditFilter{}, err
+	}
+	if value := query.Get("before"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return dto.AuditFilter{}, apperror.BadRequestError("before must be an audit entry id")
+		}
+		filter.Before = before
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return dto.AuditFilter{}, apperror.BadRequestError("limit must be a number")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// parseWatchFilter reads the event filter from query parameters user_uuid and type, a
+// comma separated list of event types. The watch resumes after the Last-Event-ID header
+// a reconnecting EventSource sends, or else after query parameter last_event_id.
+func parseWatchFilter(query url.Values, lastEventID string) (dto.WatchEventsDTO, error) {
+	filter := dto.WatchEventsDTO{
+		UserUUID: query.Get("user_uuid"),
+	}
+	if value := query.Get("type"); value != "" {
+		filter.Types = strings.Split(value, ",")
+	}
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+	if lastEventID != "" {
+		position, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			return dto.WatchEventsDTO{}, apperror.BadRequestError("last event id must be an event position")
+		}
+		filter.AfterPosition = position
+	}
+	return filter, nil
+}
@@ -0,0 +1,454 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// CreateGroup
+// @Summary 	Create group
+// @Description Creates a group of users, e.g. beta testers. Group names are unique
+// @Tags 		Admin
+// @Accept		json
+// @Param 		input	body 	 user.CreateGroupDTO	true	"Name and description"
+// @Success 	201
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	409 	{object} apperror.AppError "Group name taken"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups	[post]
+func (h *handler) CreateGroup(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Create group")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.CreateGroupDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	groupUUID, err := h.service.CreateGroup(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s/%s", adminGroupsURL, groupUUID))
+	w.WriteHeader(http.StatusCreated)
+
+	h.logger.Info("Create group successfully")
+	return nil
+}
+
+// GetGroups
+// @Summary 	Get all groups
+// @Description Lists the groups ordered by name
+// @Tags 		Admin
+// @Produce 	json
+// @Success 	200		{object} []user.Group "Groups"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups	[get]
+func (h *handler) GetGroups(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get groups")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	groups, err := h.service.GetGroups(r.Context())
+	if err != nil {
+		return err
+	}
+
+	groupsBytes, err := json.Marshal(groups)
+	if err != nil {
+		return fmt.Errorf("failed to marshall groups. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(groupsBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get groups successfully")
+	return nil
+}
+
+// GetGroup
+// @Summary 	Get group
+// @Description Get group by uuid
+// @Tags 		Admin
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "Group's uuid"
+// @Success 	200		{object} user.Group "Group"
+// @Failure 	404 	{object} apperror.AppError "Group not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups/{uuid}	[get]
+func (h *handler) GetGroup(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get group")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	groupUUID := params.ByName("uuid")
+	if groupUUID == "" {
+		return apperror.BadRequestError("group uuid must not be empty")
+	}
+
+	group, err := h.service.GetGroup(r.Context(), groupUUID)
+	if err != nil {
+		return err
+	}
+
+	groupBytes, err := json.Marshal(group)
+	if err != nil {
+		return fmt.Errorf("failed to marshall group. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(groupBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get group successfully")
+	return nil
+}
+
+// UpdateGroup
+// @Summary 	Update group
+// @Description Renames the group and replaces its description
+// @Tags 		Admin
+// @Accept		json
+// @Param 		uuid 	path 	 string 				true  "Group's uuid"
+// @Param 		input	body 	 user.UpdateGroupDTO	true  "Name and description"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	404 	{object} apperror.AppError "Group not found"
+// @Failure 	409 	{object} apperror.AppError "Group name taken"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups/{uuid}	[put]
+func (h *handler) UpdateGroup(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Update group")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.UpdateGroupDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input.UUID = params.ByName("uuid")
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := h.service.UpdateGroup(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Update group successfully")
+	return nil
+}
+
+// DeleteGroup
+// @Summary 	Delete group
+// @Description Deletes the group together with its memberships and nestings, its members and subgroups are kept
+// @Tags 		Admin
+// @Param 		uuid 	path 	 string 	true  "Group's uuid"
+// @Success 	204
+// @Failure 	404 	{object} apperror.AppError "Group not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups/{uuid}	[delete]
+func (h *handler) DeleteGroup(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Delete group")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	groupUUID := params.ByName("uuid")
+	if groupUUID == "" {
+		return apperror.BadRequestError("group uuid must not be empty")
+	}
+
+	err := h.service.DeleteGroup(r.Context(), groupUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Delete group successfully")
+	return nil
+}
+
+// GetGroupMembers
+// @Summary 	Get group members
+// @Description Lists the members of the group and, transitively, of its subgroups. Direct tells the direct members
+// @Tags 		Admin
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "Group's uuid"
+// @Success 	200		{object} []user.GroupMember "Members"
+// @Failure 	404 	{object} apperror.AppError "Group not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups/{uuid}/members	[get]
+func (h *handler) GetGroupMembers(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get group members")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	groupUUID := params.ByName("uuid")
+	if groupUUID == "" {
+		return apperror.BadRequestError("group uuid must not be empty")
+	}
+
+	members, err := h.service.GetGroupMembers(r.Context(), groupUUID)
+	if err != nil {
+		return err
+	}
+
+	membersBytes, err := json.Marshal(members)
+	if err != nil {
+		return fmt.Errorf("failed to marshall group members. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(membersBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get group members successfully")
+	return nil
+}
+
+// AddGroupMember
+// @Summary 	Add group member
+// @Description Adds the user to the group, adding a member twice has no effect
+// @Tags 		Admin
+// @Param 		uuid 			path 	 string 	true  "Group's uuid"
+// @Param 		user_uuid 	path 	 string 	true  "User's uuid"
+// @Success 	204
+// @Failure 	404 	{object} apperror.AppError "Group or user not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups/{uuid}/members/{user_uuid}	[put]
+func (h *handler) AddGroupMember(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Add group member")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	groupUUID := params.ByName("uuid")
+	userUUID := params.ByName("user_uuid")
+	if groupUUID == "" || userUUID == "" {
+		return apperror.BadRequestError("group uuid and user uuid must not be empty")
+	}
+
+	err := h.service.AddGroupMember(r.Context(), groupUUID, userUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Add group member successfully")
+	return nil
+}
+
+// RemoveGroupMember
+// @Summary 	Remove group member
+// @Description Removes a direct member from the group
+// @Tags 		Admin
+// @Param 		uuid 			path 	 string 	true  "Group's uuid"
+// @Param 		user_uuid 	path 	 string 	true  "User's uuid"
+// @Success 	204
+// @Failure 	404 	{object} apperror.AppError "Member not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups/{uuid}/members/{user_uuid}	[delete]
+func (h *handler) RemoveGroupMember(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Remove group member")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	groupUUID := params.ByName("uuid")
+	userUUID := params.ByName("user_uuid")
+	if groupUUID == "" || userUUID == "" {
+		return apperror.BadRequestError("group uuid and user uuid must not be empty")
+	}
+
+	err := h.service.RemoveGroupMember(r.Context(), groupUUID, userUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Remove group member successfully")
+	return nil
+}
+
+// GetSubgroups
+// @Summary 	Get subgroups
+// @Description Lists the groups nested directly in the group
+// @Tags 		Admin
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "Group's uuid"
+// @Success 	200		{object} []user.Group "Subgroups"
+// @Failure 	404 	{object} apperror.AppError "Group not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups/{uuid}/subgroups	[get]
+func (h *handler) GetSubgroups(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get subgroups")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	groupUUID := params.ByName("uuid")
+	if groupUUID == "" {
+		return apperror.BadRequestError("group uuid must not be empty")
+	}
+
+	subgroups, err := h.service.GetSubgroups(r.Context(), groupUUID)
+	if err != nil {
+		return err
+	}
+
+	subgroupsBytes, err := json.Marshal(subgroups)
+	if err != nil {
+		return fmt.Errorf("failed to marshall subgroups. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(subgroupsBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get subgroups successfully")
+	return nil
+}
+
+// AddSubgroup
+// @Summary 	Add subgroup
+// @Description Nests a group in the group, its members become members of the group. A nesting closing a cycle is rejected
+// @Tags 		Admin
+// @Param 		uuid 			path 	 string 	true  "Group's uuid"
+// @Param 		subgroup_uuid 	path 	 string 	true  "Subgroup's uuid"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Group nested in itself"
+// @Failure 	404 	{object} apperror.AppError "Group not found"
+// @Failure 	409 	{object} apperror.AppError "Nesting would create a cycle"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups/{uuid}/subgroups/{subgroup_uuid}	[put]
+func (h *handler) AddSubgroup(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Add subgroup")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	groupUUID := params.ByName("uuid")
+	subgroupUUID := params.ByName("subgroup_uuid")
+	if groupUUID == "" || subgroupUUID == "" {
+		return apperror.BadRequestError("group uuid and subgroup uuid must not be empty")
+	}
+
+	err := h.service.AddSubgroup(r.Context(), groupUUID, subgroupUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Add subgroup successfully")
+	return nil
+}
+
+// RemoveSubgroup
+// @Summary 	Remove subgroup
+// @Description Removes a group nested directly in the group
+// @Tags 		Admin
+// @Param 		uuid 			path 	 string 	true  "Group's uuid"
+// @Param 		subgroup_uuid 	path 	 string 	true  "Subgroup's uuid"
+// @Success 	204
+// @Failure 	404 	{object} apperror.AppError "Subgroup not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/groups/{uuid}/subgroups/{subgroup_uuid}	[delete]
+func (h *handler) RemoveSubgroup(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Remove subgroup")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	groupUUID := params.ByName("uuid")
+	subgroupUUID := params.ByName("subgroup_uuid")
+	if groupUUID == "" || subgroupUUID == "" {
+		return apperror.BadRequestError("group uuid and subgroup uuid must not be empty")
+	}
+
+	err := h.service.RemoveSubgroup(r.Context(), groupUUID, subgroupUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Remove subgroup successfully")
+	return nil
+}
+
+// GetUserGroups
+// @Summary 	Get user's groups
+// @Description Lists the groups the user is a member of, directly or through a subgroup. Direct tells the direct memberships
+// @Tags 		User
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "User's uuid"
+// @Success 	200		{object} []user.EffectiveGroup "Groups"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/groups	[get]
+func (h *handler) GetUserGroups(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get user groups")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("uuid")
+	if userUUID == "" {
+		return apperror.BadRequestError("user uuid must not be empty")
+	}
+
+	groups, err := h.service.GetUserGroups(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+
+	groupsBytes, err := json.Marshal(groups)
+	if err != nil {
+		return fmt.Errorf("failed to marshall groups. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(groupsBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get user groups successfully")
+	return nil
+}
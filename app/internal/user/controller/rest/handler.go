@@ -5,18 +5,79 @@ import (
 	h "Users/internal/handler"
 	"Users/internal/user/controller"
 	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
 	"Users/pkg/logging"
 	"Users/pkg/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
 	usersURL    = "/api/users"
 	userByIdURL = "/api/users/one/:uuid"
 	allUsersURL = "/api/users/all"
+	streamURL   = "/api/users/stream"
+	batchURL    = "/api/users/batch"
+	importURL   = "/api/users/import"
+	exportURL   = "/api/users/export"
+	profileURL  = "/api/users/one/:uuid/profile"
+	avatarURL   = "/api/users/one/:uuid/avatar"
+	// avatarFileURL matches the avatar URLs returned on upload, see model.AvatarKey
+	avatarFileURL = "/api/avatars/:uuid/:id/:file"
+	// the email change URLs are the links mailed on an email change
+	confirmEmailChangeURL = "/api/email-change/confirm"
+	revertEmailChangeURL  = "/api/email-change/revert"
+	usernameURL           = "/api/usernames/:username"
+	phoneURL              = "/api/users/one/:uuid/phone"
+	phoneCodeURL          = "/api/users/one/:uuid/phone/code"
+	phoneVerifyURL        = "/api/users/one/:uuid/phone/verify"
+	phoneSecondFactorURL  = "/api/users/one/:uuid/phone/second-factor"
+	userOrganizationsURL  = "/api/users/one/:uuid/organizations"
+	userGroupsURL         = "/api/users/one/:uuid/groups"
+
+	organizationsURL    = "/api/organizations"
+	organizationURL     = "/api/organizations/:uuid"
+	membersURL          = "/api/organizations/:uuid/members"
+	memberURL           = "/api/organizations/:uuid/members/:user_uuid"
+	invitationsURL      = "/api/organizations/:uuid/invitations"
+	invitationURL       = "/api/organizations/:uuid/invitations/:id"
+	acceptInvitationURL = "/api/invitations/accept"
+
+	adminRestoreUserURL    = "/api/admin/users/:uuid/restore"
+	adminSuspendUserURL    = "/api/admin/users/:uuid/suspend"
+	adminLockUserURL       = "/api/admin/users/:uuid/lock"
+	adminReactivateUserURL = "/api/admin/users/:uuid/reactivate"
+	adminProfileSchemaURL  = "/api/admin/profile-schema"
+	adminGroupsURL         = "/api/admin/groups"
+	adminGroupURL          = "/api/admin/groups/:uuid"
+	adminGroupMembersURL   = "/api/admin/groups/:uuid/members"
+	adminGroupMemberURL    = "/api/admin/groups/:uuid/members/:user_uuid"
+	adminSubgroupsURL      = "/api/admin/groups/:uuid/subgroups"
+	adminSubgroupURL       = "/api/admin/groups/:uuid/subgroups/:subgroup_uuid"
+	authzCheckURL          = "/api/authz/check"
+	authzExpandURL         = "/api/authz/expand"
+	authzListObjectsURL    = "/api/authz/list-objects"
+	adminAuthzTuplesURL    = "/api/admin/authz/tuples"
+	adminAuthzSchemaURL    = "/api/admin/authz/schema"
+	adminWebhooksURL       = "/api/admin/webhooks"
+	adminWebhookURL        = "/api/admin/webhooks/:uuid"
+	adminDeliveriesURL     = "/api/admin/webhooks/:uuid/deliveries"
+	adminRedeliverURL      = "/api/admin/webhooks/:uuid/deliveries/:delivery_id/redeliver"
+	adminEventsStreamURL   = "/api/admin/events/stream"
+	adminAuditURL          = "/api/admin/audit"
+	adminAuditVerifyURL    = "/api/admin/audit/verify"
+
+	maxImportSize = 256 << 20
+	maxPatchSize  = 64 << 10
+	maxSchemaSize = 1 << 20
+	maxAvatarSize = 32 << 20
 )
 
 type handler struct {
@@ -34,10 +95,75 @@ func NewHandler(service controller.Service, logger *logging.Logger) h.Handler {
 func (h *handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodPost, usersURL, apperror.Middleware(h.CreateUser))
 	router.HandlerFunc(http.MethodGet, allUsersURL, apperror.Middleware(h.GetAllUsers))
+	router.HandlerFunc(http.MethodGet, streamURL, apperror.Middleware(h.StreamUsers))
 	router.HandlerFunc(http.MethodGet, userByIdURL, apperror.Middleware(h.GetUserByUUID))
-	router.HandlerFunc(http.MethodGet, usersURL, apperror.Middleware(h.GetUserByEmailAndPassword))
+	router.HandlerFunc(http.MethodPost, batchURL, apperror.Middleware(h.GetUsersByUUIDs))
+	router.HandlerFunc(http.MethodGet, usersURL, apperror.Middleware(h.GetUserByLoginAndPassword))
+	router.HandlerFunc(http.MethodPost, importURL, apperror.Middleware(h.ImportUsers))
+	router.HandlerFunc(http.MethodGet, exportURL, apperror.Middleware(h.ExportUsers))
+	router.HandlerFunc(http.MethodPut, userByIdURL, apperror.Middleware(h.ReplaceUser))
 	router.HandlerFunc(http.MethodPatch, userByIdURL, apperror.Middleware(h.PartiallyUpdateUser))
 	router.HandlerFunc(http.MethodDelete, userByIdURL, apperror.Middleware(h.DeleteUser))
+	router.HandlerFunc(http.MethodGet, profileURL, apperror.Middleware(h.GetProfile))
+	router.HandlerFunc(http.MethodPatch, profileURL, apperror.Middleware(h.PatchProfile))
+	router.HandlerFunc(http.MethodPost, avatarURL, apperror.Middleware(h.UploadAvatar))
+	router.HandlerFunc(http.MethodDelete, avatarURL, apperror.Middleware(h.DeleteAvatar))
+	router.HandlerFunc(http.MethodGet, avatarFileURL, apperror.Middleware(h.GetAvatar))
+	router.HandlerFunc(http.MethodGet, confirmEmailChangeURL, apperror.Middleware(h.ConfirmEmailChangePage))
+	router.HandlerFunc(http.MethodPost, confirmEmailChangeURL, apperror.Middleware(h.ConfirmEmailChange))
+	router.HandlerFunc(http.MethodGet, revertEmailChangeURL, apperror.Middleware(h.RevertEmailChangePage))
+	router.HandlerFunc(http.MethodPost, revertEmailChangeURL, apperror.Middleware(h.RevertEmailChange))
+	router.HandlerFunc(http.MethodGet, usernameURL, apperror.Middleware(h.CheckUsername))
+	router.HandlerFunc(http.MethodPut, phoneURL, apperror.Middleware(h.SetPhone))
+	router.HandlerFunc(http.MethodPost, phoneCodeURL, apperror.Middleware(h.SendPhoneCode))
+	router.HandlerFunc(http.MethodPost, phoneVerifyURL, apperror.Middleware(h.VerifyPhone))
+	router.HandlerFunc(http.MethodPut, phoneSecondFactorURL, apperror.Middleware(h.SetPhoneSecondFactor))
+	router.HandlerFunc(http.MethodGet, userOrganizationsURL, apperror.Middleware(h.GetUserOrganizations))
+	router.HandlerFunc(http.MethodPost, organizationsURL, apperror.Middleware(h.CreateOrganization))
+	router.HandlerFunc(http.MethodGet, organizationURL, apperror.Middleware(h.GetOrganization))
+	router.HandlerFunc(http.MethodGet, membersURL, apperror.Middleware(h.GetMembers))
+	router.HandlerFunc(http.MethodPut, memberURL, apperror.Middleware(h.ChangeMemberRole))
+	router.HandlerFunc(http.MethodDelete, memberURL, apperror.Middleware(h.RemoveMember))
+	router.HandlerFunc(http.MethodPost, invitationsURL, apperror.Middleware(h.InviteMember))
+	router.HandlerFunc(http.MethodGet, invitationsURL, apperror.Middleware(h.GetInvitations))
+	router.HandlerFunc(http.MethodDelete, invitationURL, apperror.Middleware(h.RevokeInvitation))
+	router.HandlerFunc(http.MethodPost, acceptInvitationURL, apperror.Middleware(h.AcceptInvitation))
+	router.HandlerFunc(http.MethodPost, adminRestoreUserURL, apperror.Middleware(h.RestoreUser))
+	router.HandlerFunc(http.MethodPost, adminSuspendUserURL, apperror.Middleware(h.SuspendUser))
+	router.HandlerFunc(http.MethodPost, adminLockUserURL, apperror.Middleware(h.LockUser))
+	router.HandlerFunc(http.MethodPost, adminReactivateUserURL, apperror.Middleware(h.ReactivateUser))
+	router.HandlerFunc(http.MethodGet, adminProfileSchemaURL, apperror.Middleware(h.GetProfileSchema))
+	router.HandlerFunc(http.MethodPut, adminProfileSchemaURL, apperror.Middleware(h.UpdateProfileSchema))
+	router.HandlerFunc(http.MethodGet, userGroupsURL, apperror.Middleware(h.GetUserGroups))
+	router.HandlerFunc(http.MethodPost, adminGroupsURL, apperror.Middleware(h.CreateGroup))
+	router.HandlerFunc(http.MethodGet, adminGroupsURL, apperror.Middleware(h.GetGroups))
+	router.HandlerFunc(http.MethodGet, adminGroupURL, apperror.Middleware(h.GetGroup))
+	router.HandlerFunc(http.MethodPut, adminGroupURL, apperror.Middleware(h.UpdateGroup))
+	router.HandlerFunc(http.MethodDelete, adminGroupURL, apperror.Middleware(h.DeleteGroup))
+	router.HandlerFunc(http.MethodGet, adminGroupMembersURL, apperror.Middleware(h.GetGroupMembers))
+	router.HandlerFunc(http.MethodPut, adminGroupMemberURL, apperror.Middleware(h.AddGroupMember))
+	router.HandlerFunc(http.MethodDelete, adminGroupMemberURL, apperror.Middleware(h.RemoveGroupMember))
+	router.HandlerFunc(http.MethodGet, adminSubgroupsURL, apperror.Middleware(h.GetSubgroups))
+	router.HandlerFunc(http.MethodPut, adminSubgroupURL, apperror.Middleware(h.AddSubgroup))
+	router.HandlerFunc(http.MethodDelete, adminSubgroupURL, apperror.Middleware(h.RemoveSubgroup))
+	router.HandlerFunc(http.MethodPost, authzCheckURL, apperror.Middleware(h.Check))
+	router.HandlerFunc(http.MethodPost, authzExpandURL, apperror.Middleware(h.Expand))
+	router.HandlerFunc(http.MethodPost, authzListObjectsURL, apperror.Middleware(h.ListObjects))
+	router.HandlerFunc(http.MethodGet, adminAuthzTuplesURL, apperror.Middleware(h.GetTuples))
+	router.HandlerFunc(http.MethodPost, adminAuthzTuplesURL, apperror.Middleware(h.WriteTuples))
+	router.HandlerFunc(http.MethodDelete, adminAuthzTuplesURL, apperror.Middleware(h.DeleteTuples))
+	router.HandlerFunc(http.MethodGet, adminAuthzSchemaURL, apperror.Middleware(h.GetAuthzSchema))
+	router.HandlerFunc(http.MethodPut, adminAuthzSchemaURL, apperror.Middleware(h.UpdateAuthzSchema))
+	router.HandlerFunc(http.MethodPost, adminWebhooksURL, apperror.Middleware(h.CreateWebhook))
+	router.HandlerFunc(http.MethodGet, adminWebhooksURL, apperror.Middleware(h.GetWebhooks))
+	router.HandlerFunc(http.MethodGet, adminWebhookURL, apperror.Middleware(h.GetWebhook))
+	router.HandlerFunc(http.MethodPut, adminWebhookURL, apperror.Middleware(h.UpdateWebhook))
+	router.HandlerFunc(http.MethodDelete, adminWebhookURL, apperror.Middleware(h.DeleteWebhook))
+	router.HandlerFunc(http.MethodGet, adminDeliveriesURL, apperror.Middleware(h.GetWebhookDeliveries))
+	router.HandlerFunc(http.MethodPost, adminRedeliverURL, apperror.Middleware(h.RedeliverWebhook))
+	router.HandlerFunc(http.MethodGet, adminEventsStreamURL, apperror.Middleware(h.WatchEvents))
+	router.HandlerFunc(http.MethodGet, adminAuditURL, apperror.Middleware(h.GetAuditLog))
+	router.HandlerFunc(http.MethodGet, adminAuditVerifyURL, apperror.Middleware(h.VerifyAuditLog))
 }
 
 // CreateUser
@@ -83,7 +209,17 @@ func (h *handler) CreateUser(w http.ResponseWriter, r *http.Request) error {
 // @Description Get list of all users
 // @Tags 		User
 // @Produce 	json
+// @Param 		name 			query 	 string 	false  "Name substring"
+// @Param 		email 			query 	 string 	false  "Email substring"
+// @Param 		organization 	query 	 string 	false  "Organization uuid, only its members are returned"
+// @Param 		created_after 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		created_before 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		updated_after 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		updated_before 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		last_login_after 	query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		last_login_before 	query 	 string 	false  "RFC 3339 timestamp"
 // @Success 	200		{object} []user.User "Users list"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
 // @Router 		/users/all 		[get]
@@ -92,7 +228,12 @@ func (h *handler) GetAllUsers(w http.ResponseWriter, r *http.Request) error {
 	defer utils.CloseBody(h.logger, r.Body)
 	w.Header().Set("Content-Type", "application/json")
 
-	users, err := h.service.GetAll(r.Context())
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	users, err := h.service.GetAll(r.Context(), filter)
 	if err != nil {
 		return err
 	}
@@ -112,13 +253,98 @@ func (h *handler) GetAllUsers(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// StreamUsers
+// @Summary 	Stream users
+// @Description Streams users as newline delimited JSON, ordered by update time
+// @Tags 		User
+// @Produce 	application/x-ndjson
+// @Param 		changed_since 	query 	 string 	false  "Only users updated after this RFC 3339 timestamp"
+// @Param 		name 			query 	 string 	false  "Name substring"
+// @Param 		email 			query 	 string 	false  "Email substring"
+// @Param 		organization 	query 	 string 	false  "Organization uuid, only its members are returned"
+// @Param 		created_after 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		created_before 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		updated_before 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		last_login_after 	query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		last_login_before 	query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		batch_size 		query 	 int 		false  "Number of users fetched from the database at once"
+// @Success 	200		{object} user.PublicUser "Stream of users"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/stream	[get]
+func (h *handler) StreamUsers(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Stream users")
+	defer utils.CloseBody(h.logger, r.Body)
+
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	// changed_since is the sync watermark, an alias of updated_after
+	changedSince, err := parseTimeParam(r.URL.Query(), "changed_since")
+	if err != nil {
+		return err
+	}
+	if !changedSince.IsZero() {
+		filter.UpdatedAfter = changedSince
+	}
+
+	input := dto.StreamUsersDTO{Filter: filter}
+	if batchSize := r.URL.Query().Get("batch_size"); batchSize != "" {
+		n, err := strconv.Atoi(batchSize)
+		if err != nil {
+			return apperror.BadRequestError("batch_size must be an integer")
+		}
+		input.BatchSize = n
+	}
+
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warnf("failed to disable write deadline: %v", err)
+	}
+
+	encoder := json.NewEncoder(w)
+	started := false
+	err = h.service.Stream(r.Context(), input, func(users []model.User) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		for _, user := range users {
+			if err := encoder.Encode(model.NewPublicUser(user)); err != nil {
+				return err
+			}
+		}
+		return rc.Flush()
+	})
+	if err != nil {
+		if started {
+			// headers are already sent, the client sees a truncated stream
+			h.logger.Errorf("users stream interrupted: %v", err)
+			return nil
+		}
+		return err
+	}
+	if !started {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	h.logger.Info("Stream users successfully")
+	return nil
+}
+
 // GetUserByUUID
 // @Summary 	Get user by uuid
 // @Description Get user by uuid
 // @Tags 		User
 // @Produce 	json
-// @Param 		uuid 	path 	 string 	true  "User's uuid"
+// @Param 		uuid 			path 	 string 	true  "User's uuid"
+// @Param 		If-None-Match 	header 	 string 	false "ETag of a cached user"
 // @Success 	200		{object} user.User "User"
+// @Success 	304		"User not modified"
 // @Failure 	404 	{object} apperror.AppError "User not found"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
@@ -139,6 +365,14 @@ func (h *handler) GetUserByUUID(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	etag := userETag(user)
+	w.Header().Set("ETag", etag)
+	if matchesIfNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		h.logger.Info("Get user by uuid not modified")
+		return nil
+	}
+
 	userBytes, err := json.Marshal(user)
 	if err != nil {
 		return fmt.Errorf("failed to marshall user. error: %w", err)
@@ -154,33 +388,84 @@ func (h *handler) GetUserByUUID(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-// GetUserByEmailAndPassword
-// @Summary 	Get user by email and password
-// @Description Get user by email and password
+// GetUsersByUUIDs
+// @Summary 	Get users by uuids
+// @Description Get users by a list of uuids. Users are returned in the order of the input,
+// @Description uuids without a matching user are listed in "missing"
 // @Tags 		User
+// @Accept		json
 // @Produce 	json
-// @Param 		email 		path 	 string 	true  "User's email"
-// @Param 		password 	path 	 string 	true  "User's password"
+// @Param 		input	body 	 user.GetUsersByUUIDsDTO	true	"Users' uuids"
+// @Success 	200		{object} user.UsersBatch "Found users and missing uuids"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/batch	[post]
+func (h *handler) GetUsersByUUIDs(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get users by uuids")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.GetUsersByUUIDsDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	batch, err := h.service.GetByUUIDs(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshall users. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(batchBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get users by uuids successfully")
+	return nil
+}
+
+// GetUserByLoginAndPassword
+// @Summary 	Get user by login and password
+// @Description Get user by email, username or verified phone and password. The email parameter is the former
+// @Description name of login. Users having the phone as second factor get 401 and a code by SMS at first,
+// @Description the request is then repeated with the code
+// @Tags 		User
+// @Produce 	json
+// @Param 		login 		query 	 string 	true  "User's email or username"
+// @Param 		password 	query 	 string 	true  "User's password"
+// @Param 		code 		query 	 string 	false "Code sent by SMS to users having the phone as second factor"
 // @Success 	200		{object} user.User "User"
+// @Failure 	401 	{object} apperror.AppError "Second factor required, a code has been sent"
 // @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	429 	{object} apperror.AppError "Too many codes sent"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
 // @Router 		/users	[get]
-func (h *handler) GetUserByEmailAndPassword(w http.ResponseWriter, r *http.Request) error {
-	h.logger.Info("Get user by email and password")
+func (h *handler) GetUserByLoginAndPassword(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get user by login and password")
 	defer utils.CloseBody(h.logger, r.Body)
 	w.Header().Set("Content-Type", "application/json")
 
-	email := r.URL.Query().Get("email")
-	password := r.URL.Query().Get("password")
-	if email == "" {
-		return apperror.BadRequestError("email must not be empty")
+	input := dto.LoginDTO{
+		Login:    r.URL.Query().Get("login"),
+		Password: r.URL.Query().Get("password"),
+		Code:     r.URL.Query().Get("code"),
+	}
+	if input.Login == "" {
+		input.Login = r.URL.Query().Get("email")
 	}
-	if password == "" {
-		return apperror.BadRequestError("password must not be empty")
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
 	}
 
-	user, err := h.service.GetByEmailAndPassword(r.Context(), email, password)
+	user, err := h.service.GetByLoginAndPassword(r.Context(), input)
 	if err != nil {
 		return err
 	}
@@ -196,19 +481,216 @@ func (h *handler) GetUserByEmailAndPassword(w http.ResponseWriter, r *http.Reque
 		return err
 	}
 
-	h.logger.Info("Get user by email and password successfully")
+	h.logger.Info("Get user by login and password successfully")
+	return nil
+}
+
+// ImportUsers
+// @Summary 	Import users
+// @Description Imports users from CSV (header: name,email,password|password_hash) or NDJSON.
+// @Description Passwords are given either in plaintext or as bcrypt/argon2id hashes.
+// @Description Invalid rows are skipped and listed in the report
+// @Tags 		User
+// @Accept		text/csv
+// @Accept		application/x-ndjson
+// @Produce 	json
+// @Param 		format 		query 	 string 	false  "csv or ndjson, taken from Content-Type if omitted"
+// @Param 		dry_run 	query 	 bool 		false  "Validate without saving"
+// @Success 	200		{object} user.ImportReport "Import report"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/import	[post]
+func (h *handler) ImportUsers(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Import users")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	input := dto.ImportUsersDTO{
+		Format: dto.ImportFormat(r.URL.Query().Get("format")),
+		Source: http.MaxBytesReader(w, r.Body, maxImportSize),
+	}
+	if input.Format == "" {
+		switch mediaType(r.Header.Get("Content-Type")) {
+		case "text/csv":
+			input.Format = dto.ImportFormatCSV
+		case "application/x-ndjson", "application/jsonl":
+			input.Format = dto.ImportFormatNDJSON
+		}
+	}
+	if dryRun := r.URL.Query().Get("dry_run"); dryRun != "" {
+		var err error
+		if input.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			return apperror.BadRequestError("dry_run must be a boolean")
+		}
+	}
+
+	// large files take longer than the server's default timeouts
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		h.logger.Warnf("failed to disable read deadline: %v", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warnf("failed to disable write deadline: %v", err)
+	}
+
+	report, err := h.service.Import(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshall import report. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(reportBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Import users successfully")
+	return nil
+}
+
+// ExportUsers
+// @Summary 	Export users
+// @Description Streams users without secrets as CSV, NDJSON or Parquet
+// @Tags 		User
+// @Produce 	text/csv
+// @Produce 	application/x-ndjson
+// @Produce 	application/vnd.apache.parquet
+// @Param 		format 			query 	 string 	true   "csv, ndjson or parquet"
+// @Param 		columns 		query 	 string 	false  "Comma separated columns, all but secrets by default"
+// @Param 		name 			query 	 string 	false  "Name substring"
+// @Param 		email 			query 	 string 	false  "Email substring"
+// @Param 		organization 	query 	 string 	false  "Organization uuid, only its members are returned"
+// @Param 		created_after 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		created_before 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		updated_after 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		updated_before 		query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		last_login_after 	query 	 string 	false  "RFC 3339 timestamp"
+// @Param 		last_login_before 	query 	 string 	false  "RFC 3339 timestamp"
+// @Success 	200
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/export	[get]
+func (h *handler) ExportUsers(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Export users")
+	defer utils.CloseBody(h.logger, r.Body)
+
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		return err
+	}
+	input := dto.ExportUsersDTO{
+		Format: dto.ExportFormat(r.URL.Query().Get("format")),
+		Filter: filter,
+	}
+	if columns := r.URL.Query().Get("columns"); columns != "" {
+		input.Columns = strings.Split(columns, ",")
+	}
+	if err = input.Validate(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	rc := http.NewResponseController(w)
+	if err = rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warnf("failed to disable write deadline: %v", err)
+	}
+
+	// the body is written while the cursor is read, so a failure past the
+	// first bytes can only truncate the response
+	out := &lazyResponseWriter{
+		w:           w,
+		contentType: exportContentTypes[input.Format],
+		filename:    "users." + string(input.Format),
+	}
+	err = h.service.Export(r.Context(), input, out)
+	if err != nil {
+		if out.started {
+			h.logger.Errorf("users export interrupted: %v", err)
+			return nil
+		}
+		return err
+	}
+	out.start()
+
+	h.logger.Info("Export users successfully")
+	return nil
+}
+
+// ReplaceUser
+// @Summary 	Replace user
+// @Description Replaces all writable fields of the user. The password is changed only if new_password is given.
+// @Description A new email takes effect once confirmed through the link mailed to it
+// @Tags 		User
+// @Accept		json
+// @Param 		user_uuid 	path 	 string 			 true  "User's uuid"
+// @Param 		input 		body 	 user.ReplaceUserDTO true  "User's data"
+// @Param 		If-Match 	header 	 string 			 false "ETag of the user being replaced"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	412 	{object} apperror.AppError "User has been changed meanwhile"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router /users/one [put]
+func (h *handler) ReplaceUser(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Replace user")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+
+	var replacedUser dto.ReplaceUserDTO
+	if err := json.NewDecoder(r.Body).Decode(&replacedUser); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	replacedUser.UUID = params.ByName("uuid")
+
+	if err := replacedUser.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+	replacedUser.ExpectedVersion = expectedVersion
+
+	err = h.service.Replace(r.Context(), replacedUser)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Replace user successfully")
 	return nil
 }
 
 // PartiallyUpdateUser
 // @Summary 	Update user
-// @Description Update user
+// @Description Update user. Besides the UpdateUserDTO JSON body it accepts a JSON Merge Patch
+// @Description (application/merge-patch+json) or a JSON Patch (application/json-patch+json) of the
+// @Description public user representation, in which only "name", "email" and "username" are writable.
+// @Description Patches take the current password from the X-Current-Password header.
+// @Description A new email takes effect once confirmed through the link mailed to it
 // @Tags 		User
 // @Accept		json
-// @Param 		user_uuid 	path 	 string 			true  "User's uuid"
-// @Param 		input 		body 	 user.UpdateUserDTO true  "User's data"
+// @Accept		application/merge-patch+json
+// @Accept		application/json-patch+json
+// @Param 		user_uuid 			path 	 string 			true  "User's uuid"
+// @Param 		input 				body 	 user.UpdateUserDTO true  "User's data or patch document"
+// @Param 		If-Match 			header 	 string 			false "ETag of the user being updated"
+// @Param 		X-Current-Password 	header 	 string 			false "User's password, required for patches"
 // @Success 	204
 // @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	409 	{object} apperror.AppError "JSON Patch test operation failed"
+// @Failure 	412 	{object} apperror.AppError "User has been changed meanwhile"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
 // @Router /users/one [patch]
@@ -220,6 +702,11 @@ func (h *handler) PartiallyUpdateUser(w http.ResponseWriter, r *http.Request) er
 	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
 	userUUID := params.ByName("uuid")
 
+	switch format := dto.PatchFormat(mediaType(r.Header.Get("Content-Type"))); format {
+	case dto.PatchFormatMergePatch, dto.PatchFormatJSONPatch:
+		return h.patchUser(w, r, userUUID, format)
+	}
+
 	var updatedUser dto.UpdateUserDTO
 	if err := json.NewDecoder(r.Body).Decode(&updatedUser); err != nil {
 		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
@@ -231,7 +718,13 @@ func (h *handler) PartiallyUpdateUser(w http.ResponseWriter, r *http.Request) er
 		return apperror.BadRequestError(err.Error())
 	}
 
-	err := h.service.Update(r.Context(), updatedUser)
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+	updatedUser.ExpectedVersion = expectedVersion
+
+	err = h.service.Update(r.Context(), updatedUser)
 	if err != nil {
 		return err
 	}
@@ -241,13 +734,45 @@ func (h *handler) PartiallyUpdateUser(w http.ResponseWriter, r *http.Request) er
 	return nil
 }
 
+func (h *handler) patchUser(w http.ResponseWriter, r *http.Request, userUUID string, format dto.PatchFormat) error {
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		return apperror.BadRequestError("failed to read patch document")
+	}
+
+	input := dto.PatchUserDTO{
+		UUID:     userUUID,
+		Password: r.Header.Get("X-Current-Password"),
+		Format:   format,
+		Patch:    patch,
+	}
+	if err = input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	if input.ExpectedVersion, err = parseIfMatch(r); err != nil {
+		return err
+	}
+
+	err = h.service.Patch(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Patch user successfully")
+	return nil
+}
+
 // DeleteUser
 // @Summary 	Delete user
-// @Description Delete user
+// @Description Soft deletes user, it can be restored during the grace period
 // @Tags 		User
 // @Param 		user_uuid 	path 	 string 			true  "User's uuid"
+// @Param 		If-Match 	header 	 string 			false "ETag of the user being deleted"
 // @Success 	204
 // @Failure 	404 	{object} apperror.AppError "user not found"
+// @Failure 	412 	{object} apperror.AppError "User has been changed meanwhile"
 // @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 	{object} apperror.AppError "Internal server error"
 // @Router /users/one [delete]
@@ -262,7 +787,15 @@ func (h *handler) DeleteUser(w http.ResponseWriter, r *http.Request) error {
 		return apperror.BadRequestError("user uuid must not be empty")
 	}
 
-	err := h.service.Delete(r.Context(), userUUID)
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.Delete(r.Context(), dto.DeleteUserDTO{
+		UUID:            userUUID,
+		ExpectedVersion: expectedVersion,
+	})
 	if err != nil {
 		return err
 	}
@@ -271,3 +804,115 @@ func (h *handler) DeleteUser(w http.ResponseWriter, r *http.Request) error {
 	h.logger.Info("Delete user successfully")
 	return nil
 }
+
+// RestoreUser
+// @Summary 	Restore deleted user
+// @Description Restores a soft deleted user within the grace period
+// @Tags 		Admin
+// @Param 		uuid 	path 	 string 	true  "User's uuid"
+// @Success 	204
+// @Failure 	404 	{object} apperror.AppError "Deleted user not found or grace period expired"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/users/{uuid}/restore [post]
+func (h *handler) RestoreUser(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Restore user")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("uuid")
+	if userUUID == "" {
+		return apperror.BadRequestError("user uuid must not be empty")
+	}
+
+	err := h.service.Restore(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Restore user successfully")
+	return nil
+}
+
+// SuspendUser
+// @Summary 	Suspend user
+// @Description Suspends an active user, suspended users cannot authenticate
+// @Tags 		Admin
+// @Accept		json
+// @Param 		uuid 	path 	 string 					true  "User's uuid"
+// @Param 		input	body 	 user.ChangeUserStatusDTO	true  "Reason"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error or forbidden status change"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/users/{uuid}/suspend [post]
+func (h *handler) SuspendUser(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Suspend user")
+	return h.changeUserStatus(w, r, model.StatusSuspended)
+}
+
+// LockUser
+// @Summary 	Lock user
+// @Description Locks an active user, locked users cannot authenticate
+// @Tags 		Admin
+// @Accept		json
+// @Param 		uuid 	path 	 string 					true  "User's uuid"
+// @Param 		input	body 	 user.ChangeUserStatusDTO	true  "Reason"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error or forbidden status change"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/users/{uuid}/lock [post]
+func (h *handler) LockUser(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Lock user")
+	return h.changeUserStatus(w, r, model.StatusLocked)
+}
+
+// ReactivateUser
+// @Summary 	Reactivate user
+// @Description Makes a pending, suspended or locked user active
+// @Tags 		Admin
+// @Accept		json
+// @Param 		uuid 	path 	 string 					true  "User's uuid"
+// @Param 		input	body 	 user.ChangeUserStatusDTO	false "Reason"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error or forbidden status change"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/users/{uuid}/reactivate [post]
+func (h *handler) ReactivateUser(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Reactivate user")
+	return h.changeUserStatus(w, r, model.StatusActive)
+}
+
+func (h *handler) changeUserStatus(w http.ResponseWriter, r *http.Request, status model.Status) error {
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.ChangeUserStatusDTO
+	// the body with the reason is optional for some statuses
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input.UUID = params.ByName("uuid")
+	input.Status = string(status)
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := h.service.ChangeStatus(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Infof("Change user status to %s successfully", status)
+	return nil
+}
@@ -0,0 +1,440 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+)
+
+// CreateOrganization
+// @Summary 	Create organization
+// @Description Creates an organization with the caller, the user of the bearer token, as its first owner
+// @Tags 		Organization
+// @Accept		json
+// @Param 		input	body 	 user.CreateOrganizationDTO	true	"Name"
+// @Success 	201
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	404 	{object} apperror.AppError "Owner not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/organizations	[post]
+func (h *handler) CreateOrganization(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Create organization")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.CreateOrganizationDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	organizationUUID, err := h.service.CreateOrganization(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s/%s", organizationsURL, organizationUUID))
+	w.WriteHeader(http.StatusCreated)
+
+	h.logger.Info("Create organization successfully")
+	return nil
+}
+
+// GetOrganization
+// @Summary 	Get organization
+// @Description Get organization by uuid
+// @Tags 		Organization
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "Organization's uuid"
+// @Success 	200		{object} user.Organization "Organization"
+// @Failure 	404 	{object} apperror.AppError "Organization not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/organizations/{uuid}	[get]
+func (h *handler) GetOrganization(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get organization")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	organizationUUID := params.ByName("uuid")
+	if organizationUUID == "" {
+		return apperror.BadRequestError("organization uuid must not be empty")
+	}
+
+	organization, err := h.service.GetOrganization(r.Context(), organizationUUID)
+	if err != nil {
+		return err
+	}
+
+	organizationBytes, err := json.Marshal(organization)
+	if err != nil {
+		return fmt.Errorf("failed to marshall organization. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(organizationBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get organization successfully")
+	return nil
+}
+
+// GetUserOrganizations
+// @Summary 	Get user's organizations
+// @Description Lists the organizations the user is a member of with the user's role in each
+// @Tags 		Organization
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "User's uuid"
+// @Success 	200		{object} []user.Membership "Memberships"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/organizations	[get]
+func (h *handler) GetUserOrganizations(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get user organizations")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("uuid")
+	if userUUID == "" {
+		return apperror.BadRequestError("user uuid must not be empty")
+	}
+
+	memberships, err := h.service.GetUserOrganizations(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+
+	membershipsBytes, err := json.Marshal(memberships)
+	if err != nil {
+		return fmt.Errorf("failed to marshall memberships. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(membershipsBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get user organizations successfully")
+	return nil
+}
+
+// GetMembers
+// @Summary 	Get organization members
+// @Description Lists the members of the organization with their roles. The users of an organization
+// @Description can also be listed with the organization query parameter of the user listings
+// @Tags 		Organization
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "Organization's uuid"
+// @Success 	200		{object} []user.Member "Members"
+// @Failure 	404 	{object} apperror.AppError "Organization not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/organizations/{uuid}/members	[get]
+func (h *handler) GetMembers(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get members")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	organizationUUID := params.ByName("uuid")
+	if organizationUUID == "" {
+		return apperror.BadRequestError("organization uuid must not be empty")
+	}
+
+	members, err := h.service.GetMembers(r.Context(), organizationUUID)
+	if err != nil {
+		return err
+	}
+
+	membersBytes, err := json.Marshal(members)
+	if err != nil {
+		return fmt.Errorf("failed to marshall members. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(membersBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get members successfully")
+	return nil
+}
+
+// ChangeMemberRole
+// @Summary 	Change member role
+// @Description Changes the role of a member. Owners and admins can change roles, only owners can change
+// @Description the owners. The last owner of an organization cannot be demoted
+// @Tags 		Organization
+// @Accept		json
+// @Param 		uuid 		path 	 string 					true  "Organization's uuid"
+// @Param 		user_uuid 	path 	 string 					true  "Member's uuid"
+// @Param 		input		body 	 user.ChangeMemberRoleDTO	true  "Role"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	403 	{object} apperror.AppError "Caller is not allowed to change the role"
+// @Failure 	404 	{object} apperror.AppError "Member not found"
+// @Failure 	409 	{object} apperror.AppError "Organization would be left without an owner"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/organizations/{uuid}/members/{user_uuid}	[put]
+func (h *handler) ChangeMemberRole(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Change member role")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.ChangeMemberRoleDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input.OrganizationUUID = params.ByName("uuid")
+	input.UserUUID = params.ByName("user_uuid")
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := h.service.ChangeMemberRole(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Change member role successfully")
+	return nil
+}
+
+// RemoveMember
+// @Summary 	Remove member
+// @Description Removes the user from the organization. Owners and admins can remove members, only owners can
+// @Description remove the owners and every member can leave. The last owner of an organization cannot be removed
+// @Tags 		Organization
+// @Param 		uuid 		path 	 string 	true  "Organization's uuid"
+// @Param 		user_uuid 	path 	 string 	true  "Member's uuid"
+// @Success 	204
+// @Failure 	403 	{object} apperror.AppError "Caller is not allowed to remove the member"
+// @Failure 	404 	{object} apperror.AppError "Member not found"
+// @Failure 	409 	{object} apperror.AppError "Organization would be left without an owner"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/organizations/{uuid}/members/{user_uuid}	[delete]
+func (h *handler) RemoveMember(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Remove member")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	organizationUUID := params.ByName("uuid")
+	userUUID := params.ByName("user_uuid")
+	if organizationUUID == "" || userUUID == "" {
+		return apperror.BadRequestError("organization and user uuid must not be empty")
+	}
+
+	err := h.service.RemoveMember(r.Context(), organizationUUID, userUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Remove member successfully")
+	return nil
+}
+
+// InviteMember
+// @Summary 	Invite member
+// @Description Mails an invitation of the caller with an expiring token to the email address. Owners and
+// @Description admins can invite, only owners can invite owners. A new invitation revokes the pending one of
+// @Description the address
+// @Tags 		Organization
+// @Accept		json
+// @Produce 	json
+// @Param 		uuid 	path 	 string 				true  "Organization's uuid"
+// @Param 		input	body 	 user.InviteMemberDTO	true  "Email and role"
+// @Success 	201		{object} user.Invitation "Invitation"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	403 	{object} apperror.AppError "Caller is not allowed to invite"
+// @Failure 	404 	{object} apperror.AppError "Organization not found"
+// @Failure 	409 	{object} apperror.AppError "User is already a member"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/organizations/{uuid}/invitations	[post]
+func (h *handler) InviteMember(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Invite member")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.InviteMemberDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input.OrganizationUUID = params.ByName("uuid")
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	invitation, err := h.service.InviteMember(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	invitationBytes, err := json.Marshal(invitation)
+	if err != nil {
+		return fmt.Errorf("failed to marshall invitation. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_, err = w.Write(invitationBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Invite member successfully")
+	return nil
+}
+
+// GetInvitations
+// @Summary 	Get pending invitations
+// @Description Lists the invitations of the organization that are neither accepted, revoked nor expired
+// @Tags 		Organization
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "Organization's uuid"
+// @Success 	200		{object} []user.Invitation "Invitations"
+// @Failure 	404 	{object} apperror.AppError "Organization not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/organizations/{uuid}/invitations	[get]
+func (h *handler) GetInvitations(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get invitations")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	organizationUUID := params.ByName("uuid")
+	if organizationUUID == "" {
+		return apperror.BadRequestError("organization uuid must not be empty")
+	}
+
+	invitations, err := h.service.GetInvitations(r.Context(), organizationUUID)
+	if err != nil {
+		return err
+	}
+
+	invitationsBytes, err := json.Marshal(invitations)
+	if err != nil {
+		return fmt.Errorf("failed to marshall invitations. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(invitationsBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get invitations successfully")
+	return nil
+}
+
+// RevokeInvitation
+// @Summary 	Revoke invitation
+// @Description Revokes a pending invitation, its token can no longer be accepted. Owners and admins can revoke
+// @Tags 		Organization
+// @Param 		uuid 	path 	 string 	true  "Organization's uuid"
+// @Param 		id 		path 	 string 	true  "Invitation's id"
+// @Success 	204
+// @Failure 	403 	{object} apperror.AppError "Caller is not allowed to revoke"
+// @Failure 	404 	{object} apperror.AppError "Pending invitation not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/organizations/{uuid}/invitations/{id}	[delete]
+func (h *handler) RevokeInvitation(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Revoke invitation")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	organizationUUID := params.ByName("uuid")
+	invitationID := params.ByName("id")
+	if organizationUUID == "" || invitationID == "" {
+		return apperror.BadRequestError("organization uuid and invitation id must not be empty")
+	}
+
+	err := h.service.RevokeInvitation(r.Context(), organizationUUID, invitationID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Revoke invitation successfully")
+	return nil
+}
+
+// AcceptInvitation
+// @Summary 	Accept invitation
+// @Description Makes the caller a member of the inviting organization. The caller's email must be the invited
+// @Description address. The token can be given in the body or in the token query parameter of the mailed link
+// @Tags 		Organization
+// @Accept		json
+// @Produce 	json
+// @Param 		token 	query 	 string 					false "Invitation token"
+// @Param 		input	body 	 user.AcceptInvitationDTO	false "Token"
+// @Success 	200		{object} user.Membership "Membership"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	403 	{object} apperror.AppError "Invitation was sent to another address"
+// @Failure 	404 	{object} apperror.AppError "Invitation not found, expired or revoked"
+// @Failure 	409 	{object} apperror.AppError "User is already a member"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/invitations/accept	[post]
+func (h *handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Accept invitation")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.AcceptInvitationDTO
+	// the body is optional, the mailed link carries the token in the query
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if input.Token == "" {
+		input.Token = r.URL.Query().Get("token")
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	membership, err := h.service.AcceptInvitation(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	membershipBytes, err := json.Marshal(membership)
+	if err != nil {
+		return fmt.Errorf("failed to marshall membership. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(membershipBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Accept invitation successfully")
+	return nil
+}
@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/utils"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// SetPhone
+// @Summary 	Set phone
+// @Description Sets an international phone number, normalized to E.164, and sends a verification code
+// @Description to it by SMS. The phone is unverified and not a second factor until the code is entered.
+// @Description An empty phone removes the phone
+// @Tags 		User
+// @Accept		json
+// @Param 		uuid 	path 	 string 			true  "User's uuid"
+// @Param 		input	body 	 user.SetPhoneDTO	true  "Current password and phone"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error, incorrect password or phone taken"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	429 	{object} apperror.AppError "Too many codes sent"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/phone	[put]
+func (h *handler) SetPhone(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Set phone")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.SetPhoneDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input.UUID = params.ByName("uuid")
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := h.service.SetPhone(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Set phone successfully")
+	return nil
+}
+
+// SendPhoneCode
+// @Summary 	Send phone verification code
+// @Description Sends a new verification code to the unverified phone. Codes are rate limited
+// @Tags 		User
+// @Param 		uuid 	path 	 string 	true  "User's uuid"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "User has no unverified phone"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	429 	{object} apperror.AppError "Too many codes sent"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/phone/code	[post]
+func (h *handler) SendPhoneCode(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Send phone code")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("uuid")
+	if userUUID == "" {
+		return apperror.BadRequestError("user uuid must not be empty")
+	}
+
+	err := h.service.SendPhoneCode(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Send phone code successfully")
+	return nil
+}
+
+// VerifyPhone
+// @Summary 	Verify phone
+// @Description Verifies the phone with the code sent to it, a code allows a limited number of attempts
+// @Tags 		User
+// @Accept		json
+// @Param 		uuid 	path 	 string 				true  "User's uuid"
+// @Param 		input	body 	 user.VerifyPhoneDTO	true  "Code"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Incorrect or expired code"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/phone/verify	[post]
+func (h *handler) VerifyPhone(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Verify phone")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.VerifyPhoneDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input.UUID = params.ByName("uuid")
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := h.service.VerifyPhone(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Verify phone successfully")
+	return nil
+}
+
+// SetPhoneSecondFactor
+// @Summary 	Enable or disable phone second factor
+// @Description Makes logins with a password also require a code sent to the verified phone
+// @Tags 		User
+// @Accept		json
+// @Param 		uuid 	path 	 string 					true  "User's uuid"
+// @Param 		input	body 	 user.PhoneSecondFactorDTO	true  "Current password and whether to enable"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Incorrect password or phone not verified"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/phone/second-factor	[put]
+func (h *handler) SetPhoneSecondFactor(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Set phone second factor")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.PhoneSecondFactorDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input.UUID = params.ByName("uuid")
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := h.service.SetPhoneSecondFactor(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Set phone second factor successfully")
+	return nil
+}
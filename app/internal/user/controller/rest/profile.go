@@ -0,0 +1,181 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"net/http"
+)
+
+// GetProfile
+// @Summary 	Get user's profile
+// @Description Get user's settings and custom attributes
+// @Tags 		Profile
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "User's uuid"
+// @Success 	200		{object} user.Profile "Profile"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/profile	[get]
+func (h *handler) GetProfile(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get profile")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	userUUID := params.ByName("uuid")
+	if userUUID == "" {
+		return apperror.BadRequestError("user uuid must not be empty")
+	}
+
+	profile, err := h.service.GetProfile(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+
+	profileBytes, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshall profile. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(profileBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get profile successfully")
+	return nil
+}
+
+// PatchProfile
+// @Summary 	Patch user's profile
+// @Description Applies a JSON Merge Patch to the profile, null removes a field or an attribute.
+// @Description Attributes must match the profile attributes schema. The password is not required
+// @Tags 		Profile
+// @Accept		application/merge-patch+json
+// @Produce 	json
+// @Param 		uuid 	path 	 string 		true  "User's uuid"
+// @Param 		input	body 	 user.Profile 	true  "Merge patch of the profile"
+// @Success 	200		{object} user.Profile "Updated profile"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	404 	{object} apperror.AppError "User not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/users/one/{uuid}/profile	[patch]
+func (h *handler) PatchProfile(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Patch profile")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	switch mediaType(r.Header.Get("Content-Type")) {
+	case string(dto.PatchFormatMergePatch), "application/json":
+	default:
+		return apperror.BadRequestError("profile patch must be application/merge-patch+json")
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		return apperror.BadRequestError("failed to read patch document")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input := dto.PatchProfileDTO{
+		UUID:  params.ByName("uuid"),
+		Patch: patch,
+	}
+	if err = input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	profile, err := h.service.PatchProfile(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	profileBytes, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshall profile. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(profileBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Patch profile successfully")
+	return nil
+}
+
+// GetProfileSchema
+// @Summary 	Get profile attributes schema
+// @Description Get the JSON Schema profile attributes are validated against
+// @Tags 		Admin
+// @Produce 	json
+// @Success 	200		{object} user.ProfileSchema "Schema"
+// @Failure 	404 	{object} apperror.AppError "No schema is defined"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/profile-schema	[get]
+func (h *handler) GetProfileSchema(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get profile attributes schema")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	schema, err := h.service.GetProfileSchema(r.Context())
+	if err != nil {
+		return err
+	}
+
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to marshall profile attributes schema. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(schemaBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get profile attributes schema successfully")
+	return nil
+}
+
+// UpdateProfileSchema
+// @Summary 	Update profile attributes schema
+// @Description Replaces the JSON Schema (draft 2020-12 by default) profile attributes are validated against.
+// @Description Existing profiles are checked on their next change
+// @Tags 		Admin
+// @Accept		json
+// @Param 		input	body 	 object 	true  "JSON Schema"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Invalid schema"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/profile-schema	[put]
+func (h *handler) UpdateProfileSchema(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Update profile attributes schema")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var schema json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSchemaSize)).Decode(&schema); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	err := h.service.UpdateProfileSchema(r.Context(), schema)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Update profile attributes schema successfully")
+	return nil
+}
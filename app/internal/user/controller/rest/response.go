@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"Users/internal/user/domain/dto"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+var exportContentTypes = map[dto.ExportFormat]string{
+	dto.ExportFormatCSV:     "text/csv",
+	dto.ExportFormatNDJSON:  "application/x-ndjson",
+	dto.ExportFormatParquet: "application/vnd.apache.parquet",
+}
+
+// lazyResponseWriter sends the status and headers with the first write,
+// so errors that happen before any output still get a regular error response.
+type lazyResponseWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (l *lazyResponseWriter) start() {
+	if l.started {
+		return
+	}
+	l.started = true
+	l.w.Header().Set("Content-Type", l.contentType)
+	l.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", l.filename))
+	l.w.WriteHeader(http.StatusOK)
+}
+
+func (l *lazyResponseWriter) Write(p []byte) (int, error) {
+	l.start()
+	return l.w.Write(p)
+}
@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"Users/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// CheckUsername
+// @Summary 	Check username availability
+// @Description Tells whether a username can be claimed. Unavailable usernames come with the reason:
+// @Description invalid, reserved, taken or recently_released, the latter being kept from others for a while
+// @Tags 		User
+// @Produce 	json
+// @Param 		username 	path 	 string 	true  "Username"
+// @Success 	200		{object} user.UsernameAvailability "Availability"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/usernames/{username}	[get]
+func (h *handler) CheckUsername(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Check username")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	availability, err := h.service.CheckUsername(r.Context(), params.ByName("username"))
+	if err != nil {
+		return err
+	}
+
+	availabilityBytes, err := json.Marshal(availability)
+	if err != nil {
+		return fmt.Errorf("failed to marshall username availability. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(availabilityBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Check username successfully")
+	return nil
+}
@@ -0,0 +1,296 @@
+package rest
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/pkg/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"strconv"
+)
+
+// CreateWebhook
+// @Summary 	Create webhook
+// @Description Subscribes the URL to user events, all of them unless events lists some of UserCreated,
+// @Description UserUpdated, UserDeleted, UserRestored, UserPurged, UserStatusChanged, PasswordChanged
+// @Description and EmailChanged. Requests carry the header
+// @Description X-Webhook-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed with the secret>.
+// @Description The secret is only returned here
+// @Tags 		Admin
+// @Accept		json
+// @Produce 	json
+// @Param 		input	body 	 user.CreateWebhookDTO	true	"URL, event filter and description"
+// @Success 	201		{object} user.CreatedWebhook "Webhook uuid and signing secret"
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/webhooks	[post]
+func (h *handler) CreateWebhook(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Create webhook")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.CreateWebhookDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	created, err := h.service.CreateWebhook(r.Context(), input)
+	if err != nil {
+		return err
+	}
+
+	createdBytes, err := json.Marshal(created)
+	if err != nil {
+		return fmt.Errorf("failed to marshall webhook. error: %w", err)
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%s", adminWebhooksURL, created.UUID))
+	w.WriteHeader(http.StatusCreated)
+	_, err = w.Write(createdBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Create webhook successfully")
+	return nil
+}
+
+// GetWebhooks
+// @Summary 	Get all webhooks
+// @Description Lists the webhook subscriptions in the order they were created
+// @Tags 		Admin
+// @Produce 	json
+// @Success 	200		{object} []user.WebhookSubscription "Webhooks"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/webhooks	[get]
+func (h *handler) GetWebhooks(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get webhooks")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	webhooks, err := h.service.GetWebhooks(r.Context())
+	if err != nil {
+		return err
+	}
+
+	webhooksBytes, err := json.Marshal(webhooks)
+	if err != nil {
+		return fmt.Errorf("failed to marshall webhooks. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(webhooksBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get webhooks successfully")
+	return nil
+}
+
+// GetWebhook
+// @Summary 	Get webhook
+// @Description Get webhook subscription by uuid
+// @Tags 		Admin
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "Webhook's uuid"
+// @Success 	200		{object} user.WebhookSubscription "Webhook"
+// @Failure 	404 	{object} apperror.AppError "Webhook not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/webhooks/{uuid}	[get]
+func (h *handler) GetWebhook(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get webhook")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	webhookUUID := params.ByName("uuid")
+	if webhookUUID == "" {
+		return apperror.BadRequestError("webhook uuid must not be empty")
+	}
+
+	webhook, err := h.service.GetWebhook(r.Context(), webhookUUID)
+	if err != nil {
+		return err
+	}
+
+	webhookBytes, err := json.Marshal(webhook)
+	if err != nil {
+		return fmt.Errorf("failed to marshall webhook. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(webhookBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get webhook successfully")
+	return nil
+}
+
+// UpdateWebhook
+// @Summary 	Update webhook
+// @Description Replaces the URL, event filter and description. Deliveries of an inactive webhook wait until it is active again
+// @Tags 		Admin
+// @Accept		json
+// @Param 		uuid 	path 	 string 				true  "Webhook's uuid"
+// @Param 		input	body 	 user.UpdateWebhookDTO	true  "URL, event filter, description and active flag"
+// @Success 	204
+// @Failure 	400 	{object} apperror.AppError "Validation error"
+// @Failure 	404 	{object} apperror.AppError "Webhook not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/webhooks/{uuid}	[put]
+func (h *handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Update webhook")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var input dto.UpdateWebhookDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return apperror.BadRequestError("invalid JSON scheme. check swagger API")
+	}
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	input.UUID = params.ByName("uuid")
+	if err := input.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := h.service.UpdateWebhook(r.Context(), input)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Update webhook successfully")
+	return nil
+}
+
+// DeleteWebhook
+// @Summary 	Delete webhook
+// @Description Deletes the webhook subscription together with its deliveries
+// @Tags 		Admin
+// @Param 		uuid 	path 	 string 	true  "Webhook's uuid"
+// @Success 	204
+// @Failure 	404 	{object} apperror.AppError "Webhook not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/webhooks/{uuid}	[delete]
+func (h *handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Delete webhook")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	webhookUUID := params.ByName("uuid")
+	if webhookUUID == "" {
+		return apperror.BadRequestError("webhook uuid must not be empty")
+	}
+
+	err := h.service.DeleteWebhook(r.Context(), webhookUUID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	h.logger.Info("Delete webhook successfully")
+	return nil
+}
+
+// GetWebhookDeliveries
+// @Summary 	Get webhook deliveries
+// @Description Lists the deliveries of the webhook newest first with the outcome of their last attempt.
+// @Description The next page starts before the id of the last delivery of the previous one
+// @Tags 		Admin
+// @Produce 	json
+// @Param 		uuid 	path 	 string 	true  "Webhook's uuid"
+// @Param 		status 	query 	 string 	false "pending, succeeded or dead"
+// @Param 		before 	query 	 int 		false "Only deliveries with a smaller id"
+// @Param 		limit 	query 	 int 		false "Page size, 50 by default and 200 at most"
+// @Success 	200		{object} []user.WebhookDelivery "Deliveries"
+// @Failure 	400 	{object} apperror.AppError "Invalid filter"
+// @Failure 	404 	{object} apperror.AppError "Webhook not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/webhooks/{uuid}/deliveries	[get]
+func (h *handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Get webhook deliveries")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	webhookUUID := params.ByName("uuid")
+	if webhookUUID == "" {
+		return apperror.BadRequestError("webhook uuid must not be empty")
+	}
+	filter, err := parseDeliveryFilter(r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := h.service.GetWebhookDeliveries(r.Context(), webhookUUID, filter)
+	if err != nil {
+		return err
+	}
+
+	deliveriesBytes, err := json.Marshal(deliveries)
+	if err != nil {
+		return fmt.Errorf("failed to marshall webhook deliveries. error: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(deliveriesBytes)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Get webhook deliveries successfully")
+	return nil
+}
+
+// RedeliverWebhook
+// @Summary 	Redeliver webhook
+// @Description Sends the delivery again with a fresh set of attempts, also when it is dead or succeeded
+// @Tags 		Admin
+// @Param 		uuid 		 path 	 string 	true  "Webhook's uuid"
+// @Param 		delivery_id  path 	 int 		true  "Delivery id"
+// @Success 	202
+// @Failure 	400 	{object} apperror.AppError "Invalid delivery id"
+// @Failure 	404 	{object} apperror.AppError "Delivery not found"
+// @Failure 	418 	{object} apperror.AppError "Something wrong with application logic"
+// @Failure 	500 	{object} apperror.AppError "Internal server error"
+// @Router 		/admin/webhooks/{uuid}/deliveries/{delivery_id}/redeliver	[post]
+func (h *handler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) error {
+	h.logger.Info("Redeliver webhook")
+	defer utils.CloseBody(h.logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	params := r.Context().Value(httprouter.ParamsKey).(httprouter.Params)
+	webhookUUID := params.ByName("uuid")
+	if webhookUUID == "" {
+		return apperror.BadRequestError("webhook uuid must not be empty")
+	}
+	deliveryID, err := strconv.ParseInt(params.ByName("delivery_id"), 10, 64)
+	if err != nil {
+		return apperror.BadRequestError("delivery id must be a number")
+	}
+
+	err = h.service.RedeliverWebhook(r.Context(), webhookUUID, deliveryID)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	h.logger.Info("Redeliver webhook successfully")
+	return nil
+}
@@ -3,14 +3,87 @@ package controller
 import (
 	"Users/internal/user/domain/dto"
 	"Users/internal/user/domain/model"
+	"Users/pkg/authz"
+	"Users/pkg/events"
+	"Users/pkg/webhook"
 	"context"
+	"encoding/json"
+	"io"
 )
 
 type Service interface {
 	Create(ctx context.Context, dto dto.CreateUserDTO) (string, error)
-	GetAll(ctx context.Context) ([]model.User, error)
+	GetAll(ctx context.Context, filter dto.UserFilter) ([]model.User, error)
+	Stream(ctx context.Context, dto dto.StreamUsersDTO, fn func(users []model.User) error) error
 	GetByUUID(ctx context.Context, uuid string) (model.User, error)
-	GetByEmailAndPassword(ctx context.Context, email, password string) (model.User, error)
+	GetByUUIDs(ctx context.Context, dto dto.GetUsersByUUIDsDTO) (model.UsersBatch, error)
+	GetByLoginAndPassword(ctx context.Context, dto dto.LoginDTO) (model.User, error)
+	CheckUsername(ctx context.Context, username string) (model.UsernameAvailability, error)
 	Update(ctx context.Context, dto dto.UpdateUserDTO) error
-	Delete(ctx context.Context, uuid string) error
+	Patch(ctx context.Context, dto dto.PatchUserDTO) error
+	Replace(ctx context.Context, dto dto.ReplaceUserDTO) error
+	UpdateMasked(ctx context.Context, dto dto.MaskedUpdateUserDTO) error
+	ChangeStatus(ctx context.Context, dto dto.ChangeUserStatusDTO) error
+	Delete(ctx context.Context, dto dto.DeleteUserDTO) error
+	Restore(ctx context.Context, uuid string) error
+	PurgeDeleted(ctx context.Context) (int64, error)
+	RelayEvents(ctx context.Context, limit int, publisher events.Publisher) (int, error)
+	PurgePublishedEvents(ctx context.Context) (int64, error)
+	WatchEvents(ctx context.Context, dto dto.WatchEventsDTO, fn func(batch []events.Event) error) error
+	CreateWebhook(ctx context.Context, dto dto.CreateWebhookDTO) (model.CreatedWebhook, error)
+	GetWebhooks(ctx context.Context) ([]model.WebhookSubscription, error)
+	GetWebhook(ctx context.Context, uuid string) (model.WebhookSubscription, error)
+	UpdateWebhook(ctx context.Context, dto dto.UpdateWebhookDTO) error
+	DeleteWebhook(ctx context.Context, uuid string) error
+	GetWebhookDeliveries(ctx context.Context, webhookUUID string, filter dto.DeliveryFilter) ([]model.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, webhookUUID string, deliveryID int64) error
+	DispatchWebhooks(ctx context.Context, limit int, client *webhook.Client) (int, error)
+	GetAuditLog(ctx context.Context, filter dto.AuditFilter) ([]model.AuditEntry, error)
+	VerifyAuditLog(ctx context.Context) (model.AuditVerification, error)
+	Import(ctx context.Context, dto dto.ImportUsersDTO) (model.ImportReport, error)
+	Export(ctx context.Context, dto dto.ExportUsersDTO, w io.Writer) error
+	GetProfile(ctx context.Context, uuid string) (model.Profile, error)
+	PatchProfile(ctx context.Context, dto dto.PatchProfileDTO) (model.Profile, error)
+	GetProfileSchema(ctx context.Context) (model.ProfileSchema, error)
+	UpdateProfileSchema(ctx context.Context, schema json.RawMessage) error
+	UploadAvatar(ctx context.Context, dto dto.UploadAvatarDTO) (model.Avatar, error)
+	DeleteAvatar(ctx context.Context, uuid string) error
+	OpenAvatar(ctx context.Context, uuid, avatarID string, size int) (model.AvatarImage, error)
+	ConfirmEmailChange(ctx context.Context, token string) (model.EmailChange, error)
+	RevertEmailChange(ctx context.Context, token string) (model.EmailChange, error)
+	SetPhone(ctx context.Context, dto dto.SetPhoneDTO) error
+	SendPhoneCode(ctx context.Context, uuid string) error
+	VerifyPhone(ctx context.Context, dto dto.VerifyPhoneDTO) error
+	SetPhoneSecondFactor(ctx context.Context, dto dto.PhoneSecondFactorDTO) error
+	CreateOrganization(ctx context.Context, dto dto.CreateOrganizationDTO) (string, error)
+	GetOrganization(ctx context.Context, uuid string) (model.Organization, error)
+	GetUserOrganizations(ctx context.Context, userUUID string) ([]model.Membership, error)
+	GetMembers(ctx context.Context, organizationUUID string) ([]model.Member, error)
+	ChangeMemberRole(ctx context.Context, dto dto.ChangeMemberRoleDTO) error
+	RemoveMember(ctx context.Context, organizationUUID, userUUID string) error
+	InviteMember(ctx context.Context, dto dto.InviteMemberDTO) (model.Invitation, error)
+	GetInvitations(ctx context.Context, organizationUUID string) ([]model.Invitation, error)
+	RevokeInvitation(ctx context.Context, organizationUUID, id string) error
+	AcceptInvitation(ctx context.Context, dto dto.AcceptInvitationDTO) (model.Membership, error)
+	GetTenants(ctx context.Context) ([]string, error)
+	CreateGroup(ctx context.Context, dto dto.CreateGroupDTO) (string, error)
+	GetGroups(ctx context.Context) ([]model.Group, error)
+	GetGroup(ctx context.Context, uuid string) (model.Group, error)
+	UpdateGroup(ctx context.Context, dto dto.UpdateGroupDTO) error
+	DeleteGroup(ctx context.Context, uuid string) error
+	AddGroupMember(ctx context.Context, groupUUID, userUUID string) error
+	RemoveGroupMember(ctx context.Context, groupUUID, userUUID string) error
+	GetSubgroups(ctx context.Context, uuid string) ([]model.Group, error)
+	AddSubgroup(ctx context.Context, parentUUID, childUUID string) error
+	RemoveSubgroup(ctx context.Context, parentUUID, childUUID string) error
+	GetUserGroups(ctx context.Context, userUUID string) ([]model.EffectiveGroup, error)
+	GetGroupMembers(ctx context.Context, groupUUID string) ([]model.GroupMember, error)
+	Check(ctx context.Context, dto dto.CheckDTO) (model.CheckResult, error)
+	Expand(ctx context.Context, dto dto.ExpandDTO) (authz.Node, error)
+	ListObjects(ctx context.Context, dto dto.ListObjectsDTO) (model.ObjectList, error)
+	GetTuples(ctx context.Context, object, relation string) ([]authz.Tuple, error)
+	WriteTuples(ctx context.Context, dto dto.RelationTuplesDTO) error
+	DeleteTuples(ctx context.Context, dto dto.RelationTuplesDTO) error
+	GetAuthzSchema(ctx context.Context) (string, error)
+	UpdateAuthzSchema(ctx context.Context, source string) error
 }
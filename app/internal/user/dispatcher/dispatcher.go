@@ -0,0 +1,64 @@
+package dispatcher
+
+import (
+	"Users/internal/user/controller"
+	"Users/pkg/logging"
+	"Users/pkg/tenant"
+	"Users/pkg/webhook"
+	"context"
+	"time"
+)
+
+// Dispatcher periodically sends the due webhook deliveries, tenant by tenant because
+// the storage only sees the rows of one tenant at a time.
+type Dispatcher struct {
+	service   controller.Service
+	client    *webhook.Client
+	interval  time.Duration
+	batchSize int
+	logger    *logging.Logger
+}
+
+func NewDispatcher(service controller.Service, client *webhook.Client, interval time.Duration, batchSize int,
+	logger *logging.Logger) *Dispatcher {
+	return &Dispatcher{
+		service:   service,
+		client:    client,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) error {
+	d.logger.Infof("webhook dispatcher started, interval: %s", d.interval)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			d.logger.Info("webhook dispatcher stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	tenants, err := d.service.GetTenants(ctx)
+	if err != nil {
+		// the service has already logged the error, the next tick retries
+		return
+	}
+	for _, id := range tenants {
+		if ctx.Err() != nil {
+			return
+		}
+		// the service has already logged failures, the deliveries stay due
+		_, _ = d.service.DispatchWebhooks(tenant.WithID(ctx, id), d.batchSize, d.client)
+	}
+}
@@ -1,10 +1,18 @@
 package dto
 
-import "fmt"
+import (
+	"Users/pkg/utils"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
 
 type CreateUserDTO struct {
 	Name             string `json:"name"`
 	Email            string `json:"email"`
+	Username         string `json:"username,omitempty"`
 	Password         string `json:"password"`
 	RepeatedPassword string `json:"repeated_password"`
 }
@@ -25,13 +33,34 @@ func (dto *CreateUserDTO) ValidateEmptyFields() error {
 	return nil
 }
 
+// LoginDTO identifies a user by email, username or verified phone.
+type LoginDTO struct {
+	Login    string
+	Password string
+	// Code is the one-time code sent by SMS to users having the phone as second factor.
+	Code string
+}
+
+func (dto *LoginDTO) ValidateEmptyFields() error {
+	if dto.Login == "" {
+		return fmt.Errorf("login must not be empty")
+	}
+	if dto.Password == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+	return nil
+}
+
 type UpdateUserDTO struct {
 	UUID                string  `json:"uuid,omitempty"`
 	Name                *string `json:"name,omitempty"`
 	Email               *string `json:"email,omitempty"`
+	Username            *string `json:"username,omitempty"`
 	Password            string  `json:"password,omitempty"`
 	NewPassword         *string `json:"new_password,omitempty"`
 	RepeatedNewPassword *string `json:"repeated_new_password,omitempty"`
+	// ExpectedVersion makes the update fail if the user has been changed meanwhile.
+	ExpectedVersion *int64 `json:"-"`
 }
 
 func (dto *UpdateUserDTO) ValidateEmptyFields() error {
@@ -43,3 +72,652 @@ func (dto *UpdateUserDTO) ValidateEmptyFields() error {
 	}
 	return nil
 }
+
+// UserFilter narrows listing, streaming and export. Zero fields are ignored,
+// Name and Email match case-insensitive substrings.
+type UserFilter struct {
+	Name            string
+	Email           string
+	CreatedAfter    time.Time
+	CreatedBefore   time.Time
+	UpdatedAfter    time.Time
+	UpdatedBefore   time.Time
+	LastLoginAfter  time.Time
+	LastLoginBefore time.Time
+
+	// OrganizationUUID scopes the users to the members of an organization.
+	OrganizationUUID string
+}
+
+func (f *UserFilter) Validate() error {
+	if f.OrganizationUUID != "" && !utils.IsValidUUID(f.OrganizationUUID) {
+		return fmt.Errorf("organization must be a uuid")
+	}
+	ranges := []struct {
+		name          string
+		after, before time.Time
+	}{
+		{"created", f.CreatedAfter, f.CreatedBefore},
+		{"updated", f.UpdatedAfter, f.UpdatedBefore},
+		{"last_login", f.LastLoginAfter, f.LastLoginBefore},
+	}
+	for _, r := range ranges {
+		if !r.after.IsZero() && !r.before.IsZero() && !r.after.Before(r.before) {
+			return fmt.Errorf("%s_after must be before %s_before", r.name, r.name)
+		}
+	}
+	return nil
+}
+
+type PatchFormat string
+
+const (
+	PatchFormatMergePatch PatchFormat = "application/merge-patch+json"
+	PatchFormatJSONPatch  PatchFormat = "application/json-patch+json"
+)
+
+// PatchUserDTO is a JSON Merge Patch or JSON Patch document for the public user representation.
+type PatchUserDTO struct {
+	UUID            string
+	Password        string
+	Format          PatchFormat
+	Patch           []byte
+	ExpectedVersion *int64
+}
+
+func (dto *PatchUserDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	if dto.Password == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+	if len(dto.Patch) == 0 {
+		return fmt.Errorf("patch must not be empty")
+	}
+	return nil
+}
+
+// ReplaceUserDTO is the full writable state of a user, fields missing from it are not kept.
+// The password is only changed when NewPassword is set.
+type ReplaceUserDTO struct {
+	UUID                string  `json:"-"`
+	Name                string  `json:"name"`
+	Email               string  `json:"email"`
+	Username            string  `json:"username"`
+	Password            string  `json:"password"`
+	NewPassword         *string `json:"new_password,omitempty"`
+	RepeatedNewPassword *string `json:"repeated_new_password,omitempty"`
+	ExpectedVersion     *int64  `json:"-"`
+}
+
+func (dto *ReplaceUserDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	if dto.Password == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+	return nil
+}
+
+// MaskedUpdateUserDTO changes exactly the fields named in UpdateMask, a masked
+// field left at its zero value is cleared. Password is the current password,
+// the "password" path sets NewPassword.
+type MaskedUpdateUserDTO struct {
+	UUID                string
+	Password            string
+	UpdateMask          []string
+	Name                string
+	Email               string
+	Username            string
+	NewPassword         string
+	RepeatedNewPassword string
+	ExpectedVersion     *int64
+}
+
+func (dto *MaskedUpdateUserDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	if dto.Password == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+	if len(dto.UpdateMask) == 0 {
+		return fmt.Errorf("update mask must not be empty")
+	}
+	return nil
+}
+
+// PatchProfileDTO is a JSON Merge Patch document for the user's profile.
+type PatchProfileDTO struct {
+	UUID  string
+	Patch []byte
+}
+
+func (dto *PatchProfileDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	if len(dto.Patch) == 0 {
+		return fmt.Errorf("patch must not be empty")
+	}
+	return nil
+}
+
+// SetPhoneDTO sets an unverified phone and sends a verification code to it,
+// an empty phone removes the phone.
+type SetPhoneDTO struct {
+	UUID     string `json:"-"`
+	Password string `json:"password"`
+	Phone    string `json:"phone"`
+}
+
+func (dto *SetPhoneDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	if dto.Password == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+	return nil
+}
+
+type VerifyPhoneDTO struct {
+	UUID string `json:"-"`
+	Code string `json:"code"`
+}
+
+func (dto *VerifyPhoneDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	if dto.Code == "" {
+		return fmt.Errorf("code must not be empty")
+	}
+	return nil
+}
+
+type PhoneSecondFactorDTO struct {
+	UUID     string `json:"-"`
+	Password string `json:"password"`
+	Enabled  bool   `json:"enabled"`
+}
+
+func (dto *PhoneSecondFactorDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	if dto.Password == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+	return nil
+}
+
+// UploadAvatarDTO is an uploaded image, it is sniffed rather than trusting the declared type.
+type UploadAvatarDTO struct {
+	UUID    string
+	Content []byte
+}
+
+func (dto *UploadAvatarDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	if len(dto.Content) == 0 {
+		return fmt.Errorf("avatar must not be empty")
+	}
+	return nil
+}
+
+type DeleteUserDTO struct {
+	UUID string
+	// ExpectedVersion makes the deletion fail if the user has been changed meanwhile.
+	ExpectedVersion *int64
+}
+
+func (dto *DeleteUserDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	return nil
+}
+
+const (
+	DefaultStreamBatchSize = 100
+	MaxStreamBatchSize     = 1000
+)
+
+// StreamUsersDTO describes a stream ordered by update time, Filter.UpdatedAfter
+// being the watermark of the previous sync.
+type StreamUsersDTO struct {
+	Filter    UserFilter
+	BatchSize int
+}
+
+func (dto *StreamUsersDTO) Validate() error {
+	if err := dto.Filter.Validate(); err != nil {
+		return err
+	}
+	if dto.BatchSize < 0 {
+		return fmt.Errorf("batch size must not be negative")
+	}
+	if dto.BatchSize > MaxStreamBatchSize {
+		return fmt.Errorf("batch size must not be greater than %d", MaxStreamBatchSize)
+	}
+	if dto.BatchSize == 0 {
+		dto.BatchSize = DefaultStreamBatchSize
+	}
+	return nil
+}
+
+const MaxBatchUUIDs = 1000
+
+type GetUsersByUUIDsDTO struct {
+	UUIDs []string `json:"uuids"`
+}
+
+func (dto *GetUsersByUUIDsDTO) Validate() error {
+	if len(dto.UUIDs) == 0 {
+		return fmt.Errorf("uuids must not be empty")
+	}
+	if len(dto.UUIDs) > MaxBatchUUIDs {
+		return fmt.Errorf("no more than %d uuids can be requested at once", MaxBatchUUIDs)
+	}
+	return nil
+}
+
+type ImportFormat string
+
+const (
+	ImportFormatCSV    ImportFormat = "csv"
+	ImportFormatNDJSON ImportFormat = "ndjson"
+)
+
+type ImportUsersDTO struct {
+	Format ImportFormat
+	DryRun bool
+	Source io.Reader
+}
+
+func (dto *ImportUsersDTO) Validate() error {
+	if dto.Format != ImportFormatCSV && dto.Format != ImportFormatNDJSON {
+		return fmt.Errorf("format must be one of: %s, %s", ImportFormatCSV, ImportFormatNDJSON)
+	}
+	if dto.Source == nil {
+		return fmt.Errorf("source must not be empty")
+	}
+	return nil
+}
+
+// ImportUserDTO is a single imported row. Exactly one of Password and
+// PasswordHash is set, the hash being a bcrypt or argon2id one.
+type ImportUserDTO struct {
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Password     string `json:"password,omitempty"`
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+func (dto *ImportUserDTO) Validate() error {
+	if dto.Password != "" && dto.PasswordHash != "" {
+		return fmt.Errorf("only one of password and password hash must be provided")
+	}
+
+	secret := dto.Password
+	if secret == "" {
+		secret = dto.PasswordHash
+	}
+	createdUser := CreateUserDTO{
+		Name:             dto.Name,
+		Email:            dto.Email,
+		Password:         secret,
+		RepeatedPassword: secret,
+	}
+	return createdUser.ValidateEmptyFields()
+}
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV     ExportFormat = "csv"
+	ExportFormatNDJSON  ExportFormat = "ndjson"
+	ExportFormatParquet ExportFormat = "parquet"
+)
+
+// ExportColumns are the columns that can be exported, secrets are never among them.
+var ExportColumns = []string{"uuid", "name", "email", "username", "status",
+	"created_at", "updated_at", "last_login_at", "password_changed_at"}
+
+type ExportUsersDTO struct {
+	Format  ExportFormat
+	Columns []string
+	Filter  UserFilter
+}
+
+func (dto *ExportUsersDTO) Validate() error {
+	switch dto.Format {
+	case ExportFormatCSV, ExportFormatNDJSON, ExportFormatParquet:
+	default:
+		return fmt.Errorf("format must be one of: %s, %s, %s", ExportFormatCSV, ExportFormatNDJSON, ExportFormatParquet)
+	}
+	if err := dto.Filter.Validate(); err != nil {
+		return err
+	}
+
+	if len(dto.Columns) == 0 {
+		dto.Columns = ExportColumns
+		return nil
+	}
+	seen := make(map[string]struct{}, len(dto.Columns))
+	for _, column := range dto.Columns {
+		if !slices.Contains(ExportColumns, column) {
+			return fmt.Errorf("unknown column %q, available columns: %s", column, strings.Join(ExportColumns, ", "))
+		}
+		if _, ok := seen[column]; ok {
+			return fmt.Errorf("duplicate column %q", column)
+		}
+		seen[column] = struct{}{}
+	}
+	return nil
+}
+
+// CreateOrganizationDTO creates an organization owned by the caller.
+type CreateOrganizationDTO struct {
+	Name string `json:"name"`
+}
+
+func (dto *CreateOrganizationDTO) ValidateEmptyFields() error {
+	if dto.Name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	return nil
+}
+
+type ChangeMemberRoleDTO struct {
+	OrganizationUUID string `json:"-"`
+	UserUUID         string `json:"-"`
+	Role             string `json:"role"`
+}
+
+func (dto *ChangeMemberRoleDTO) ValidateEmptyFields() error {
+	if dto.OrganizationUUID == "" {
+		return fmt.Errorf("organization uuid must not be empty")
+	}
+	if dto.UserUUID == "" {
+		return fmt.Errorf("user uuid must not be empty")
+	}
+	if dto.Role == "" {
+		return fmt.Errorf("role must not be empty")
+	}
+	return nil
+}
+
+// InviteMemberDTO is an invitation sent by the caller, who must be an owner or an admin.
+type InviteMemberDTO struct {
+	OrganizationUUID string `json:"-"`
+	Email            string `json:"email"`
+	Role             string `json:"role"`
+}
+
+func (dto *InviteMemberDTO) ValidateEmptyFields() error {
+	if dto.OrganizationUUID == "" {
+		return fmt.Errorf("organization uuid must not be empty")
+	}
+	if dto.Email == "" {
+		return fmt.Errorf("email must not be empty")
+	}
+	if dto.Role == "" {
+		return fmt.Errorf("role must not be empty")
+	}
+	return nil
+}
+
+// AcceptInvitationDTO accepts an invitation for the caller.
+type AcceptInvitationDTO struct {
+	Token string `json:"token"`
+}
+
+func (dto *AcceptInvitationDTO) ValidateEmptyFields() error {
+	if dto.Token == "" {
+		return fmt.Errorf("token must not be empty")
+	}
+	return nil
+}
+
+type CreateGroupDTO struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (dto *CreateGroupDTO) ValidateEmptyFields() error {
+	if dto.Name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	return nil
+}
+
+type UpdateGroupDTO struct {
+	UUID        string `json:"-"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (dto *UpdateGroupDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("group uuid must not be empty")
+	}
+	if dto.Name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	return nil
+}
+
+type CreateWebhookDTO struct {
+	URL string `json:"url"`
+	// Events filters the event types sent, all of them are sent when empty
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+}
+
+func (dto *CreateWebhookDTO) ValidateEmptyFields() error {
+	if dto.URL == "" {
+		return fmt.Errorf("url must not be empty")
+	}
+	return nil
+}
+
+type UpdateWebhookDTO struct {
+	UUID        string   `json:"-"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+func (dto *UpdateWebhookDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("webhook uuid must not be empty")
+	}
+	if dto.URL == "" {
+		return fmt.Errorf("url must not be empty")
+	}
+	if dto.Active == nil {
+		return fmt.Errorf("active must be set")
+	}
+	return nil
+}
+
+const (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 200
+)
+
+// DeliveryFilter pages through the deliveries of a webhook, newest first.
+type DeliveryFilter struct {
+	Status string
+	// Before is the id of the last delivery of the previous page
+	Before int64
+	Limit  int
+}
+
+func (f *DeliveryFilter) Validate() error {
+	if f.Limit == 0 {
+		f.Limit = DefaultDeliveryLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxDeliveryLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxDeliveryLimit)
+	}
+	if f.Before < 0 {
+		return fmt.Errorf("before must be a delivery id")
+	}
+	return nil
+}
+
+const (
+	DefaultAuditLimit = 50
+	MaxAuditLimit     = 200
+)
+
+// AuditFilter pages through the audit log, newest first. Empty fields match every entry.
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	TargetUUID string
+	From       time.Time
+	To         time.Time
+	// Before is the id of the last entry of the previous page
+	Before int64
+	Limit  int
+}
+
+func (f *AuditFilter) Validate() error {
+	if f.Limit == 0 {
+		f.Limit = DefaultAuditLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxAuditLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxAuditLimit)
+	}
+	if f.Before < 0 {
+		return fmt.Errorf("before must be an audit entry id")
+	}
+	if f.TargetUUID != "" && !utils.IsValidUUID(f.TargetUUID) {
+		return fmt.Errorf("target uuid %q is not valid", f.TargetUUID)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return fmt.Errorf("to must not be before from")
+	}
+	return nil
+}
+
+// WatchEventsDTO filters the events of a watch, an empty filter matches every event.
+type WatchEventsDTO struct {
+	UserUUID string
+	Types    []string
+	// AfterPosition resumes the watch after the event at this position, zero watches new
+	// events only
+	AfterPosition int64
+}
+
+func (dto *WatchEventsDTO) Validate() error {
+	if dto.UserUUID != "" && !utils.IsValidUUID(dto.UserUUID) {
+		return fmt.Errorf("user uuid %q is not valid", dto.UserUUID)
+	}
+	if dto.AfterPosition < 0 {
+		return fmt.Errorf("event position must not be negative")
+	}
+	return nil
+}
+
+// CheckDTO asks whether the subject has the relation on the object.
+type CheckDTO struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	Subject  string `json:"subject"`
+}
+
+func (dto *CheckDTO) ValidateEmptyFields() error {
+	if dto.Object == "" {
+		return fmt.Errorf("object must not be empty")
+	}
+	if dto.Relation == "" {
+		return fmt.Errorf("relation must not be empty")
+	}
+	if dto.Subject == "" {
+		return fmt.Errorf("subject must not be empty")
+	}
+	return nil
+}
+
+type ExpandDTO struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+}
+
+func (dto *ExpandDTO) ValidateEmptyFields() error {
+	if dto.Object == "" {
+		return fmt.Errorf("object must not be empty")
+	}
+	if dto.Relation == "" {
+		return fmt.Errorf("relation must not be empty")
+	}
+	return nil
+}
+
+// ListObjectsDTO asks for the objects of the namespace the subject has the relation on.
+type ListObjectsDTO struct {
+	Namespace string `json:"namespace"`
+	Relation  string `json:"relation"`
+	Subject   string `json:"subject"`
+}
+
+func (dto *ListObjectsDTO) ValidateEmptyFields() error {
+	if dto.Namespace == "" {
+		return fmt.Errorf("namespace must not be empty")
+	}
+	if dto.Relation == "" {
+		return fmt.Errorf("relation must not be empty")
+	}
+	if dto.Subject == "" {
+		return fmt.Errorf("subject must not be empty")
+	}
+	return nil
+}
+
+type RelationTupleDTO struct {
+	Object   string `json:"object"`
+	Relation string `json:"relation"`
+	Subject  string `json:"subject"`
+}
+
+type RelationTuplesDTO struct {
+	Tuples []RelationTupleDTO `json:"tuples"`
+}
+
+func (dto *RelationTuplesDTO) ValidateEmptyFields() error {
+	if len(dto.Tuples) == 0 {
+		return fmt.Errorf("tuples must not be empty")
+	}
+	for i, tuple := range dto.Tuples {
+		if tuple.Object == "" || tuple.Relation == "" || tuple.Subject == "" {
+			return fmt.Errorf("tuple %d must have an object, a relation and a subject", i)
+		}
+	}
+	return nil
+}
+
+type ChangeUserStatusDTO struct {
+	UUID   string `json:"-"`
+	Status string `json:"-"`
+	Reason string `json:"reason"`
+}
+
+func (dto *ChangeUserStatusDTO) ValidateEmptyFields() error {
+	if dto.UUID == "" {
+		return fmt.Errorf("uuid must not be empty")
+	}
+	if dto.Status == "" {
+		return fmt.Errorf("status must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package model
+
+import (
+	"Users/pkg/audit"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditUserCreated       = "user.created"
+	AuditUserImported      = "user.imported"
+	AuditUserUpdated       = "user.updated"
+	AuditPhoneChanged      = "user.phone_changed"
+	AuditEmailChanged      = "user.email_changed"
+	AuditEmailReverted     = "user.email_reverted"
+	AuditProfileUpdated    = "user.profile_updated"
+	AuditAvatarChanged     = "user.avatar_changed"
+	AuditUserStatusChanged = "user.status_changed"
+	AuditUserDeleted       = "user.deleted"
+	AuditUserRestored      = "user.restored"
+	AuditUserPurged        = "user.purged"
+	AuditLoginSucceeded    = "login.succeeded"
+	AuditLoginFailed       = "login.failed"
+)
+
+// Redacted replaces the values of secret fields in audit log diffs.
+const Redacted = "[REDACTED]"
+
+// auditSecretFields are the members whose values never reach the audit log, only the
+// fact that they changed.
+var auditSecretFields = map[string]struct{}{
+	"password": {},
+}
+
+// AuditChange is the value of a field before and after a change, nil where it was unset.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditEntry records an action on a user. Entries form a chain per tenant, every hash
+// covering the entry and the hash of the previous one, so that changing or removing an
+// entry breaks the chain from there on.
+type AuditEntry struct {
+	ID         int64                  `json:"id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Actor      string                 `json:"actor"`
+	Action     string                 `json:"action"`
+	TargetUUID string                 `json:"target_uuid,omitempty"`
+	Changes    map[string]AuditChange `json:"changes,omitempty"`
+	// Error is why a failed action failed.
+	Error     string `json:"error,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash"`
+}
+
+// NewAuditEntry describes the action of the request source on the target user, before
+// and after being the user or its profile around the change, nil where it did not exist.
+func NewAuditEntry(source audit.Source, action, targetUUID string, before, after interface{},
+	now time.Time) (AuditEntry, error) {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	return AuditEntry{
+		// the storage keeps microseconds, the hash must cover what is read back
+		OccurredAt: now.UTC().Truncate(time.Microsecond),
+		Actor:      source.Actor,
+		Action:     action,
+		TargetUUID: targetUUID,
+		Changes:    changes,
+		IP:         source.IP,
+		UserAgent:  source.UserAgent,
+		RequestID:  source.RequestID,
+	}, nil
+}
+
+// Seal links the entry to the previous one of the chain, an empty hash starting it.
+func (e *AuditEntry) Seal(prevHash string) error {
+	e.PrevHash = prevHash
+	hash, err := e.ComputeHash()
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+	return nil
+}
+
+// ComputeHash returns the hex SHA-256 of the entry as it is sealed, its JSON encoding
+// without the hash itself. Maps are encoded with sorted keys, so the encoding of an
+// entry read back from the storage is the same.
+func (e *AuditEntry) ComputeHash() (string, error) {
+	sealed := *e
+	sealed.Hash = ""
+	sealed.OccurredAt = sealed.OccurredAt.UTC()
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditVerification is the result of walking the audit log chain of a tenant.
+type AuditVerification struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt is the first entry whose hash or link does not match
+	BrokenAt int64 `json:"broken_at,omitempty"`
+	// LastHash is the head of the chain, keeping it elsewhere reveals removed trailing entries
+	LastHash string `json:"last_hash,omitempty"`
+}
+
+// auditChanges returns the members that differ, secrets redacted.
+func auditChanges(before, after interface{}) (map[string]AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]AuditChange)
+	for field, value := range afterFields {
+		if !reflect.DeepEqual(beforeFields[field], value) {
+			changes[field] = AuditChange{Before: beforeFields[field], After: value}
+		}
+	}
+	for field, value := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			changes[field] = AuditChange{Before: value}
+		}
+	}
+	for field, change := range changes {
+		if _, ok := auditSecretFields[field]; ok {
+			changes[field] = AuditChange{Before: redact(change.Before), After: redact(change.After)}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+// auditFields returns the members of the value as they are encoded in JSON, so that the
+// diff reads like the API and survives a round trip through the storage unchanged.
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audited value: %w", err)
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal audited value: %w", err)
+	}
+	return fields, nil
+}
+
+func redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return Redacted
+}
@@ -0,0 +1,9 @@
+package model
+
+type CheckResult struct {
+	Allowed bool `json:"allowed"`
+}
+
+type ObjectList struct {
+	Objects []string `json:"objects"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const AvatarContentType = "image/jpeg"
+
+// Avatar is an uploaded picture, URLs hold its square thumbnails by edge size in pixels.
+type Avatar struct {
+	ID   string            `json:"id"`
+	URLs map[string]string `json:"urls"`
+}
+
+// AvatarImage is a thumbnail read from the blob storage.
+type AvatarImage struct {
+	Content     io.ReadCloser
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// AvatarKey is the blob storage key of a thumbnail. It doubles as the path
+// of the thumbnail URL below /api/.
+func AvatarKey(userUUID, avatarID string, size int) string {
+	return fmt.Sprintf("avatars/%s/%s/%d.jpg", userUUID, avatarID, size)
+}
+
+func NewAvatar(baseURL, userUUID, avatarID string, sizes []int) Avatar {
+	avatar := Avatar{
+		ID:   avatarID,
+		URLs: make(map[string]string, len(sizes)),
+	}
+	for _, size := range sizes {
+		avatar.URLs[strconv.Itoa(size)] = fmt.Sprintf("%s/api/%s", baseURL, AvatarKey(userUUID, avatarID, size))
+	}
+	return avatar
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"Users/internal/apperror"
+	"fmt"
+	"golang.org/x/net/idna"
+	"net/mail"
+	"strings"
+)
+
+const (
+	maxEmailLength     = 254
+	maxEmailLocalBytes = 64
+)
+
+// NormalizeEmail trims and lowercases the address and converts an internationalized
+// domain to its ASCII (punycode) form, so that every spelling of an address is stored
+// and looked up the same way. Syntactically invalid addresses are rejected.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", apperror.BadRequestError("email must not be empty")
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return "", apperror.BadRequestError(fmt.Sprintf("email %q must have the form local@domain", email))
+	}
+	local, domain := strings.ToLower(email[:at]), email[at+1:]
+
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(domain, "."))
+	if err != nil || domain == "" {
+		return "", apperror.BadRequestError(fmt.Sprintf("email %q has an invalid domain", email))
+	}
+	domain = strings.ToLower(domain)
+
+	if len(local) > maxEmailLocalBytes {
+		return "", apperror.BadRequestError(
+			fmt.Sprintf("email local part must not be longer than %d bytes", maxEmailLocalBytes))
+	}
+	normalized := local + "@" + domain
+	if len(normalized) > maxEmailLength {
+		return "", apperror.BadRequestError(fmt.Sprintf("email must not be longer than %d bytes", maxEmailLength))
+	}
+
+	// a display name or angle brackets change the parsed address
+	address, err := mail.ParseAddress(normalized)
+	if err != nil || address.Address != normalized {
+		return "", apperror.BadRequestError(fmt.Sprintf("email %q is not a valid address", email))
+	}
+	return normalized, nil
+}
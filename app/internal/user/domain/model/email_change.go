@@ -0,0 +1,59 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+)
+
+// EmailChange is a requested change of the user's email. It takes effect once the new
+// address confirms it, and the old address can revert it for a while.
+type EmailChange struct {
+	ID          string     `json:"id"`
+	UserUUID    string     `json:"user_uuid"`
+	OldEmail    string     `json:"old_email"`
+	NewEmail    string     `json:"new_email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	RevertedAt  *time.Time `json:"reverted_at,omitempty"`
+	// ConfirmToken and RevertToken are only known when the change is requested,
+	// the storage keeps their hashes.
+	ConfirmToken string `json:"-"`
+	RevertToken  string `json:"-"`
+}
+
+func NewEmailChange(user User, newEmail string, now time.Time, ttl time.Duration) (EmailChange, error) {
+	confirmToken, err := newToken()
+	if err != nil {
+		return EmailChange{}, err
+	}
+	revertToken, err := newToken()
+	if err != nil {
+		return EmailChange{}, err
+	}
+	return EmailChange{
+		UserUUID:     user.UUID,
+		OldEmail:     user.Email,
+		NewEmail:     newEmail,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(ttl),
+		ConfirmToken: confirmToken,
+		RevertToken:  revertToken,
+	}, nil
+}
+
+// HashToken is the form a mailed token is stored and looked up in.
+func HashToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+func newToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"Users/pkg/events"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Types of the user domain events written to the outbox.
+const (
+	EventUserCreated       = "UserCreated"
+	EventUserUpdated       = "UserUpdated"
+	EventUserDeleted       = "UserDeleted"
+	EventUserRestored      = "UserRestored"
+	EventUserPurged        = "UserPurged"
+	EventUserStatusChanged = "UserStatusChanged"
+	EventPasswordChanged   = "PasswordChanged"
+	EventEmailChanged      = "EmailChanged"
+)
+
+// NewUserEvent describes the user as stored after the change, the password hash is
+// never part of the payload.
+func NewUserEvent(eventType string, user User, at time.Time) (events.Event, error) {
+	payload, err := json.Marshal(NewPublicUser(user))
+	if err != nil {
+		return events.Event{}, fmt.Errorf("failed to marshal event payload: %w", err)
+	}
+	return events.Event{
+		Type:       eventType,
+		UserUUID:   user.UUID,
+		Payload:    payload,
+		OccurredAt: at,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"Users/internal/apperror"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const maxGroupNameLength = 100
+
+// Group gathers users independently of their roles, e.g. beta testers. The members
+// of its subgroups are members of the group as well.
+type Group struct {
+	UUID        string    `json:"uuid"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func NewGroup(name, description string, now time.Time) (Group, error) {
+	group := Group{
+		CreatedAt: now,
+	}
+	if err := group.Update(name, description, now); err != nil {
+		return Group{}, err
+	}
+	return group, nil
+}
+
+func (g *Group) Update(name, description string, now time.Time) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return apperror.BadRequestError("group name must not be empty")
+	}
+	if len(name) > maxGroupNameLength {
+		return apperror.BadRequestError(
+			fmt.Sprintf("group name must not be longer than %d bytes", maxGroupNameLength))
+	}
+	g.Name = name
+	g.Description = strings.TrimSpace(description)
+	g.UpdatedAt = now
+	return nil
+}
+
+// EffectiveGroup is a group a user belongs to, directly or through one of its subgroups.
+type EffectiveGroup struct {
+	Group
+	Direct bool `json:"direct"`
+}
+
+// GroupMember is a user belonging to a group, directly or through one of its subgroups.
+type GroupMember struct {
+	UserUUID string `json:"user_uuid"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username,omitempty"`
+	Direct   bool   `json:"direct"`
+}
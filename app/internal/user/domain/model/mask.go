@@ -0,0 +1,97 @@
+package model
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"fmt"
+)
+
+// userFieldPaths are the update mask paths of a user, the value tells whether the field is mutable.
+// Status is changed by the admin operations only.
+var userFieldPaths = map[string]bool{
+	"uuid":                false,
+	"name":                true,
+	"email":               true,
+	"username":            true,
+	"password":            true,
+	"status":              false,
+	"status_reason":       false,
+	"version":             false,
+	"created_at":          false,
+	"updated_at":          false,
+	"last_login_at":       false,
+	"password_changed_at": false,
+	"phone":               false,
+	"phone_verified_at":   false,
+	"phone_second_factor": false,
+}
+
+// NewMaskedUpdate turns a field mask driven update into an update of exactly the masked fields.
+// Unknown and immutable paths are rejected, as is clearing a required field.
+func NewMaskedUpdate(input dto.MaskedUpdateUserDTO) (dto.UpdateUserDTO, error) {
+	update := dto.UpdateUserDTO{
+		UUID:            input.UUID,
+		Password:        input.Password,
+		ExpectedVersion: input.ExpectedVersion,
+	}
+
+	seen := make(map[string]struct{}, len(input.UpdateMask))
+	for _, path := range input.UpdateMask {
+		mutable, ok := userFieldPaths[path]
+		if !ok {
+			return dto.UpdateUserDTO{}, apperror.BadRequestError(fmt.Sprintf("unknown update mask path %q", path))
+		}
+		if !mutable {
+			return dto.UpdateUserDTO{}, apperror.BadRequestError(fmt.Sprintf("field %q is immutable", path))
+		}
+		if _, ok = seen[path]; ok {
+			return dto.UpdateUserDTO{}, apperror.BadRequestError(fmt.Sprintf("duplicate update mask path %q", path))
+		}
+		seen[path] = struct{}{}
+
+		switch path {
+		case "name":
+			if input.Name == "" {
+				return dto.UpdateUserDTO{}, apperror.BadRequestError("name must not be empty")
+			}
+			update.Name = &input.Name
+		case "email":
+			if input.Email == "" {
+				return dto.UpdateUserDTO{}, apperror.BadRequestError("email must not be empty")
+			}
+			update.Email = &input.Email
+		case "username":
+			// the username is optional, an empty one clears it
+			update.Username = &input.Username
+		case "password":
+			if input.NewPassword == "" {
+				return dto.UpdateUserDTO{}, apperror.BadRequestError("new password must not be empty")
+			}
+			update.NewPassword = &input.NewPassword
+			update.RepeatedNewPassword = &input.RepeatedNewPassword
+		}
+	}
+	return update, nil
+}
+
+// NewReplaceUpdate is the masked update of every writable field, the password
+// being masked only when a new one is given.
+func NewReplaceUpdate(input dto.ReplaceUserDTO) dto.MaskedUpdateUserDTO {
+	update := dto.MaskedUpdateUserDTO{
+		UUID:            input.UUID,
+		Password:        input.Password,
+		UpdateMask:      []string{"name", "email", "username"},
+		Name:            input.Name,
+		Email:           input.Email,
+		Username:        input.Username,
+		ExpectedVersion: input.ExpectedVersion,
+	}
+	if input.NewPassword != nil {
+		update.UpdateMask = append(update.UpdateMask, "password")
+		update.NewPassword = *input.NewPassword
+		if input.RepeatedNewPassword != nil {
+			update.RepeatedNewPassword = *input.RepeatedNewPassword
+		}
+	}
+	return update
+}
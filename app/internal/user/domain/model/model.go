@@ -5,32 +5,127 @@ import (
 	"Users/internal/user/domain/dto"
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
 type User struct {
-	UUID     string `json:"uuid"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	UUID         string `json:"uuid"`
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Username     string `json:"username,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Password     string `json:"password"`
+	Status       Status `json:"status"`
+	StatusReason string `json:"status_reason,omitempty"`
+	// Version is incremented on every write but logins and backs optimistic concurrency.
+	Version int64 `json:"version"`
+	// PhoneSecondFactor makes password logins require a code sent to the verified phone.
+	PhoneSecondFactor bool `json:"phone_second_factor,omitempty"`
+
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	LastLoginAt       *time.Time `json:"last_login_at,omitempty"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	PhoneVerifiedAt   *time.Time `json:"phone_verified_at,omitempty"`
+}
+
+// UsersBatch is the result of a batch lookup, its users carry no secrets.
+type UsersBatch struct {
+	Users   []PublicUser `json:"users"`
+	Missing []string     `json:"missing"`
+}
+
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Email   string `json:"email,omitempty"`
+	Message string `json:"message"`
 }
 
-func NewCreatedUser(dto dto.CreateUserDTO) (User, error) {
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+func NewImportedUser(dto dto.ImportUserDTO, status Status, now time.Time) (User, error) {
+	email, err := NormalizeEmail(dto.Email)
+	if err != nil {
+		return User{}, err
+	}
 	user := User{
-		Name:     dto.Name,
-		Email:    dto.Email,
-		Password: dto.Password,
+		Name:              dto.Name,
+		Email:             email,
+		Status:            status,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		PasswordChangedAt: &now,
+	}
+	if dto.PasswordHash != "" {
+		if err := ValidatePasswordHash(dto.PasswordHash); err != nil {
+			return User{}, apperror.BadRequestError(err.Error())
+		}
+		user.Password = dto.PasswordHash
+		return user, nil
 	}
-	err := user.GeneratePasswordHash()
+
+	user.Password = dto.Password
+	err = user.GeneratePasswordHash()
 	return user, err
 }
 
-func NewUpdatedUser(existing User, dto dto.UpdateUserDTO) (User, error) {
+// CheckVersion fails if an expected version is given and differs from the user's one.
+func (u *User) CheckVersion(expected *int64) error {
+	if expected != nil && *expected != u.Version {
+		return apperror.PreconditionFailedError(
+			fmt.Sprintf("user version is %d, expected %d", u.Version, *expected))
+	}
+	return nil
+}
+
+func NewCreatedUser(dto dto.CreateUserDTO, status Status, now time.Time) (User, error) {
+	email, err := NormalizeEmail(dto.Email)
+	if err != nil {
+		return User{}, err
+	}
+	if dto.Username != "" {
+		if dto.Username, err = NormalizeUsername(dto.Username); err != nil {
+			return User{}, err
+		}
+	}
+	user := User{
+		Name:              dto.Name,
+		Email:             email,
+		Username:          dto.Username,
+		Password:          dto.Password,
+		Status:            status,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		PasswordChangedAt: &now,
+	}
+	err = user.GeneratePasswordHash()
+	return user, err
+}
+
+// NewUpdatedUser applies the update except for the email, which changes only
+// after the new address confirms it, see RequestedEmail.
+func NewUpdatedUser(existing User, dto dto.UpdateUserDTO, now time.Time) (User, error) {
+	existing.UpdatedAt = now
+
 	if dto.Name != nil {
 		existing.Name = *dto.Name
 	}
 
-	if dto.Email != nil {
-		existing.Email = *dto.Email
+	if dto.Username != nil {
+		existing.Username = ""
+		if *dto.Username != "" {
+			username, err := NormalizeUsername(*dto.Username)
+			if err != nil {
+				return User{}, err
+			}
+			existing.Username = username
+		}
 	}
 
 	if dto.NewPassword != nil {
@@ -44,16 +139,49 @@ func NewUpdatedUser(existing User, dto dto.UpdateUserDTO) (User, error) {
 		if err := existing.GeneratePasswordHash(); err != nil {
 			return User{}, fmt.Errorf("failed to generate paaword hash: %w", err)
 		}
+		existing.PasswordChangedAt = &now
 	}
 	return existing, nil
 }
 
-func (u *User) CheckPassword(password string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
+// RequestedEmail returns the normalized new email of the update, empty if the email does not change.
+func RequestedEmail(existing User, dto dto.UpdateUserDTO) (string, error) {
+	if dto.Email == nil {
+		return "", nil
+	}
+	email, err := NormalizeEmail(*dto.Email)
 	if err != nil {
-		return fmt.Errorf("password does not match")
+		return "", err
 	}
-	return nil
+	if email == existing.Email {
+		return "", nil
+	}
+	return email, nil
+}
+
+// PhoneVerified reports whether the user's phone can receive codes and identify the user.
+func (u *User) PhoneVerified() bool {
+	return u.Phone != "" && u.PhoneVerifiedAt != nil
+}
+
+// CanAuthenticate reports whether the user is allowed to log in.
+func (u *User) CanAuthenticate() error {
+	switch u.Status {
+	case StatusActive:
+		return nil
+	case StatusPending:
+		return apperror.ForbiddenError("user account is pending verification")
+	case StatusSuspended:
+		return apperror.ForbiddenError("user account is suspended")
+	case StatusLocked:
+		return apperror.ForbiddenError("user account is locked")
+	default:
+		return apperror.ForbiddenError("user account is not active")
+	}
+}
+
+func (u *User) CheckPassword(password string) error {
+	return comparePasswordHash(u.Password, password)
 }
 
 func (u *User) GeneratePasswordHash() error {
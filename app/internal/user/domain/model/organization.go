@@ -0,0 +1,140 @@
+package model
+
+import (
+	"Users/internal/apperror"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const maxOrganizationNameLength = 255
+
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+var roles = []Role{RoleOwner, RoleAdmin, RoleMember, RoleViewer}
+
+func (r Role) IsValid() bool {
+	for _, role := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeMemberChange checks that a member with the actor role can give the role to a
+// member, or remove it if to is empty, while it has the role from, empty for a new member.
+// Owners and admins manage the members, only owners manage the owners.
+func AuthorizeMemberChange(actor, from, to Role) error {
+	if actor != RoleOwner && actor != RoleAdmin {
+		return apperror.ForbiddenError("only owners and admins of the organization can manage its members")
+	}
+	if actor != RoleOwner && (from == RoleOwner || to == RoleOwner) {
+		return apperror.ForbiddenError("only owners of the organization can manage its owners")
+	}
+	return nil
+}
+
+func ParseRole(role string) (Role, error) {
+	if !Role(role).IsValid() {
+		return "", apperror.BadRequestError(fmt.Sprintf("role must be one of: %v", roles))
+	}
+	return Role(role), nil
+}
+
+// Organization is a household or a team whose members share budgets.
+type Organization struct {
+	UUID      string    `json:"uuid"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewOrganization(name string, now time.Time) (Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Organization{}, apperror.BadRequestError("organization name must not be empty")
+	}
+	if len(name) > maxOrganizationNameLength {
+		return Organization{}, apperror.BadRequestError(
+			fmt.Sprintf("organization name must not be longer than %d bytes", maxOrganizationNameLength))
+	}
+	return Organization{
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// Membership is the role of a user in an organization.
+type Membership struct {
+	OrganizationUUID string    `json:"organization_uuid"`
+	OrganizationName string    `json:"organization_name,omitempty"`
+	UserUUID         string    `json:"user_uuid"`
+	Role             Role      `json:"role"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Member is a user listed as a member of an organization, secrets are left out.
+type Member struct {
+	UserUUID string    `json:"user_uuid"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Username string    `json:"username,omitempty"`
+	Role     Role      `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Invitation invites an email address to join an organization with a role.
+type Invitation struct {
+	ID               string     `json:"id"`
+	OrganizationUUID string     `json:"organization_uuid"`
+	Email            string     `json:"email"`
+	Role             Role       `json:"role"`
+	InvitedBy        string     `json:"invited_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	AcceptedAt       *time.Time `json:"accepted_at,omitempty"`
+	// Token is only known when the invitation is created, the storage keeps its hash.
+	Token string `json:"-"`
+}
+
+func NewInvitation(organizationUUID, email string, role Role, invitedBy string, now time.Time,
+	ttl time.Duration) (Invitation, error) {
+	token, err := newToken()
+	if err != nil {
+		return Invitation{}, err
+	}
+	return Invitation{
+		OrganizationUUID: organizationUUID,
+		Email:            email,
+		Role:             role,
+		InvitedBy:        invitedBy,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(ttl),
+		Token:            token,
+	}, nil
+}
+
+// Accept makes the user a member with the invited role. The invitation is bound to
+// its email address, so that a forwarded token cannot be used by someone else.
+func (i *Invitation) Accept(user User, now time.Time) (Membership, error) {
+	if !strings.EqualFold(user.Email, i.Email) {
+		return Membership{}, apperror.ForbiddenError("invitation was sent to another email address")
+	}
+	return Membership{
+		OrganizationUUID: i.OrganizationUUID,
+		UserUUID:         user.UUID,
+		Role:             i.Role,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}, nil
+}
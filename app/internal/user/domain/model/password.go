@@ -0,0 +1,111 @@
+package model
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+var ErrPasswordMismatch = errors.New("password does not match")
+
+const argon2Prefix = "$argon2id$"
+
+// Bounds of the argon2id parameters accepted from imported hashes, every login
+// computes a key with them.
+const (
+	maxArgon2Memory  = 256 * 1024 // KiB
+	maxArgon2Time    = 10
+	maxArgon2Threads = 16
+	minArgon2Salt    = 8
+	maxArgon2Salt    = 64
+	minArgon2Key     = 16
+	maxArgon2Key     = 64
+)
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// ValidatePasswordHash checks that hash is a bcrypt hash or an argon2id hash
+// in the PHC string format ($argon2id$v=19$m=...,t=...,p=...$salt$key).
+func ValidatePasswordHash(hash string) error {
+	if strings.HasPrefix(hash, argon2Prefix) {
+		_, err := parseArgon2Hash(hash)
+		return err
+	}
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return fmt.Errorf("password hash is neither bcrypt nor argon2id")
+	}
+	return nil
+}
+
+func comparePasswordHash(hash, password string) error {
+	if !strings.HasPrefix(hash, argon2Prefix) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+		return err
+	}
+
+	params, err := parseArgon2Hash(hash)
+	if err != nil {
+		return err
+	}
+	key := argon2.IDKey([]byte(password), params.salt, params.time, params.memory, params.threads,
+		uint32(len(params.key)))
+	if subtle.ConstantTimeCompare(key, params.key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func parseArgon2Hash(hash string) (argon2Params, error) {
+	var params argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, fmt.Errorf("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, fmt.Errorf("invalid argon2id hash version")
+	}
+	if version != argon2.Version {
+		return params, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads)
+	if err != nil {
+		return params, fmt.Errorf("invalid argon2id hash parameters")
+	}
+	if params.threads < 1 || params.threads > maxArgon2Threads {
+		return params, fmt.Errorf("argon2id parallelism must be between 1 and %d", maxArgon2Threads)
+	}
+	if params.time < 1 || params.time > maxArgon2Time {
+		return params, fmt.Errorf("argon2id iterations must be between 1 and %d", maxArgon2Time)
+	}
+	if params.memory < 8*uint32(params.threads) || params.memory > maxArgon2Memory {
+		return params, fmt.Errorf("argon2id memory must be between %d and %d KiB",
+			8*uint32(params.threads), maxArgon2Memory)
+	}
+
+	params.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(params.salt) < minArgon2Salt || len(params.salt) > maxArgon2Salt {
+		return params, fmt.Errorf("invalid argon2id hash salt")
+	}
+	params.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(params.key) < minArgon2Key || len(params.key) > maxArgon2Key {
+		return params, fmt.Errorf("invalid argon2id hash key")
+	}
+	return params, nil
+}
@@ -0,0 +1,140 @@
+package model
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// PublicUser is the representation of a user without its secrets, the one patches
+// are applied to. Only the fields listed in PatchableFields can be changed by a patch.
+type PublicUser struct {
+	UUID              string     `json:"uuid"`
+	Name              string     `json:"name"`
+	Email             string     `json:"email"`
+	Username          string     `json:"username,omitempty"`
+	Status            Status     `json:"status"`
+	StatusReason      string     `json:"status_reason,omitempty"`
+	Version           int64      `json:"version"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	LastLoginAt       *time.Time `json:"last_login_at,omitempty"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+}
+
+// PatchableFields are the top level members of PublicUser writable through a patch.
+var PatchableFields = map[string]struct{}{
+	"name":     {},
+	"email":    {},
+	"username": {},
+}
+
+func NewPublicUser(user User) PublicUser {
+	return PublicUser{
+		UUID:              user.UUID,
+		Name:              user.Name,
+		Email:             user.Email,
+		Username:          user.Username,
+		Status:            user.Status,
+		StatusReason:      user.StatusReason,
+		Version:           user.Version,
+		CreatedAt:         user.CreatedAt,
+		UpdatedAt:         user.UpdatedAt,
+		LastLoginAt:       user.LastLoginAt,
+		PasswordChangedAt: user.PasswordChangedAt,
+	}
+}
+
+// NewPatchUpdate applies a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902)
+// to the public representation of the user and turns the result into an update.
+// A failed JSON Patch "test" operation is reported as a conflict.
+func NewPatchUpdate(existing User, format dto.PatchFormat, patch []byte) (dto.UpdateUserDTO, error) {
+	original, err := json.Marshal(NewPublicUser(existing))
+	if err != nil {
+		return dto.UpdateUserDTO{}, fmt.Errorf("failed to marshal user: %w", err)
+	}
+
+	var patched []byte
+	switch format {
+	case dto.PatchFormatMergePatch:
+		patched, err = jsonpatch.MergePatch(original, patch)
+		if err != nil {
+			return dto.UpdateUserDTO{}, apperror.BadRequestError(fmt.Sprintf("invalid merge patch: %v", err))
+		}
+	case dto.PatchFormatJSONPatch:
+		operations, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return dto.UpdateUserDTO{}, apperror.BadRequestError(fmt.Sprintf("invalid JSON patch: %v", err))
+		}
+		patched, err = operations.Apply(original)
+		if err != nil {
+			if errors.Is(err, jsonpatch.ErrTestFailed) {
+				return dto.UpdateUserDTO{}, apperror.ConflictError(err.Error())
+			}
+			return dto.UpdateUserDTO{}, apperror.BadRequestError(fmt.Sprintf("failed to apply JSON patch: %v", err))
+		}
+	default:
+		return dto.UpdateUserDTO{}, apperror.BadRequestError(fmt.Sprintf("unsupported patch format %q", format))
+	}
+
+	var before, after map[string]interface{}
+	if err = json.Unmarshal(original, &before); err != nil {
+		return dto.UpdateUserDTO{}, fmt.Errorf("failed to unmarshal user: %w", err)
+	}
+	if err = json.Unmarshal(patched, &after); err != nil {
+		return dto.UpdateUserDTO{}, apperror.BadRequestError("patched user must be a JSON object")
+	}
+
+	changed := changedFields(before, after)
+	for _, field := range changed {
+		if _, ok := PatchableFields[field]; !ok {
+			return dto.UpdateUserDTO{}, apperror.BadRequestError(fmt.Sprintf("field %q is read-only", field))
+		}
+	}
+
+	update := dto.UpdateUserDTO{UUID: existing.UUID}
+	for _, field := range changed {
+		value, ok := after[field].(string)
+		if field == "username" {
+			// the username is optional, removing it clears it
+			if after[field] != nil && !ok {
+				return dto.UpdateUserDTO{}, apperror.BadRequestError("username must be a string")
+			}
+			update.Username = &value
+			continue
+		}
+		if !ok || value == "" {
+			return dto.UpdateUserDTO{}, apperror.BadRequestError(fmt.Sprintf("%s must be a non-empty string", field))
+		}
+		switch field {
+		case "name":
+			update.Name = &value
+		case "email":
+			update.Email = &value
+		}
+	}
+	return update, nil
+}
+
+// changedFields returns the sorted top level members that differ between the documents.
+func changedFields(before, after map[string]interface{}) []string {
+	changed := make([]string, 0)
+	for field, value := range after {
+		if !reflect.DeepEqual(before[field], value) {
+			changed = append(changed, field)
+		}
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"Users/internal/apperror"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const phoneCodeDigits = 6
+
+// Purposes of the one-time codes sent to a phone.
+const (
+	PhoneCodeVerify = "verify"
+	PhoneCodeLogin  = "login"
+)
+
+// e164Regexp is a plus sign followed by a country code and at most 15 digits in total.
+var e164Regexp = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// phoneSeparators are the characters people format numbers with.
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "/", "")
+
+// NormalizePhone converts an international number to E.164, the 00 international
+// prefix being accepted for the plus sign. National numbers are rejected as the
+// country they belong to is unknown.
+func NormalizePhone(phone string) (string, error) {
+	normalized := phoneSeparators.Replace(strings.TrimSpace(phone))
+	if strings.HasPrefix(normalized, "00") {
+		normalized = "+" + normalized[2:]
+	}
+	if !e164Regexp.MatchString(normalized) {
+		return "", apperror.BadRequestError(fmt.Sprintf(
+			"phone %q must be an international number starting with + and the country code", phone))
+	}
+	return normalized, nil
+}
+
+// PhoneCode is a one-time code sent by SMS, the storage keeps only its hash.
+type PhoneCode struct {
+	ID        string
+	UserUUID  string
+	Phone     string
+	Purpose   string
+	CodeHash  []byte
+	Attempts  int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// NewPhoneCode generates a code of random digits for the user's phone.
+func NewPhoneCode(user User, purpose string, now time.Time, ttl time.Duration) (PhoneCode, string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(phoneCodeDigits), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return PhoneCode{}, "", err
+	}
+	code := fmt.Sprintf("%0*d", phoneCodeDigits, n)
+
+	return PhoneCode{
+		UserUUID:  user.UUID,
+		Phone:     user.Phone,
+		Purpose:   purpose,
+		CodeHash:  HashToken(code),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, code, nil
+}
+
+// Check verifies the code entered by the user. Every failed check counts as an attempt.
+func (c *PhoneCode) Check(code, phone string, now time.Time, maxAttempts int) error {
+	if c.Attempts >= maxAttempts {
+		return apperror.BadRequestError("too many wrong codes, request a new one")
+	}
+	if !now.Before(c.ExpiresAt) {
+		return apperror.BadRequestError("code has expired, request a new one")
+	}
+	if c.Phone != phone {
+		return apperror.BadRequestError("code was sent to another phone, request a new one")
+	}
+	if subtle.ConstantTimeCompare(c.CodeHash, HashToken(strings.TrimSpace(code))) != 1 {
+		return apperror.BadRequestError("incorrect code")
+	}
+	return nil
+}
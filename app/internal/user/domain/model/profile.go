@@ -0,0 +1,148 @@
+package model
+
+import (
+	"Users/internal/apperror"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"golang.org/x/text/language"
+	"net/url"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// DateFormats are the date formats a user can choose from.
+var DateFormats = []string{"YYYY-MM-DD", "DD.MM.YYYY", "DD/MM/YYYY", "MM/DD/YYYY"}
+
+var currencyRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Profile holds the user's settings. It is changed independently of the credentials,
+// Attributes keep the keys of other teams and follow the profile attributes schema.
+type Profile struct {
+	UserUUID  string `json:"user_uuid"`
+	Currency  string `json:"currency,omitempty"`
+	Locale    string `json:"locale,omitempty"`
+	Timezone  string `json:"timezone,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+	// AvatarID identifies the uploaded avatar, it is changed by uploads only.
+	AvatarID   string                 `json:"avatar_id,omitempty"`
+	DateFormat string                 `json:"date_format,omitempty"`
+	Attributes map[string]interface{} `json:"attributes"`
+	UpdatedAt  *time.Time             `json:"updated_at,omitempty"`
+}
+
+// profileDocument is the writable part of a profile that patches are applied to.
+type profileDocument struct {
+	Currency   string                 `json:"currency,omitempty"`
+	Locale     string                 `json:"locale,omitempty"`
+	Timezone   string                 `json:"timezone,omitempty"`
+	AvatarURL  string                 `json:"avatar_url,omitempty"`
+	DateFormat string                 `json:"date_format,omitempty"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+func (p *Profile) Validate() error {
+	if p.Currency != "" && !currencyRegexp.MatchString(p.Currency) {
+		return fmt.Errorf("currency must be an ISO 4217 code")
+	}
+	if p.Locale != "" {
+		if _, err := language.Parse(p.Locale); err != nil {
+			return fmt.Errorf("locale must be a BCP 47 language tag")
+		}
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("timezone must be an IANA time zone name")
+		}
+	}
+	if p.AvatarURL != "" {
+		avatarURL, err := url.Parse(p.AvatarURL)
+		if err != nil || (avatarURL.Scheme != "http" && avatarURL.Scheme != "https") || avatarURL.Host == "" {
+			return fmt.Errorf("avatar url must be an absolute http(s) url")
+		}
+	}
+	if p.DateFormat != "" && !slices.Contains(DateFormats, p.DateFormat) {
+		return fmt.Errorf("date format must be one of: %v", DateFormats)
+	}
+	return nil
+}
+
+// NewPatchedProfile applies a JSON Merge Patch (RFC 7396) to the writable part of the profile.
+// A null member clears a field or removes an attribute.
+func NewPatchedProfile(existing Profile, patch []byte) (Profile, error) {
+	original, err := json.Marshal(profileDocument{
+		Currency:   existing.Currency,
+		Locale:     existing.Locale,
+		Timezone:   existing.Timezone,
+		AvatarURL:  existing.AvatarURL,
+		DateFormat: existing.DateFormat,
+		Attributes: existing.Attributes,
+	})
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to marshal profile: %w", err)
+	}
+
+	patched, err := jsonpatch.MergePatch(original, patch)
+	if err != nil {
+		return Profile{}, apperror.BadRequestError(fmt.Sprintf("invalid merge patch: %v", err))
+	}
+
+	var document profileDocument
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&document); err != nil {
+		return Profile{}, apperror.BadRequestError(fmt.Sprintf("invalid profile: %v", err))
+	}
+
+	profile := Profile{
+		UserUUID:   existing.UserUUID,
+		Currency:   document.Currency,
+		Locale:     document.Locale,
+		Timezone:   document.Timezone,
+		AvatarURL:  document.AvatarURL,
+		AvatarID:   existing.AvatarID,
+		DateFormat: document.DateFormat,
+		Attributes: document.Attributes,
+		UpdatedAt:  existing.UpdatedAt,
+	}
+	if profile.Attributes == nil {
+		profile.Attributes = make(map[string]interface{})
+	}
+	if err = profile.Validate(); err != nil {
+		return Profile{}, apperror.BadRequestError(err.Error())
+	}
+	return profile, nil
+}
+
+// ProfileSchema is the admin defined JSON Schema of profile attributes.
+type ProfileSchema struct {
+	Schema    json.RawMessage `json:"schema"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+const profileSchemaURL = "mem:///profile_attributes.json"
+
+// Compile parses the schema, JSON Schema draft 2020-12 is assumed unless $schema says otherwise.
+func (s *ProfileSchema) Compile() (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	if err := compiler.AddResource(profileSchemaURL, bytes.NewReader(s.Schema)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(profileSchemaURL)
+}
+
+// ValidateAttributes checks the profile attributes against the schema.
+func (s *ProfileSchema) ValidateAttributes(attributes map[string]interface{}) error {
+	schema, err := s.Compile()
+	if err != nil {
+		return fmt.Errorf("failed to compile profile attributes schema: %w", err)
+	}
+	if err = schema.Validate(attributes); err != nil {
+		return apperror.BadRequestError(fmt.Sprintf("profile attributes do not match the schema: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusActive    Status = "active"
+	StatusSuspended Status = "suspended"
+	StatusLocked    Status = "locked"
+	StatusDeleted   Status = "deleted"
+)
+
+// statusTransitions lists the statuses reachable from each status.
+// Deleted users come back only through a restore.
+var statusTransitions = map[Status][]Status{
+	StatusPending:   {StatusActive, StatusDeleted},
+	StatusActive:    {StatusSuspended, StatusLocked, StatusDeleted},
+	StatusSuspended: {StatusActive, StatusDeleted},
+	StatusLocked:    {StatusActive, StatusDeleted},
+	StatusDeleted:   {},
+}
+
+func (s Status) IsValid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+func (s Status) CanTransitionTo(to Status) bool {
+	for _, next := range statusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Status) ValidateTransition(to Status) error {
+	if !to.IsValid() {
+		return fmt.Errorf("unknown status %q", to)
+	}
+	if !s.CanTransitionTo(to) {
+		return fmt.Errorf("user status cannot change from %s to %s", s, to)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"Users/internal/apperror"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 30
+)
+
+// Reasons a username cannot be claimed, see UsernameAvailability.
+const (
+	UsernameInvalid          = "invalid"
+	UsernameReserved         = "reserved"
+	UsernameTaken            = "taken"
+	UsernameRecentlyReleased = "recently_released"
+)
+
+// usernameRegexp allows latin letters, digits and single dots or underscores between them.
+var usernameRegexp = regexp.MustCompile(`^[a-z0-9]+(?:[._][a-z0-9]+)*$`)
+
+var allDigitsRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+// reservedUsernames could be mistaken for the service itself or for its routes.
+var reservedUsernames = map[string]struct{}{
+	"admin": {}, "administrator": {}, "root": {}, "system": {}, "sysadmin": {},
+	"support": {}, "help": {}, "helpdesk": {}, "info": {}, "contact": {},
+	"security": {}, "abuse": {}, "postmaster": {}, "hostmaster": {}, "webmaster": {},
+	"noreply": {}, "no_reply": {}, "mail": {}, "email": {}, "www": {}, "api": {},
+	"billing": {}, "payments": {}, "finance": {}, "budget": {}, "budgets": {},
+	"staff": {}, "team": {}, "official": {}, "moderator": {}, "owner": {},
+	"me": {}, "self": {}, "user": {}, "users": {}, "username": {}, "account": {},
+	"settings": {}, "profile": {}, "login": {}, "logout": {}, "signin": {},
+	"signup": {}, "register": {}, "null": {}, "undefined": {}, "anonymous": {},
+}
+
+// UsernameAvailability tells whether a username can be claimed and if not, why.
+type UsernameAvailability struct {
+	Username  string `json:"username"`
+	Available bool   `json:"available"`
+	// Reason is one of invalid, reserved, taken and recently_released.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// NewUsernameAvailability checks the username rules, the availability among
+// existing users is up to the caller.
+func NewUsernameAvailability(username string) UsernameAvailability {
+	normalized := normalizeUsername(username)
+	reason, message := usernameRules(normalized)
+	return UsernameAvailability{
+		Username:  normalized,
+		Available: reason == "",
+		Reason:    reason,
+		Message:   message,
+	}
+}
+
+// NormalizeUsername trims and lowercases the username, a leading "@" is dropped.
+// Usernames breaking the format rules or reserved ones are rejected.
+func NormalizeUsername(username string) (string, error) {
+	normalized := normalizeUsername(username)
+	if reason, message := usernameRules(normalized); reason != "" {
+		return "", apperror.BadRequestError(message)
+	}
+	return normalized, nil
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(username), "@"))
+}
+
+func usernameRules(username string) (reason, message string) {
+	switch {
+	case username == "":
+		return UsernameInvalid, "username must not be empty"
+	case len(username) < minUsernameLength || len(username) > maxUsernameLength:
+		return UsernameInvalid, fmt.Sprintf("username must be %d to %d characters long",
+			minUsernameLength, maxUsernameLength)
+	case !usernameRegexp.MatchString(username):
+		return UsernameInvalid, "username must consist of latin letters and digits, " +
+			"optionally separated by single dots or underscores"
+	case allDigitsRegexp.MatchString(username):
+		return UsernameInvalid, "username must not consist of digits only"
+	}
+	if _, ok := reservedUsernames[username]; ok {
+		return UsernameReserved, fmt.Sprintf("username %q is reserved", username)
+	}
+	return "", ""
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"Users/internal/apperror"
+	"Users/pkg/events"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const maxWebhookURLLength = 2048
+
+// EventTypes are the event types a webhook can subscribe to.
+var EventTypes = map[string]struct{}{
+	EventUserCreated:       {},
+	EventUserUpdated:       {},
+	EventUserDeleted:       {},
+	EventUserRestored:      {},
+	EventUserPurged:        {},
+	EventUserStatusChanged: {},
+	EventPasswordChanged:   {},
+	EventEmailChanged:      {},
+}
+
+// WebhookSubscription is a partner endpoint receiving the user domain events.
+type WebhookSubscription struct {
+	UUID string `json:"uuid"`
+	URL  string `json:"url"`
+	// Events are the event types sent to the endpoint, all of them when empty.
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+	// Secret signs the requests, it is only shown when the subscription is created.
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewWebhookSubscription(rawURL string, eventTypes []string, description string,
+	now time.Time) (WebhookSubscription, error) {
+	secret, err := newToken()
+	if err != nil {
+		return WebhookSubscription{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	subscription := WebhookSubscription{
+		Secret:    "whsec_" + secret,
+		CreatedAt: now,
+	}
+	if err = subscription.Update(rawURL, eventTypes, description, true, now); err != nil {
+		return WebhookSubscription{}, err
+	}
+	return subscription, nil
+}
+
+func (s *WebhookSubscription) Update(rawURL string, eventTypes []string, description string, active bool,
+	now time.Time) error {
+	rawURL = strings.TrimSpace(rawURL)
+	if len(rawURL) > maxWebhookURLLength {
+		return apperror.BadRequestError(fmt.Sprintf("url must not be longer than %d bytes", maxWebhookURLLength))
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return apperror.BadRequestError("url must be an absolute http or https URL")
+	}
+
+	seen := make(map[string]struct{}, len(eventTypes))
+	filter := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if _, ok := EventTypes[eventType]; !ok {
+			return apperror.BadRequestError(fmt.Sprintf("unknown event type %q", eventType))
+		}
+		if _, ok := seen[eventType]; ok {
+			continue
+		}
+		seen[eventType] = struct{}{}
+		filter = append(filter, eventType)
+	}
+
+	s.URL = rawURL
+	s.Events = filter
+	s.Description = strings.TrimSpace(description)
+	s.Active = active
+	s.UpdatedAt = now
+	return nil
+}
+
+// CreatedWebhook is returned once, when the subscription is created.
+type CreatedWebhook struct {
+	UUID   string `json:"uuid"`
+	Secret string `json:"secret"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryDead is the dead letter state of deliveries that ran out of attempts,
+	// only a manual redelivery sends them again.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+func (s DeliveryStatus) Valid() bool {
+	switch s {
+	case DeliveryPending, DeliverySucceeded, DeliveryDead:
+		return true
+	}
+	return false
+}
+
+// RetryPolicy spaces the attempts of a delivery, the wait doubles after every failure.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// WebhookDelivery is an event sent to a subscription together with the outcome of its last attempt.
+type WebhookDelivery struct {
+	ID               int64          `json:"id"`
+	SubscriptionUUID string         `json:"subscription_uuid"`
+	Event            events.Event   `json:"event"`
+	Status           DeliveryStatus `json:"status"`
+	Attempts         int            `json:"attempts"`
+	NextAttemptAt    *time.Time     `json:"next_attempt_at,omitempty"`
+	LastAttemptAt    *time.Time     `json:"last_attempt_at,omitempty"`
+	LastStatusCode   int            `json:"last_status_code,omitempty"`
+	LastError        string         `json:"last_error,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+
+	// URL and Secret of the subscription are only loaded to send the delivery.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// RecordAttempt schedules the next attempt after a failure or ends the delivery.
+func (d *WebhookDelivery) RecordAttempt(statusCode int, sendErr error, now time.Time, policy RetryPolicy) {
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.LastStatusCode = statusCode
+	d.NextAttemptAt = nil
+	if sendErr == nil {
+		d.Status = DeliverySucceeded
+		d.LastError = ""
+		return
+	}
+
+	d.LastError = sendErr.Error()
+	if d.Attempts >= policy.MaxAttempts {
+		d.Status = DeliveryDead
+		return
+	}
+	backoff := policy.InitialBackoff
+	for i := 1; i < d.Attempts && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	next := now.Add(backoff)
+	d.Status = DeliveryPending
+	d.NextAttemptAt = &next
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/audit"
+	"context"
+	"fmt"
+	"time"
+)
+
+// auditVerifyBatchSize is the number of entries read at once while verifying the chain.
+const auditVerifyBatchSize = 1000
+
+// auditLogin records a login attempt on the target user, which changes no user. Changes
+// are audited by the repository in their own transactions.
+func (s *service) auditLogin(ctx context.Context, action, targetUUID string, loginErr error) error {
+	entry, err := model.NewAuditEntry(audit.FromContext(ctx), action, targetUUID, nil, nil, time.Now())
+	if err != nil {
+		s.logger.Errorf("failed to audit %s of user %s: %v", action, targetUUID, err)
+		return fmt.Errorf("failed to audit %s: %w", action, err)
+	}
+	if loginErr != nil {
+		entry.Error = loginErr.Error()
+	}
+	if err = s.repository.AppendAuditEntry(ctx, entry); err != nil {
+		s.logger.Errorf("failed to audit %s of user %s: %v", action, targetUUID, err)
+		return fmt.Errorf("failed to audit %s: %w", action, err)
+	}
+	return nil
+}
+
+func (s *service) GetAuditLog(ctx context.Context, filter dto.AuditFilter) ([]model.AuditEntry, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, apperror.BadRequestError(err.Error())
+	}
+	entries, err := s.repository.FindAuditEntries(ctx, filter)
+	if err != nil {
+		s.logger.Errorf("failed to find audit log entries: %v", err)
+		return nil, fmt.Errorf("failed to find audit log entries: %w", err)
+	}
+	return entries, nil
+}
+
+// VerifyAuditLog walks the chain of the tenant from its first entry and recomputes every
+// hash, the first entry that does not match its hash or its predecessor breaks the chain.
+func (s *service) VerifyAuditLog(ctx context.Context) (model.AuditVerification, error) {
+	var verification model.AuditVerification
+	var after int64
+	for {
+		entries, err := s.repository.FindAuditChain(ctx, after, auditVerifyBatchSize)
+		if err != nil {
+			s.logger.Errorf("failed to find audit log entries: %v", err)
+			return model.AuditVerification{}, fmt.Errorf("failed to find audit log entries: %w", err)
+		}
+		for _, entry := range entries {
+			hash, err := entry.ComputeHash()
+			if err != nil {
+				return model.AuditVerification{}, err
+			}
+			if entry.PrevHash != verification.LastHash || entry.Hash != hash {
+				s.logger.Warnf("audit log chain broken at entry %d", entry.ID)
+				verification.BrokenAt = entry.ID
+				return verification, nil
+			}
+			verification.Checked++
+			verification.LastHash = entry.Hash
+			after = entry.ID
+		}
+		if len(entries) < auditVerifyBatchSize {
+			verification.Valid = true
+			return verification, nil
+		}
+	}
+}
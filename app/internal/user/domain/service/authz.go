@@ -0,0 +1,259 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/authz"
+	"Users/pkg/tenant"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxTuplesPerWrite bounds the tuples written or deleted by one request.
+const maxTuplesPerWrite = 1000
+
+func (s *service) Check(ctx context.Context, dto dto.CheckDTO) (model.CheckResult, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return model.CheckResult{}, apperror.BadRequestError(err.Error())
+	}
+	object, err := authz.ParseObject(dto.Object)
+	if err != nil {
+		return model.CheckResult{}, apperror.BadRequestError(err.Error())
+	}
+	subject, err := authz.ParseSubject(dto.Subject)
+	if err != nil {
+		return model.CheckResult{}, apperror.BadRequestError(err.Error())
+	}
+
+	revision := s.authzRevision(ctx)
+	checker, err := s.authzChecker(ctx, object.Namespace, dto.Relation)
+	if err != nil {
+		return model.CheckResult{}, err
+	}
+	allowed, err := s.check(ctx, revision, checker, object, dto.Relation, subject)
+	if err != nil {
+		return model.CheckResult{}, err
+	}
+	return model.CheckResult{Allowed: allowed}, nil
+}
+
+// authzRevision returns the revision of the cached check results of the tenant, taken
+// before the schema of a check is read.
+func (s *service) authzRevision(ctx context.Context) uint64 {
+	tenantID, _ := tenant.FromContext(ctx)
+	return s.authzCache.Revision(tenantID)
+}
+
+// check answers from the cache of check results at the revision when it can.
+func (s *service) check(ctx context.Context, revision uint64, checker *authz.Checker, object authz.Object,
+	relation string, subject authz.Subject) (bool, error) {
+	tenantID, _ := tenant.FromContext(ctx)
+	key := authz.Tuple{Object: object, Relation: relation, Subject: subject}.String()
+	if allowed, ok := s.authzCache.Get(tenantID, revision, key); ok {
+		return allowed, nil
+	}
+
+	allowed, err := checker.Check(ctx, object, relation, subject)
+	if err != nil {
+		return false, s.authzError(err, "check relation")
+	}
+	s.authzCache.Set(tenantID, revision, key, allowed)
+	return allowed, nil
+}
+
+func (s *service) Expand(ctx context.Context, dto dto.ExpandDTO) (authz.Node, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return authz.Node{}, apperror.BadRequestError(err.Error())
+	}
+	object, err := authz.ParseObject(dto.Object)
+	if err != nil {
+		return authz.Node{}, apperror.BadRequestError(err.Error())
+	}
+
+	checker, err := s.authzChecker(ctx, object.Namespace, dto.Relation)
+	if err != nil {
+		return authz.Node{}, err
+	}
+	node, err := checker.Expand(ctx, object, dto.Relation)
+	if err != nil {
+		return authz.Node{}, s.authzError(err, "expand relation")
+	}
+	return node, nil
+}
+
+// ListObjects checks the relation on every object of the namespace that appears in a
+// tuple, objects without tuples cannot be related to anything.
+func (s *service) ListObjects(ctx context.Context, dto dto.ListObjectsDTO) (model.ObjectList, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return model.ObjectList{}, apperror.BadRequestError(err.Error())
+	}
+	subject, err := authz.ParseSubject(dto.Subject)
+	if err != nil {
+		return model.ObjectList{}, apperror.BadRequestError(err.Error())
+	}
+
+	revision := s.authzRevision(ctx)
+	checker, err := s.authzChecker(ctx, dto.Namespace, dto.Relation)
+	if err != nil {
+		return model.ObjectList{}, err
+	}
+
+	ids, err := s.repository.FindObjectIDs(ctx, dto.Namespace)
+	if err != nil {
+		s.logger.Errorf("failed to find objects: %v", err)
+		return model.ObjectList{}, fmt.Errorf("failed to find objects: %w", err)
+	}
+
+	objects := make([]string, 0)
+	for _, id := range ids {
+		object := authz.Object{Namespace: dto.Namespace, ID: id}
+		allowed, err := s.check(ctx, revision, checker, object, dto.Relation, subject)
+		if err != nil {
+			return model.ObjectList{}, err
+		}
+		if allowed {
+			objects = append(objects, object.String())
+		}
+	}
+	return model.ObjectList{Objects: objects}, nil
+}
+
+func (s *service) GetTuples(ctx context.Context, object, relation string) ([]authz.Tuple, error) {
+	parsed, err := authz.ParseObject(object)
+	if err != nil {
+		return nil, apperror.BadRequestError(err.Error())
+	}
+	tuples, err := s.repository.FindTuples(ctx, parsed, relation)
+	if err != nil {
+		s.logger.Errorf("failed to find relation tuples: %v", err)
+		return nil, fmt.Errorf("failed to find relation tuples: %w", err)
+	}
+	return tuples, nil
+}
+
+func (s *service) WriteTuples(ctx context.Context, dto dto.RelationTuplesDTO) error {
+	tuples, err := s.parseTuples(ctx, dto)
+	if err != nil {
+		return err
+	}
+	if err = s.repository.WriteTuples(ctx, tuples, time.Now().UTC()); err != nil {
+		return s.authzError(err, "write relation tuples")
+	}
+	s.invalidateChecks(ctx)
+	return nil
+}
+
+func (s *service) DeleteTuples(ctx context.Context, dto dto.RelationTuplesDTO) error {
+	tuples, err := s.parseTuples(ctx, dto)
+	if err != nil {
+		return err
+	}
+	if err = s.repository.DeleteTuples(ctx, tuples); err != nil {
+		return s.authzError(err, "delete relation tuples")
+	}
+	s.invalidateChecks(ctx)
+	return nil
+}
+
+// parseTuples parses the tuples and validates them against the schema.
+func (s *service) parseTuples(ctx context.Context, dto dto.RelationTuplesDTO) ([]authz.Tuple, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return nil, apperror.BadRequestError(err.Error())
+	}
+	if len(dto.Tuples) > maxTuplesPerWrite {
+		return nil, apperror.TooLargeError(fmt.Sprintf("at most %d tuples can be written at once", maxTuplesPerWrite))
+	}
+	schema, err := s.authzSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tuples := make([]authz.Tuple, 0, len(dto.Tuples))
+	for i, input := range dto.Tuples {
+		tuple, err := authz.ParseTuple(input.Object + "#" + input.Relation + "@" + input.Subject)
+		if err != nil {
+			return nil, apperror.BadRequestError(fmt.Sprintf("tuple %d: %v", i, err))
+		}
+		if err = schema.ValidateTuple(tuple); err != nil {
+			return nil, apperror.BadRequestError(fmt.Sprintf("tuple %d: %v", i, err))
+		}
+		tuples = append(tuples, tuple)
+	}
+	return tuples, nil
+}
+
+func (s *service) GetAuthzSchema(ctx context.Context) (string, error) {
+	source, err := s.repository.FindAuthzSchema(ctx)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return "", err
+		}
+		s.logger.Errorf("failed to find authorization schema: %v", err)
+		return "", fmt.Errorf("failed to find authorization schema: %w", err)
+	}
+	return source, nil
+}
+
+// UpdateAuthzSchema replaces the schema. Tuples of relations the new schema no longer
+// declares are kept but ignored by checks.
+func (s *service) UpdateAuthzSchema(ctx context.Context, source string) error {
+	if _, err := authz.ParseSchema(source); err != nil {
+		return apperror.BadRequestError(fmt.Sprintf("invalid authorization schema: %v", err))
+	}
+	if err := s.repository.SaveAuthzSchema(ctx, source, time.Now().UTC()); err != nil {
+		s.logger.Errorf("failed to save authorization schema: %v", err)
+		return fmt.Errorf("failed to save authorization schema: %w", err)
+	}
+	s.invalidateChecks(ctx)
+	return nil
+}
+
+func (s *service) authzSchema(ctx context.Context) (*authz.Schema, error) {
+	source, err := s.GetAuthzSchema(ctx)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil, apperror.BadRequestError("no authorization schema has been saved")
+		}
+		return nil, err
+	}
+	schema, err := authz.ParseSchema(source)
+	if err != nil {
+		s.logger.Errorf("failed to parse stored authorization schema: %v", err)
+		return nil, fmt.Errorf("failed to parse stored authorization schema: %w", err)
+	}
+	return schema, nil
+}
+
+// authzChecker returns a checker for the current schema once the relation to evaluate
+// is known to be declared in it.
+func (s *service) authzChecker(ctx context.Context, namespace, relation string) (*authz.Checker, error) {
+	schema, err := s.authzSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = schema.Relation(namespace, relation); err != nil {
+		return nil, apperror.BadRequestError(err.Error())
+	}
+	return authz.NewChecker(schema, s.repository), nil
+}
+
+func (s *service) invalidateChecks(ctx context.Context) {
+	tenantID, _ := tenant.FromContext(ctx)
+	s.authzCache.Invalidate(tenantID)
+}
+
+// authzError reports relations nested too deeply as a bad request and wraps storage errors.
+func (s *service) authzError(err error, action string) error {
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if errors.Is(err, authz.ErrMaxDepth) {
+		return apperror.BadRequestError(err.Error())
+	}
+	s.logger.Errorf("failed to %s: %v", action, err)
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"Users/internal/user/domain/dto"
+	"Users/pkg/authz"
+	"Users/pkg/tenant"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testAuthzSchema = `
+namespace user {}
+
+namespace document {
+  relation viewer
+}
+`
+
+// tupleRepository keeps the tuples in memory. A read of the tuples can be held while
+// a write runs, the way a check overlaps a write in the database.
+type tupleRepository struct {
+	Repository
+	mu     sync.Mutex
+	tuples map[string]authz.Tuple
+	// read is told when the tuples have been read, then the read waits for resume
+	read   chan struct{}
+	resume chan struct{}
+}
+
+func (r *tupleRepository) FindAuthzSchema(ctx context.Context) (string, error) {
+	return testAuthzSchema, nil
+}
+
+func (r *tupleRepository) FindTuples(ctx context.Context, object authz.Object, relation string) ([]authz.Tuple, error) {
+	r.mu.Lock()
+	found := make([]authz.Tuple, 0)
+	for _, tuple := range r.tuples {
+		if tuple.Object == object && (relation == "" || tuple.Relation == relation) {
+			found = append(found, tuple)
+		}
+	}
+	r.mu.Unlock()
+
+	if r.read != nil {
+		r.read <- struct{}{}
+		<-r.resume
+	}
+	return found, nil
+}
+
+func (r *tupleRepository) DeleteTuples(ctx context.Context, tuples []authz.Tuple) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, tuple := range tuples {
+		delete(r.tuples, tuple.String())
+	}
+	return nil
+}
+
+func TestCheckOverlappingADeleteIsNotCached(t *testing.T) {
+	tuple := authz.Tuple{
+		Object:   authz.Object{Namespace: "document", ID: "readme"},
+		Relation: "viewer",
+		Subject:  authz.Subject{Object: authz.Object{Namespace: "user", ID: "alice"}},
+	}
+	repository := &tupleRepository{
+		tuples: map[string]authz.Tuple{tuple.String(): tuple},
+		read:   make(chan struct{}),
+		resume: make(chan struct{}),
+	}
+	s := NewService(repository, nil, nil, nil, nil, Options{
+		AuthzCheckCacheTTL:  time.Minute,
+		AuthzCheckCacheSize: 100,
+	}, newTestLogger())
+	ctx := tenant.WithID(context.Background(), "5d0c8a47-1e2b-4c6f-9a3d-8b7e6f5a4c21")
+	check := dto.CheckDTO{Object: "document:readme", Relation: "viewer", Subject: "user:alice"}
+
+	results := make(chan bool)
+	go func() {
+		result, err := s.Check(ctx, check)
+		if err != nil {
+			t.Errorf("check failed: %v", err)
+		}
+		results <- result.Allowed
+	}()
+
+	// the check has read the tuple, which is deleted before the check finishes
+	<-repository.read
+	err := s.DeleteTuples(ctx, dto.RelationTuplesDTO{Tuples: []dto.RelationTupleDTO{
+		{Object: "document:readme", Relation: "viewer", Subject: "user:alice"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to delete tuple: %v", err)
+	}
+	repository.resume <- struct{}{}
+	if !<-results {
+		t.Fatal("the check that read the tuple before the delete was not allowed")
+	}
+
+	repository.read = nil
+	result, err := s.Check(ctx, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed {
+		t.Error("the deleted tuple still allows the check from the cache")
+	}
+}
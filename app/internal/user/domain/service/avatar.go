@@ -0,0 +1,135 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/blob"
+	"Users/pkg/utils"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+var avatarIDRegexp = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// UploadAvatar stores the thumbnails of the image and makes it the user's avatar.
+// The avatar id is derived from the content, so thumbnail URLs never change their content.
+func (s *service) UploadAvatar(ctx context.Context, dto dto.UploadAvatarDTO) (model.Avatar, error) {
+	if int64(len(dto.Content)) > s.opts.AvatarMaxSize {
+		return model.Avatar{}, apperror.TooLargeError(fmt.Sprintf("avatar must not be larger than %d bytes",
+			s.opts.AvatarMaxSize))
+	}
+	if _, err := sniffAvatar(dto.Content); err != nil {
+		return model.Avatar{}, err
+	}
+	// thumbnails of unknown users would never be cleaned up
+	if _, err := s.GetProfile(ctx, dto.UUID); err != nil {
+		return model.Avatar{}, err
+	}
+
+	thumbnails, err := processAvatar(dto.Content, s.opts.AvatarSizes)
+	if err != nil {
+		return model.Avatar{}, err
+	}
+
+	hash := sha256.Sum256(dto.Content)
+	avatarID := hex.EncodeToString(hash[:16])
+	for size, thumbnail := range thumbnails {
+		err = s.storage.Put(ctx, model.AvatarKey(dto.UUID, avatarID, size), bytes.NewReader(thumbnail),
+			int64(len(thumbnail)), model.AvatarContentType)
+		if err != nil {
+			s.logger.Errorf("failed to store avatar: %v", err)
+			return model.Avatar{}, fmt.Errorf("failed to store avatar: %w", err)
+		}
+	}
+
+	var previousID string
+	_, err = s.repository.UpdateProfile(ctx, dto.UUID, model.AuditAvatarChanged, func(profile model.Profile) (model.Profile, error) {
+		previousID = profile.AvatarID
+		profile.AvatarID = avatarID
+		profile.AvatarURL = s.avatarURL(dto.UUID, avatarID)
+		return profile, nil
+	})
+	if err != nil {
+		s.deleteAvatarThumbnails(ctx, dto.UUID, avatarID)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return model.Avatar{}, err
+		}
+		s.logger.Errorf("failed to set avatar: %v", err)
+		return model.Avatar{}, fmt.Errorf("failed to set avatar: %w", err)
+	}
+	if previousID != "" && previousID != avatarID {
+		s.deleteAvatarThumbnails(ctx, dto.UUID, previousID)
+	}
+
+	return model.NewAvatar(s.opts.AvatarBaseURL, dto.UUID, avatarID, s.opts.AvatarSizes), nil
+}
+
+// DeleteAvatar removes the uploaded avatar, an external avatar url set through the profile is kept.
+func (s *service) DeleteAvatar(ctx context.Context, uuid string) error {
+	var avatarID string
+	_, err := s.repository.UpdateProfile(ctx, uuid, model.AuditAvatarChanged, func(profile model.Profile) (model.Profile, error) {
+		if profile.AvatarID == "" {
+			return model.Profile{}, apperror.ErrNotFound
+		}
+		avatarID = profile.AvatarID
+		if profile.AvatarURL == s.avatarURL(uuid, avatarID) {
+			profile.AvatarURL = ""
+		}
+		profile.AvatarID = ""
+		return profile, nil
+	})
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		s.logger.Errorf("failed to delete avatar: %v", err)
+		return fmt.Errorf("failed to delete avatar: %w", err)
+	}
+
+	s.deleteAvatarThumbnails(ctx, uuid, avatarID)
+	return nil
+}
+
+func (s *service) OpenAvatar(ctx context.Context, uuid, avatarID string, size int) (model.AvatarImage, error) {
+	// the parts end up in a storage key, anything unexpected is simply not found
+	if !utils.IsValidUUID(uuid) || !avatarIDRegexp.MatchString(avatarID) || !slices.Contains(s.opts.AvatarSizes, size) {
+		return model.AvatarImage{}, apperror.ErrNotFound
+	}
+
+	content, object, err := s.storage.Get(ctx, model.AvatarKey(uuid, avatarID, size))
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return model.AvatarImage{}, apperror.ErrNotFound
+		}
+		s.logger.Errorf("failed to open avatar: %v", err)
+		return model.AvatarImage{}, fmt.Errorf("failed to open avatar: %w", err)
+	}
+	return model.AvatarImage{
+		Content:     content,
+		ContentType: model.AvatarContentType,
+		Size:        object.Size,
+		ModTime:     object.ModTime,
+	}, nil
+}
+
+// avatarURL is the profile avatar url of an uploaded avatar, its largest thumbnail.
+func (s *service) avatarURL(uuid, avatarID string) string {
+	return model.NewAvatar(s.opts.AvatarBaseURL, uuid, avatarID, s.opts.AvatarSizes).
+		URLs[fmt.Sprint(slices.Max(s.opts.AvatarSizes))]
+}
+
+// deleteAvatarThumbnails is best effort, leftovers only take storage space.
+func (s *service) deleteAvatarThumbnails(ctx context.Context, uuid, avatarID string) {
+	for _, size := range s.opts.AvatarSizes {
+		if err := s.storage.Delete(ctx, model.AvatarKey(uuid, avatarID, size)); err != nil {
+			s.logger.Warnf("failed to delete avatar thumbnail: %v", err)
+		}
+	}
+}
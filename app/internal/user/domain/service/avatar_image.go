@@ -0,0 +1,179 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"slices"
+)
+
+const (
+	// maxAvatarPixels guards against decompression bombs, the header is checked before decoding
+	maxAvatarPixels = 50_000_000
+	avatarQuality   = 85
+)
+
+var avatarContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// sniffAvatar detects the image type from the content, the declared type is not trusted.
+func sniffAvatar(content []byte) (string, error) {
+	contentType := http.DetectContentType(content)
+	if !slices.Contains(avatarContentTypes, contentType) {
+		return "", apperror.BadRequestError(fmt.Sprintf("unsupported avatar type %s, allowed: %v",
+			contentType, avatarContentTypes))
+	}
+	return contentType, nil
+}
+
+// processAvatar makes square JPEG thumbnails of the given edge sizes. Re-encoding drops
+// EXIF and other metadata, so the EXIF orientation is applied to the pixels first.
+func processAvatar(content []byte, sizes []int) (map[int][]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, apperror.BadRequestError("avatar is not a valid image")
+	}
+	if config.Width*config.Height > maxAvatarPixels {
+		return nil, apperror.TooLargeError(fmt.Sprintf("avatar must not have more than %d pixels", maxAvatarPixels))
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, apperror.BadRequestError("avatar is not a valid image")
+	}
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(content)
+	}
+
+	// the centered square is kept, which commutes with the orientation
+	bounds := img.Bounds()
+	edge := min(bounds.Dx(), bounds.Dy())
+	square := image.Rect(0, 0, edge, edge).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-edge)/2,
+		bounds.Min.Y+(bounds.Dy()-edge)/2,
+	))
+
+	thumbnails := make(map[int][]byte, len(sizes))
+	for _, size := range sizes {
+		thumbnail := image.NewRGBA(image.Rect(0, 0, size, size))
+		// JPEG has no alpha channel, transparent pixels become white
+		draw.Draw(thumbnail, thumbnail.Bounds(), image.White, image.Point{}, draw.Src)
+		xdraw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, square, draw.Over, nil)
+
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, orient(thumbnail, orientation), &jpeg.Options{Quality: avatarQuality})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode avatar: %w", err)
+		}
+		thumbnails[size] = buf.Bytes()
+	}
+	return thumbnails, nil
+}
+
+// jpegOrientation reads the EXIF orientation tag (1-8) of a JPEG, 1 if there is none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		// metadata segments precede the start of scan
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for n := 0; n < count; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// orient transforms the image so that it is displayed upright for the given EXIF orientation.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	oriented := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90 counterclockwise
+				dx, dy = y, w-1-x
+			}
+			oriented.SetRGBA(dx, dy, img.RGBAAt(x, y))
+		}
+	}
+	return oriented
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/blob"
+	"Users/pkg/logging"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// profileRepository keeps the profiles of the avatar tests in memory, other repository methods are not used.
+type profileRepository struct {
+	Repository
+	profiles map[string]model.Profile
+}
+
+func (r *profileRepository) FindProfile(ctx context.Context, uuid string) (model.Profile, error) {
+	profile, ok := r.profiles[uuid]
+	if !ok {
+		return model.Profile{}, apperror.ErrNotFound
+	}
+	return profile, nil
+}
+
+func (r *profileRepository) UpdateProfile(ctx context.Context, uuid, action string,
+	fn func(profile model.Profile) (model.Profile, error)) (model.Profile, error) {
+	profile, err := r.FindProfile(ctx, uuid)
+	if err != nil {
+		return model.Profile{}, err
+	}
+	if profile, err = fn(profile); err != nil {
+		return model.Profile{}, err
+	}
+	r.profiles[uuid] = profile
+	return profile, nil
+}
+
+// newS3TestStorage connects to the MinIO of docker-compose.yml, or the S3 compatible
+// service at TEST_S3_ENDPOINT, in a bucket of its own.
+func newS3TestStorage(t *testing.T) blob.Storage {
+	t.Helper()
+	endpoint := os.Getenv("TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("TEST_S3_ENDPOINT is not set, e.g. localhost:9000 for the MinIO of docker-compose.yml")
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	storage, err := blob.NewS3Storage(context.Background(), blob.S3Options{
+		Endpoint:  endpoint,
+		Bucket:    "avatars-test-" + hex.EncodeToString(suffix),
+		AccessKey: envOrDefault("TEST_S3_ACCESS_KEY", "minioadmin"),
+		SecretKey: envOrDefault("TEST_S3_SECRET_KEY", "minioadmin"),
+	})
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", endpoint, err)
+	}
+	return storage
+}
+
+func envOrDefault(key, value string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return value
+}
+
+func newTestLogger() *logging.Logger {
+	l := logrus.New()
+	l.SetOutput(io.Discard)
+	return &logging.Logger{Entry: logrus.NewEntry(l)}
+}
+
+// testJPEG encodes a width x height image with an EXIF segment carrying marker.
+func testJPEG(t *testing.T, width, height int, marker string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// an empty little endian TIFF directory followed by the marker
+	exif := append([]byte("Exif\x00\x00II*\x00\x08\x00\x00\x00\x00\x00"), marker...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	segment = append(segment, exif...)
+
+	content := append([]byte{}, encoded.Bytes()[:2]...)
+	content = append(content, segment...)
+	return append(content, encoded.Bytes()[2:]...)
+}
+
+func TestUploadAvatarS3(t *testing.T) {
+	storage := newS3TestStorage(t)
+	ctx := context.Background()
+	userUUID := "3f1c1c5e-4b2a-4f4e-9a43-0c1d2e3f4a5b"
+	sizes := []int{64, 256}
+	repository := &profileRepository{profiles: map[string]model.Profile{userUUID: {}}}
+	s := NewService(repository, storage, nil, nil, nil, Options{
+		AvatarBaseURL: "http://localhost:10001",
+		AvatarMaxSize: 1 << 20,
+		AvatarSizes:   sizes,
+	}, newTestLogger())
+
+	const marker = "camera-serial-0042"
+	content := testJPEG(t, 400, 300, marker)
+	avatar, err := s.UploadAvatar(ctx, dto.UploadAvatarDTO{UUID: userUUID, Content: content})
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if len(avatar.URLs) != len(sizes) {
+		t.Fatalf("got %d thumbnail urls, want %d", len(avatar.URLs), len(sizes))
+	}
+	if got := repository.profiles[userUUID].AvatarID; got != avatar.ID {
+		t.Fatalf("profile avatar id is %q, want %q", got, avatar.ID)
+	}
+
+	for _, size := range sizes {
+		if avatar.URLs[strconv.Itoa(size)] == "" {
+			t.Errorf("no url for size %d", size)
+		}
+		thumbnail, err := s.OpenAvatar(ctx, userUUID, avatar.ID, size)
+		if err != nil {
+			t.Fatalf("failed to open the %d thumbnail: %v", size, err)
+		}
+		data, err := io.ReadAll(thumbnail.Content)
+		_ = thumbnail.Content.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if thumbnail.ContentType != model.AvatarContentType {
+			t.Errorf("thumbnail content type is %q", thumbnail.ContentType)
+		}
+		if bytes.Contains(data, []byte("Exif")) || bytes.Contains(data, []byte(marker)) {
+			t.Errorf("the %d thumbnail keeps the EXIF metadata", size)
+		}
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("the %d thumbnail is not a JPEG: %v", size, err)
+		}
+		if bounds := decoded.Bounds(); bounds.Dx() != size || bounds.Dy() != size {
+			t.Errorf("the %d thumbnail is %dx%d", size, bounds.Dx(), bounds.Dy())
+		}
+	}
+
+	// the same image is the same avatar, its URLs do not change
+	again, err := s.UploadAvatar(ctx, dto.UploadAvatarDTO{UUID: userUUID, Content: content})
+	if err != nil {
+		t.Fatalf("second upload failed: %v", err)
+	}
+	if again.ID != avatar.ID {
+		t.Errorf("same image got avatar id %q, then %q", avatar.ID, again.ID)
+	}
+
+	if err = s.DeleteAvatar(ctx, userUUID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err = s.OpenAvatar(ctx, userUUID, avatar.ID, sizes[0]); !errors.Is(err, apperror.ErrNotFound) {
+		t.Errorf("deleted thumbnail opened with error %v", err)
+	}
+}
+
+func TestUploadAvatarS3Rejects(t *testing.T) {
+	storage := newS3TestStorage(t)
+	userUUID := "3f1c1c5e-4b2a-4f4e-9a43-0c1d2e3f4a5b"
+	s := NewService(&profileRepository{profiles: map[string]model.Profile{userUUID: {}}}, storage, nil, nil, nil,
+		Options{AvatarMaxSize: 1 << 10, AvatarSizes: []int{64}}, newTestLogger())
+
+	tests := []struct {
+		name    string
+		uuid    string
+		content []byte
+		check   func(error) bool
+	}{
+		{"too large", userUUID, testJPEG(t, 200, 200, ""), apperror.IsTooLarge},
+		{"not an image", userUUID, []byte("plain text"), isBadRequest},
+		{"unknown user", "0b9d7b4e-1d2c-4e5f-8a7b-6c5d4e3f2a1b", testJPEG(t, 8, 8, ""), func(err error) bool {
+			return errors.Is(err, apperror.ErrNotFound)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.UploadAvatar(context.Background(), dto.UploadAvatarDTO{UUID: tt.uuid, Content: tt.content})
+			if !tt.check(err) {
+				t.Errorf("got error %v", err)
+			}
+		})
+	}
+}
+
+func isBadRequest(err error) bool {
+	var appErr *apperror.AppError
+	return errors.As(err, &appErr) && appErr.Code == apperror.BadRequestError("").Code
+}
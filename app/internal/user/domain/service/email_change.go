@@ -0,0 +1,99 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/model"
+	"Users/pkg/mail"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	confirmEmailSubject = "Confirm your new email address"
+	confirmEmailBody    = `A request was made to change the email address of your account from %s to %s.
+
+Confirm the change by opening the link below within %s:
+%s
+
+If you did not request it, ignore this message.`
+
+	emailChangeNoticeSubject = "Your email address is being changed"
+	emailChangeNoticeBody    = `A request was made to change the email address of your account from %s to %s.
+It takes effect once the new address confirms it.
+
+If you did not request it, revert the change by opening the link below within %s:
+%s`
+)
+
+func (s *service) checkEmailAvailable(ctx context.Context, email string) error {
+	_, err := s.repository.FindByEmail(ctx, email)
+	if err == nil {
+		return apperror.BadRequestError("User with this email already exists")
+	}
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil
+	}
+	s.logger.Errorf("failed to find user by email: %v", err)
+	return fmt.Errorf("failed to find user by email: %w", err)
+}
+
+// sendEmailChangeLinks mails a confirmation link to the new address of a stored change
+// and a revert link to the old one. The change is kept if a mail fails, requesting it
+// again sends new links and supersedes it.
+func (s *service) sendEmailChangeLinks(ctx context.Context, change model.EmailChange) {
+	err := s.mailer.Send(ctx, mail.Message{
+		To:      change.NewEmail,
+		Subject: confirmEmailSubject,
+		Body: fmt.Sprintf(confirmEmailBody, change.OldEmail, change.NewEmail, s.opts.EmailChangeTTL,
+			s.emailChangeLink(ctx, "confirm", change.ConfirmToken)),
+	})
+	if err != nil {
+		s.logger.Errorf("failed to send email change confirmation: %v", err)
+	}
+
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      change.OldEmail,
+		Subject: emailChangeNoticeSubject,
+		Body: fmt.Sprintf(emailChangeNoticeBody, change.OldEmail, change.NewEmail, s.opts.EmailChangeRevertPeriod,
+			s.emailChangeLink(ctx, "revert", change.RevertToken)),
+	})
+	if err != nil {
+		s.logger.Errorf("failed to send email change notice: %v", err)
+	}
+}
+
+func (s *service) emailChangeLink(ctx context.Context, action, token string) string {
+	return fmt.Sprintf("%s/api/email-change/%s?token=%s%s", s.opts.PublicURL, action, url.QueryEscape(token),
+		tenantQuery(ctx))
+}
+
+func (s *service) ConfirmEmailChange(ctx context.Context, token string) (model.EmailChange, error) {
+	change, err := s.repository.ConfirmEmailChange(ctx, model.HashToken(token), time.Now().UTC())
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return model.EmailChange{}, err
+		}
+		s.logger.Errorf("failed to confirm email change: %v", err)
+		return model.EmailChange{}, fmt.Errorf("failed to confirm email change: %w", err)
+	}
+	return change, nil
+}
+
+func (s *service) RevertEmailChange(ctx context.Context, token string) (model.EmailChange, error) {
+	now := time.Now().UTC()
+	change, err := s.repository.RevertEmailChange(ctx, model.HashToken(token),
+		now.Add(-s.opts.EmailChangeRevertPeriod), now)
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return model.EmailChange{}, err
+		}
+		s.logger.Errorf("failed to revert email change: %v", err)
+		return model.EmailChange{}, fmt.Errorf("failed to revert email change: %w", err)
+	}
+	return change, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/mail"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// updateRepository holds a single user and records the updates of the email change tests.
+type updateRepository struct {
+	Repository
+	user         model.User
+	updated      *model.User
+	emailChanges []model.EmailChange
+}
+
+func (r *updateRepository) FindByUUID(ctx context.Context, uuid string) (model.User, error) {
+	if uuid != r.user.UUID {
+		return model.User{}, apperror.ErrNotFound
+	}
+	return r.user, nil
+}
+
+func (r *updateRepository) FindByEmail(ctx context.Context, email string) (model.User, error) {
+	return model.User{}, apperror.ErrNotFound
+}
+
+func (r *updateRepository) Update(ctx context.Context, user model.User, emailChange *model.EmailChange) error {
+	r.updated = &user
+	if emailChange != nil {
+		r.emailChanges = append(r.emailChanges, *emailChange)
+	}
+	return nil
+}
+
+// failingMailer records the messages it is asked to send and fails to send them.
+type failingMailer struct {
+	sent []mail.Message
+}
+
+func (m *failingMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return errors.New("smtp unavailable")
+}
+
+func TestUpdateRequestsEmailChangeWithTheUpdate(t *testing.T) {
+	user := model.User{UUID: "4c3c8d32-5b7e-4be6-bde1-231f0eeda630", Name: "Old", Email: "old@example.com",
+		Password: "secret", Version: 3}
+	if err := user.GeneratePasswordHash(); err != nil {
+		t.Fatal(err)
+	}
+	repository := &updateRepository{user: user}
+	mailer := &failingMailer{}
+	s := NewService(repository, nil, mailer, nil, nil, Options{PublicURL: "http://localhost"}, newTestLogger())
+
+	name, email := "New", "New@Example.com"
+	err := s.Update(context.Background(), dto.UpdateUserDTO{UUID: user.UUID, Name: &name, Email: &email,
+		Password: "secret"})
+	if err != nil {
+		t.Fatalf("update failed although only the mails failed: %v", err)
+	}
+
+	if repository.updated == nil || repository.updated.Name != name || repository.updated.Email != user.Email {
+		t.Errorf("updated user %+v, want the new name and the old email", repository.updated)
+	}
+	if len(repository.emailChanges) != 1 {
+		t.Fatalf("stored %d email changes with the update, want 1", len(repository.emailChanges))
+	}
+	change := repository.emailChanges[0]
+	if change.OldEmail != user.Email || change.NewEmail != "new@example.com" {
+		t.Errorf("email change from %s to %s", change.OldEmail, change.NewEmail)
+	}
+
+	if len(mailer.sent) != 2 {
+		t.Fatalf("tried to send %d mails, want the confirmation and the notice", len(mailer.sent))
+	}
+	if mailer.sent[0].To != change.NewEmail || !strings.Contains(mailer.sent[0].Body, "/api/email-change/confirm") {
+		t.Errorf("first mail to %s, want the confirmation link to the new address", mailer.sent[0].To)
+	}
+	if mailer.sent[1].To != change.OldEmail || !strings.Contains(mailer.sent[1].Body, "/api/email-change/revert") {
+		t.Errorf("second mail to %s, want the revert link to the old address", mailer.sent[1].To)
+	}
+}
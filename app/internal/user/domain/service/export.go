@@ -0,0 +1,42 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const exportBatchSize = 1000
+
+func (s *service) Export(ctx context.Context, input dto.ExportUsersDTO, w io.Writer) error {
+	if err := input.Validate(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	writer, err := newExportWriter(input.Format, input.Columns, w)
+	if err != nil {
+		return fmt.Errorf("failed to start export: %w", err)
+	}
+
+	err = s.repository.Stream(ctx, input.Filter, exportBatchSize, func(users []model.User) error {
+		return writer.WriteUsers(users)
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			s.logger.Info("users export canceled")
+			return err
+		}
+		s.logger.Errorf("failed to export users: %v", err)
+		return fmt.Errorf("failed to export users: %w", err)
+	}
+
+	if err = writer.Close(); err != nil {
+		s.logger.Errorf("failed to finish export: %v", err)
+		return fmt.Errorf("failed to finish export: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/parquet-go/parquet-go"
+	"io"
+	"strings"
+	"time"
+)
+
+const parquetRowGroupSize = 64 * 1024
+
+type exportWriter interface {
+	WriteUsers(users []model.User) error
+	Close() error
+}
+
+func newExportWriter(format dto.ExportFormat, columns []string, w io.Writer) (exportWriter, error) {
+	switch format {
+	case dto.ExportFormatCSV:
+		return newCSVExportWriter(columns, w)
+	case dto.ExportFormatNDJSON:
+		return &ndjsonExportWriter{columns: columns, encoder: json.NewEncoder(w)}, nil
+	case dto.ExportFormatParquet:
+		return newParquetExportWriter(columns, w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// exportValue returns the value of an exported column, see dto.ExportColumns.
+func exportValue(user model.User, column string) interface{} {
+	switch column {
+	case "uuid":
+		return user.UUID
+	case "name":
+		return user.Name
+	case "email":
+		return user.Email
+	case "username":
+		return user.Username
+	case "status":
+		return string(user.Status)
+	case "created_at":
+		return user.CreatedAt.UTC()
+	case "updated_at":
+		return user.UpdatedAt.UTC()
+	case "last_login_at":
+		return optionalTime(user.LastLoginAt)
+	case "password_changed_at":
+		return optionalTime(user.PasswordChangedAt)
+	default:
+		return nil
+	}
+}
+
+func optionalTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+type csvExportWriter struct {
+	columns []string
+	writer  *csv.Writer
+	record  []string
+}
+
+func newCSVExportWriter(columns []string, w io.Writer) (*csvExportWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{
+		columns: columns,
+		writer:  writer,
+		record:  make([]string, len(columns)),
+	}, nil
+}
+
+func (e *csvExportWriter) WriteUsers(users []model.User) error {
+	for _, user := range users {
+		for i, column := range e.columns {
+			switch v := exportValue(user, column).(type) {
+			case time.Time:
+				e.record[i] = v.Format(time.RFC3339Nano)
+			case nil:
+				e.record[i] = ""
+			default:
+				e.record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := e.writer.Write(e.record); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvExportWriter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonExportWriter struct {
+	columns []string
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExportWriter) WriteUsers(users []model.User) error {
+	for _, user := range users {
+		row := make(map[string]interface{}, len(e.columns))
+		for _, column := range e.columns {
+			row[column] = exportValue(user, column)
+		}
+		if err := e.encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+type parquetExportWriter struct {
+	columns  []string
+	indexes  []int
+	writer   *parquet.Writer
+	buffered int
+}
+
+func newParquetExportWriter(columns []string, w io.Writer) *parquetExportWriter {
+	group := make(parquet.Group, len(columns))
+	for _, column := range columns {
+		if strings.HasSuffix(column, "_at") {
+			group[column] = parquet.Optional(parquet.Timestamp(parquet.Microsecond))
+		} else {
+			group[column] = parquet.String()
+		}
+	}
+	schema := parquet.NewSchema("user", group)
+
+	// parquet orders the columns of a group by name
+	position := make(map[string]int, len(columns))
+	for i, path := range schema.Columns() {
+		position[path[0]] = i
+	}
+	indexes := make([]int, len(columns))
+	for i, column := range columns {
+		indexes[i] = position[column]
+	}
+
+	return &parquetExportWriter{
+		columns: columns,
+		indexes: indexes,
+		writer:  parquet.NewWriter(w, schema),
+	}
+}
+
+func (e *parquetExportWriter) WriteUsers(users []model.User) error {
+	rows := make([]parquet.Row, 0, len(users))
+	for _, user := range users {
+		row := make(parquet.Row, len(e.columns))
+		for i, column := range e.columns {
+			// timestamps are optional columns, so a present value has definition level 1
+			var value parquet.Value
+			switch v := exportValue(user, column).(type) {
+			case time.Time:
+				value = parquet.Int64Value(v.UnixMicro()).Level(0, 1, e.indexes[i])
+			case string:
+				value = parquet.ByteArrayValue([]byte(v)).Level(0, 0, e.indexes[i])
+			default:
+				value = parquet.NullValue().Level(0, 0, e.indexes[i])
+			}
+			row[e.indexes[i]] = value
+		}
+		rows = append(rows, row)
+	}
+
+	if _, err := e.writer.WriteRows(rows); err != nil {
+		return err
+	}
+	e.buffered += len(rows)
+	if e.buffered >= parquetRowGroupSize {
+		e.buffered = 0
+		return e.writer.Flush()
+	}
+	return nil
+}
+
+func (e *parquetExportWriter) Close() error {
+	return e.writer.Close()
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *service) CreateGroup(ctx context.Context, dto dto.CreateGroupDTO) (string, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return "", apperror.BadRequestError(err.Error())
+	}
+	group, err := model.NewGroup(dto.Name, dto.Description, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	groupUUID, err := s.repository.CreateGroup(ctx, group)
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return "", err
+		}
+		s.logger.Errorf("failed to create group: %v", err)
+		return "", fmt.Errorf("failed to create group: %w", err)
+	}
+	return groupUUID, nil
+}
+
+func (s *service) GetGroups(ctx context.Context) ([]model.Group, error) {
+	groups, err := s.repository.FindGroups(ctx)
+	if err != nil {
+		s.logger.Errorf("failed to find groups: %v", err)
+		return nil, fmt.Errorf("failed to find groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (s *service) GetGroup(ctx context.Context, uuid string) (model.Group, error) {
+	group, err := s.repository.FindGroup(ctx, uuid)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return model.Group{}, err
+		}
+		s.logger.Errorf("failed to find group by uuid: %v", err)
+		return model.Group{}, fmt.Errorf("failed to find group by uuid: %w", err)
+	}
+	return group, nil
+}
+
+func (s *service) UpdateGroup(ctx context.Context, dto dto.UpdateGroupDTO) error {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+	group, err := s.GetGroup(ctx, dto.UUID)
+	if err != nil {
+		return err
+	}
+	if err = group.Update(dto.Name, dto.Description, time.Now().UTC()); err != nil {
+		return err
+	}
+	return s.groupError(s.repository.UpdateGroup(ctx, group), "update group")
+}
+
+func (s *service) DeleteGroup(ctx context.Context, uuid string) error {
+	return s.groupError(s.repository.DeleteGroup(ctx, uuid), "delete group")
+}
+
+func (s *service) AddGroupMember(ctx context.Context, groupUUID, userUUID string) error {
+	if _, err := s.GetGroup(ctx, groupUUID); err != nil {
+		return err
+	}
+	if _, err := s.GetByUUID(ctx, userUUID); err != nil {
+		return err
+	}
+	return s.groupError(s.repository.AddGroupMember(ctx, groupUUID, userUUID, time.Now().UTC()), "add group member")
+}
+
+func (s *service) RemoveGroupMember(ctx context.Context, groupUUID, userUUID string) error {
+	return s.groupError(s.repository.RemoveGroupMember(ctx, groupUUID, userUUID), "remove group member")
+}
+
+func (s *service) GetSubgroups(ctx context.Context, uuid string) ([]model.Group, error) {
+	if _, err := s.GetGroup(ctx, uuid); err != nil {
+		return nil, err
+	}
+	groups, err := s.repository.FindSubgroups(ctx, uuid)
+	if err != nil {
+		s.logger.Errorf("failed to find subgroups: %v", err)
+		return nil, fmt.Errorf("failed to find subgroups: %w", err)
+	}
+	return groups, nil
+}
+
+func (s *service) AddSubgroup(ctx context.Context, parentUUID, childUUID string) error {
+	if parentUUID == childUUID {
+		return apperror.BadRequestError("group cannot be its own subgroup")
+	}
+	if _, err := s.GetGroup(ctx, parentUUID); err != nil {
+		return err
+	}
+	if _, err := s.GetGroup(ctx, childUUID); err != nil {
+		return err
+	}
+	return s.groupError(s.repository.AddSubgroup(ctx, parentUUID, childUUID, time.Now().UTC()), "add subgroup")
+}
+
+func (s *service) RemoveSubgroup(ctx context.Context, parentUUID, childUUID string) error {
+	return s.groupError(s.repository.RemoveSubgroup(ctx, parentUUID, childUUID), "remove subgroup")
+}
+
+func (s *service) GetUserGroups(ctx context.Context, userUUID string) ([]model.EffectiveGroup, error) {
+	if _, err := s.GetByUUID(ctx, userUUID); err != nil {
+		return nil, err
+	}
+	groups, err := s.repository.FindUserGroups(ctx, userUUID)
+	if err != nil {
+		s.logger.Errorf("failed to find user groups: %v", err)
+		return nil, fmt.Errorf("failed to find user groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (s *service) GetGroupMembers(ctx context.Context, groupUUID string) ([]model.GroupMember, error) {
+	if _, err := s.GetGroup(ctx, groupUUID); err != nil {
+		return nil, err
+	}
+	members, err := s.repository.FindGroupMembers(ctx, groupUUID)
+	if err != nil {
+		s.logger.Errorf("failed to find group members: %v", err)
+		return nil, fmt.Errorf("failed to find group members: %w", err)
+	}
+	return members, nil
+}
+
+// groupError passes application errors of a group change through and wraps the others.
+func (s *service) groupError(err error, action string) error {
+	if err == nil {
+		return nil
+	}
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	s.logger.Errorf("failed to %s: %v", action, err)
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
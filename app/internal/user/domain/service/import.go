@@ -0,0 +1,157 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const importBatchSize = 500
+
+type importRow struct {
+	line int
+	user model.User
+}
+
+func (s *service) Import(ctx context.Context, input dto.ImportUsersDTO) (model.ImportReport, error) {
+	if err := input.Validate(); err != nil {
+		return model.ImportReport{}, apperror.BadRequestError(err.Error())
+	}
+
+	reader, err := newImportReader(input.Format, input.Source)
+	if err != nil {
+		return model.ImportReport{}, apperror.BadRequestError(err.Error())
+	}
+
+	tx, err := s.repository.BeginImport(ctx)
+	if err != nil {
+		s.logger.Errorf("failed to begin import: %v", err)
+		return model.ImportReport{}, fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil {
+			s.logger.Errorf("failed to rollback import: %v", rbErr)
+		}
+	}()
+
+	report := model.ImportReport{
+		DryRun: input.DryRun,
+		Errors: make([]model.ImportRowError, 0),
+	}
+	reject := func(line int, email, message string) {
+		report.Failed++
+		report.Errors = append(report.Errors, model.ImportRowError{Line: line, Email: email, Message: message})
+	}
+
+	now := time.Now().UTC()
+	seen := make(map[string]int)
+	batch := make([]importRow, 0, importBatchSize)
+	for {
+		row, line, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *rowError
+		if errors.As(err, &rowErr) {
+			report.Total++
+			reject(rowErr.line, "", rowErr.err.Error())
+			continue
+		}
+		if err != nil {
+			return model.ImportReport{}, apperror.BadRequestError(err.Error())
+		}
+		report.Total++
+
+		if err = row.Validate(); err != nil {
+			reject(line, row.Email, err.Error())
+			continue
+		}
+		email, err := model.NormalizeEmail(row.Email)
+		if err != nil {
+			reject(line, row.Email, err.Error())
+			continue
+		}
+		if first, ok := seen[email]; ok {
+			reject(line, row.Email, fmt.Sprintf("email duplicates line %d", first))
+			continue
+		}
+		seen[email] = line
+
+		user, err := model.NewImportedUser(row, s.initialStatus(), now)
+		if err != nil {
+			var appErr *apperror.AppError
+			if !errors.As(err, &appErr) {
+				return model.ImportReport{}, fmt.Errorf("failed to import user on line %d: %w", line, err)
+			}
+			reject(line, row.Email, appErr.Message)
+			continue
+		}
+
+		batch = append(batch, importRow{line: line, user: user})
+		if len(batch) == importBatchSize {
+			if err = s.flushImportBatch(ctx, tx, batch, &report, reject); err != nil {
+				return model.ImportReport{}, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err = s.flushImportBatch(ctx, tx, batch, &report, reject); err != nil {
+		return model.ImportReport{}, err
+	}
+
+	if input.DryRun {
+		return report, nil
+	}
+	if err = tx.Commit(ctx); err != nil {
+		s.logger.Errorf("failed to commit import: %v", err)
+		return model.ImportReport{}, fmt.Errorf("failed to commit import: %w", err)
+	}
+	s.logger.Infof("imported %d of %d users", report.Imported, report.Total)
+	return report, nil
+}
+
+func (s *service) flushImportBatch(ctx context.Context, tx ImportTx, batch []importRow, report *model.ImportReport,
+	reject func(line int, email, message string)) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	emails := make([]string, 0, len(batch))
+	for _, row := range batch {
+		emails = append(emails, row.user.Email)
+	}
+	existing, err := tx.ExistingEmails(ctx, emails)
+	if err != nil {
+		s.logger.Errorf("failed to check existing emails: %v", err)
+		return fmt.Errorf("failed to check existing emails: %w", err)
+	}
+	taken := make(map[string]struct{}, len(existing))
+	for _, email := range existing {
+		taken[email] = struct{}{}
+	}
+
+	users := make([]model.User, 0, len(batch))
+	for _, row := range batch {
+		if _, ok := taken[row.user.Email]; ok {
+			reject(row.line, row.user.Email, "user with this email already exists")
+			continue
+		}
+		users = append(users, row.user)
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	copied, err := tx.CopyUsers(ctx, users)
+	if err != nil {
+		s.logger.Errorf("failed to copy users: %v", err)
+		return fmt.Errorf("failed to copy users: %w", err)
+	}
+	report.Imported += int(copied)
+	return nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"Users/internal/user/domain/dto"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const maxNDJSONLineSize = 1 << 20
+
+// rowError is a problem with a single input row, the import carries on with the next one.
+type rowError struct {
+	line int
+	err  error
+}
+
+func (e *rowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.line, e.err)
+}
+
+type importReader interface {
+	// Next returns the next row with its line number, io.EOF at the end of input
+	// or a *rowError if only the current row is malformed.
+	Next() (dto.ImportUserDTO, int, error)
+}
+
+func newImportReader(format dto.ImportFormat, source io.Reader) (importReader, error) {
+	switch format {
+	case dto.ImportFormatCSV:
+		return newCSVImportReader(source)
+	case dto.ImportFormatNDJSON:
+		scanner := bufio.NewScanner(source)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineSize)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVImportReader(source io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(source)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("csv header is missing")
+		}
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		switch column {
+		case "name", "email", "password", "password_hash":
+		default:
+			return nil, fmt.Errorf("unknown csv column %q", column)
+		}
+		if _, ok := columns[column]; ok {
+			return nil, fmt.Errorf("duplicate csv column %q", column)
+		}
+		columns[column] = i
+	}
+	for _, column := range []string{"name", "email"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("csv column %q is required", column)
+		}
+	}
+	_, hasPassword := columns["password"]
+	_, hasHash := columns["password_hash"]
+	if !hasPassword && !hasHash {
+		return nil, fmt.Errorf("one of csv columns \"password\" and \"password_hash\" is required")
+	}
+
+	return &csvImportReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvImportReader) Next() (dto.ImportUserDTO, int, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return dto.ImportUserDTO{}, parseErr.StartLine, &rowError{line: parseErr.StartLine, err: parseErr.Err}
+		}
+		return dto.ImportUserDTO{}, 0, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	return dto.ImportUserDTO{
+		Name:         r.field(record, "name"),
+		Email:        r.field(record, "email"),
+		Password:     r.field(record, "password"),
+		PasswordHash: r.field(record, "password_hash"),
+	}, line, nil
+}
+
+func (r *csvImportReader) field(record []string, column string) string {
+	i, ok := r.columns[column]
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonImportReader) Next() (dto.ImportUserDTO, int, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var row dto.ImportUserDTO
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row); err != nil {
+			return dto.ImportUserDTO{}, r.line, &rowError{line: r.line, err: fmt.Errorf("invalid JSON: %w", err)}
+		}
+		return row, r.line, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return dto.ImportUserDTO{}, r.line, fmt.Errorf("failed to read ndjson: %w", err)
+	}
+	return dto.ImportUserDTO{}, r.line, io.EOF
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/auth"
+	"Users/pkg/mail"
+	"Users/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	invitationSubject = "You are invited to join %s"
+	invitationBody    = `You are invited to join %s as %s.
+
+Accept the invitation with the link below within %s:
+%s
+
+If you do not know the organization, ignore this message.`
+)
+
+// caller returns the user the bearer token of the request was issued to.
+func caller(ctx context.Context) (string, error) {
+	identity, ok := auth.FromContext(ctx)
+	if !ok {
+		return "", apperror.UnauthorizedError("a valid bearer token is required")
+	}
+	if !utils.IsValidUUID(identity.Subject) {
+		return "", apperror.ForbiddenError("the bearer token was not issued to a user")
+	}
+	return identity.Subject, nil
+}
+
+// CreateOrganization makes the caller the first owner of a new organization.
+func (s *service) CreateOrganization(ctx context.Context, dto dto.CreateOrganizationDTO) (string, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return "", apperror.BadRequestError(err.Error())
+	}
+	ownerUUID, err := caller(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	organization, err := model.NewOrganization(dto.Name, now)
+	if err != nil {
+		return "", err
+	}
+	if _, err = s.GetByUUID(ctx, ownerUUID); err != nil {
+		return "", err
+	}
+
+	organization, err = s.repository.CreateOrganization(ctx, organization, model.Membership{
+		UserUUID:  ownerUUID,
+		Role:      model.RoleOwner,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		s.logger.Errorf("failed to create organization: %v", err)
+		return "", fmt.Errorf("failed to create organization: %w", err)
+	}
+	return organization.UUID, nil
+}
+
+func (s *service) GetOrganization(ctx context.Context, uuid string) (model.Organization, error) {
+	organization, err := s.repository.FindOrganization(ctx, uuid)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return model.Organization{}, err
+		}
+		s.logger.Errorf("failed to find organization by uuid: %v", err)
+		return model.Organization{}, fmt.Errorf("failed to find organization by uuid: %w", err)
+	}
+	return organization, nil
+}
+
+func (s *service) GetUserOrganizations(ctx context.Context, userUUID string) ([]model.Membership, error) {
+	if _, err := s.GetByUUID(ctx, userUUID); err != nil {
+		return nil, err
+	}
+	memberships, err := s.repository.FindMemberships(ctx, userUUID)
+	if err != nil {
+		s.logger.Errorf("failed to find memberships: %v", err)
+		return nil, fmt.Errorf("failed to find memberships: %w", err)
+	}
+	return memberships, nil
+}
+
+func (s *service) GetMembers(ctx context.Context, organizationUUID string) ([]model.Member, error) {
+	if _, err := s.GetOrganization(ctx, organizationUUID); err != nil {
+		return nil, err
+	}
+	members, err := s.repository.FindMembers(ctx, organizationUUID)
+	if err != nil {
+		s.logger.Errorf("failed to find members: %v", err)
+		return nil, fmt.Errorf("failed to find members: %w", err)
+	}
+	return members, nil
+}
+
+// ChangeMemberRole changes the role of a member on behalf of an owner or an admin of the
+// organization, only owners can change the owners.
+func (s *service) ChangeMemberRole(ctx context.Context, dto dto.ChangeMemberRoleDTO) error {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+	role, err := model.ParseRole(dto.Role)
+	if err != nil {
+		return err
+	}
+	actorUUID, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.UpdateMemberRole(ctx, dto.OrganizationUUID, actorUUID, dto.UserUUID, role, time.Now().UTC(),
+		func(actor, member model.Role) error {
+			return model.AuthorizeMemberChange(actor, member, role)
+		})
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
+		s.logger.Errorf("failed to change member role: %v", err)
+		return fmt.Errorf("failed to change member role: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember removes a member on behalf of an owner or an admin of the organization,
+// only owners can remove the owners. Members can always leave.
+func (s *service) RemoveMember(ctx context.Context, organizationUUID, userUUID string) error {
+	actorUUID, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.DeleteMember(ctx, organizationUUID, actorUUID, userUUID,
+		func(actor, member model.Role) error {
+			if actorUUID == userUUID {
+				return nil
+			}
+			return model.AuthorizeMemberChange(actor, member, "")
+		})
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
+		s.logger.Errorf("failed to remove member: %v", err)
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	return nil
+}
+
+// InviteMember mails an invitation token to the address on behalf of the caller. Only
+// owners and admins can invite, and only owners can invite another owner.
+func (s *service) InviteMember(ctx context.Context, dto dto.InviteMemberDTO) (model.Invitation, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return model.Invitation{}, apperror.BadRequestError(err.Error())
+	}
+	role, err := model.ParseRole(dto.Role)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+	email, err := model.NormalizeEmail(dto.Email)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	inviterUUID, err := caller(ctx)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	organization, err := s.GetOrganization(ctx, dto.OrganizationUUID)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+	if err = s.authorizeMemberChange(ctx, organization.UUID, inviterUUID, role); err != nil {
+		return model.Invitation{}, err
+	}
+	if err = s.checkNotMember(ctx, organization.UUID, email); err != nil {
+		return model.Invitation{}, err
+	}
+
+	invitation, err := model.NewInvitation(organization.UUID, email, role, inviterUUID, time.Now().UTC(),
+		s.opts.InvitationTTL)
+	if err != nil {
+		return model.Invitation{}, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	token := invitation.Token
+	invitation, err = s.repository.CreateInvitation(ctx, invitation)
+	if err != nil {
+		s.logger.Errorf("failed to create invitation: %v", err)
+		return model.Invitation{}, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf(invitationSubject, organization.Name),
+		Body: fmt.Sprintf(invitationBody, organization.Name, invitation.Role, s.opts.InvitationTTL,
+			s.invitationLink(ctx, token)),
+	})
+	if err != nil {
+		s.logger.Errorf("failed to send invitation: %v", err)
+		return model.Invitation{}, fmt.Errorf("failed to send invitation: %w", err)
+	}
+	return invitation, nil
+}
+
+// authorizeMemberChange checks that the actor can let a new member join with the role,
+// an empty role being none.
+func (s *service) authorizeMemberChange(ctx context.Context, organizationUUID, actorUUID string, role model.Role) error {
+	var actor model.Role
+	membership, err := s.repository.FindMembership(ctx, organizationUUID, actorUUID)
+	if err == nil {
+		actor = membership.Role
+	} else if !errors.Is(err, apperror.ErrNotFound) {
+		s.logger.Errorf("failed to find membership: %v", err)
+		return fmt.Errorf("failed to find membership: %w", err)
+	}
+	return model.AuthorizeMemberChange(actor, "", role)
+}
+
+func (s *service) checkNotMember(ctx context.Context, organizationUUID, email string) error {
+	user, err := s.repository.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return nil
+		}
+		s.logger.Errorf("failed to find user by email: %v", err)
+		return fmt.Errorf("failed to find user by email: %w", err)
+	}
+	_, err = s.repository.FindMembership(ctx, organizationUUID, user.UUID)
+	if err == nil {
+		return apperror.ConflictError("User is already a member of the organization")
+	}
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil
+	}
+	s.logger.Errorf("failed to find membership: %v", err)
+	return fmt.Errorf("failed to find membership: %w", err)
+}
+
+func (s *service) invitationLink(ctx context.Context, token string) string {
+	return fmt.Sprintf("%s/api/invitations/accept?token=%s%s", s.opts.PublicURL, url.QueryEscape(token),
+		tenantQuery(ctx))
+}
+
+func (s *service) GetInvitations(ctx context.Context, organizationUUID string) ([]model.Invitation, error) {
+	if _, err := s.GetOrganization(ctx, organizationUUID); err != nil {
+		return nil, err
+	}
+	invitations, err := s.repository.FindInvitations(ctx, organizationUUID, time.Now().UTC())
+	if err != nil {
+		s.logger.Errorf("failed to find invitations: %v", err)
+		return nil, fmt.Errorf("failed to find invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation revokes a pending invitation on behalf of an owner or an admin of the organization.
+func (s *service) RevokeInvitation(ctx context.Context, organizationUUID, id string) error {
+	actorUUID, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+	if err = s.authorizeMemberChange(ctx, organizationUUID, actorUUID, ""); err != nil {
+		return err
+	}
+
+	err = s.repository.RevokeInvitation(ctx, organizationUUID, id, time.Now().UTC())
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return err
+		}
+		s.logger.Errorf("failed to revoke invitation: %v", err)
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	return nil
+}
+
+// AcceptInvitation makes the caller a member of the inviting organization. The caller
+// must own the invited email address.
+func (s *service) AcceptInvitation(ctx context.Context, dto dto.AcceptInvitationDTO) (model.Membership, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return model.Membership{}, apperror.BadRequestError(err.Error())
+	}
+	userUUID, err := caller(ctx)
+	if err != nil {
+		return model.Membership{}, err
+	}
+	user, err := s.GetByUUID(ctx, userUUID)
+	if err != nil {
+		return model.Membership{}, err
+	}
+
+	now := time.Now().UTC()
+	membership, err := s.repository.AcceptInvitation(ctx, model.HashToken(dto.Token), now,
+		func(invitation model.Invitation) (model.Membership, error) {
+			return invitation.Accept(user, now)
+		})
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return model.Membership{}, err
+		}
+		s.logger.Errorf("failed to accept invitation: %v", err)
+		return model.Membership{}, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	return membership, nil
+}
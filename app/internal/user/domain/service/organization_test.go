@@ -0,0 +1,163 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/auth"
+	"Users/pkg/mail"
+	"context"
+	"testing"
+	"time"
+)
+
+const (
+	testOrganization = "0b6f2a1e-3f7d-4a59-9d3c-7a1e5c2b8f40"
+	testOwner        = "4c3c8d32-5b7e-4be6-bde1-231f0eeda630"
+	testAdmin        = "9a2d4e61-7c1b-4f3a-8e5d-2b6c9f0a1d37"
+	testMember       = "3a4541ce-d1bc-4352-ad56-437ca9873713"
+)
+
+// membershipRepository holds the roles of the members of one organization.
+type membershipRepository struct {
+	Repository
+	roles       map[string]model.Role
+	invitations []model.Invitation
+}
+
+func (r *membershipRepository) FindOrganization(ctx context.Context, uuid string) (model.Organization, error) {
+	return model.Organization{UUID: uuid, Name: "Household"}, nil
+}
+
+func (r *membershipRepository) FindMembership(ctx context.Context, organizationUUID,
+	userUUID string) (model.Membership, error) {
+	role, ok := r.roles[userUUID]
+	if !ok {
+		return model.Membership{}, apperror.ErrNotFound
+	}
+	return model.Membership{OrganizationUUID: organizationUUID, UserUUID: userUUID, Role: role}, nil
+}
+
+func (r *membershipRepository) FindByEmail(ctx context.Context, email string) (model.User, error) {
+	return model.User{}, apperror.ErrNotFound
+}
+
+func (r *membershipRepository) CreateInvitation(ctx context.Context,
+	invitation model.Invitation) (model.Invitation, error) {
+	r.invitations = append(r.invitations, invitation)
+	return invitation, nil
+}
+
+func (r *membershipRepository) UpdateMemberRole(ctx context.Context, organizationUUID, actorUUID, userUUID string,
+	role model.Role, now time.Time, authorize func(actor, member model.Role) error) error {
+	if err := authorize(r.roles[actorUUID], r.roles[userUUID]); err != nil {
+		return err
+	}
+	r.roles[userUUID] = role
+	return nil
+}
+
+func (r *membershipRepository) DeleteMember(ctx context.Context, organizationUUID, actorUUID, userUUID string,
+	authorize func(actor, member model.Role) error) error {
+	if err := authorize(r.roles[actorUUID], r.roles[userUUID]); err != nil {
+		return err
+	}
+	delete(r.roles, userUUID)
+	return nil
+}
+
+type nopMailer struct{}
+
+func (nopMailer) Send(ctx context.Context, msg mail.Message) error {
+	return nil
+}
+
+func newMembershipTest() (*membershipRepository, *service) {
+	repository := &membershipRepository{roles: map[string]model.Role{
+		testOwner:  model.RoleOwner,
+		testAdmin:  model.RoleAdmin,
+		testMember: model.RoleMember,
+	}}
+	s := NewService(repository, nil, nopMailer{}, nil, nil, Options{}, newTestLogger())
+	return repository, s.(*service)
+}
+
+func as(userUUID string) context.Context {
+	return auth.WithIdentity(context.Background(), auth.Identity{Subject: userUUID})
+}
+
+func TestInviteMemberOnBehalfOfTheCaller(t *testing.T) {
+	repository, s := newMembershipTest()
+	invite := func(ctx context.Context, role model.Role) error {
+		_, err := s.InviteMember(ctx, dto.InviteMemberDTO{OrganizationUUID: testOrganization,
+			Email: "partner@example.com", Role: string(role)})
+		return err
+	}
+
+	if err := invite(as(testAdmin), model.RoleMember); err != nil {
+		t.Fatalf("admin inviting a member: %v", err)
+	}
+	if len(repository.invitations) != 1 || repository.invitations[0].InvitedBy != testAdmin {
+		t.Fatalf("stored invitations %+v, want one invited by the caller", repository.invitations)
+	}
+
+	forbidden := map[string]struct {
+		ctx  context.Context
+		role model.Role
+	}{
+		"admin inviting an owner": {as(testAdmin), model.RoleOwner},
+		"member inviting":         {as(testMember), model.RoleViewer},
+		"non member inviting":     {as("5d0c8a47-1e2b-4c6f-9a3d-8b7e6f5a4c21"), model.RoleViewer},
+	}
+	for name, test := range forbidden {
+		if err := invite(test.ctx, test.role); !apperror.IsForbidden(err) {
+			t.Errorf("%s: got error %v, want forbidden", name, err)
+		}
+	}
+	if err := invite(context.Background(), model.RoleViewer); !apperror.IsUnauthorized(err) {
+		t.Errorf("invitation without a caller: got error %v, want unauthorized", err)
+	}
+	if err := invite(as("local-admin"), model.RoleViewer); !apperror.IsForbidden(err) {
+		t.Errorf("invitation by a token of no user: got error %v, want forbidden", err)
+	}
+}
+
+func TestChangeMembersByRole(t *testing.T) {
+	repository, s := newMembershipTest()
+	change := func(caller, userUUID string, role model.Role) error {
+		return s.ChangeMemberRole(as(caller), dto.ChangeMemberRoleDTO{OrganizationUUID: testOrganization,
+			UserUUID: userUUID, Role: string(role)})
+	}
+
+	if err := change(testMember, testMember, model.RoleAdmin); !apperror.IsForbidden(err) {
+		t.Errorf("member promoting itself: got error %v, want forbidden", err)
+	}
+	if err := change(testAdmin, testMember, model.RoleOwner); !apperror.IsForbidden(err) {
+		t.Errorf("admin making an owner: got error %v, want forbidden", err)
+	}
+	if err := change(testAdmin, testOwner, model.RoleMember); !apperror.IsForbidden(err) {
+		t.Errorf("admin demoting an owner: got error %v, want forbidden", err)
+	}
+	if err := change(testAdmin, testMember, model.RoleViewer); err != nil {
+		t.Errorf("admin changing a member: %v", err)
+	}
+	if err := change(testOwner, testAdmin, model.RoleOwner); err != nil {
+		t.Errorf("owner making an owner: %v", err)
+	}
+	if repository.roles[testMember] != model.RoleViewer || repository.roles[testAdmin] != model.RoleOwner {
+		t.Errorf("got roles %v", repository.roles)
+	}
+
+	if err := s.RemoveMember(as(testMember), testOrganization, testOwner); !apperror.IsForbidden(err) {
+		t.Errorf("member removing an owner: got error %v, want forbidden", err)
+	}
+	if err := s.RemoveMember(as(testMember), testOrganization, testMember); err != nil {
+		t.Errorf("member leaving: %v", err)
+	}
+	if _, ok := repository.roles[testMember]; ok {
+		t.Error("member is still in the organization after leaving")
+	}
+	if err := s.RemoveMember(context.Background(), testOrganization, testOwner); !apperror.IsUnauthorized(err) {
+		t.Errorf("removal without a caller: got error %v, want unauthorized", err)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/events"
+	"Users/pkg/tenant"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	watchBatchSize = 100
+	// watchHeartbeat is how long a watch stays silent before fn is called without events,
+	// so that callers can keep idle connections open
+	watchHeartbeat = 15 * time.Second
+)
+
+// RelayEvents publishes the oldest unpublished events of the tenant one by one. The first
+// event that fails to publish and those after it stay in the outbox for the next call,
+// so events are delivered at least once and in the order they were committed. Replicas
+// relaying the same tenant take turns: the calls of all but one publish nothing.
+func (s *service) RelayEvents(ctx context.Context, limit int, publisher events.Publisher) (int, error) {
+	published, err := s.repository.RelayEvents(ctx, limit, time.Now().UTC(),
+		func(batch []events.Event) (int, error) {
+			for i, event := range batch {
+				if err := publisher.Publish(ctx, event); err != nil {
+					return i, fmt.Errorf("failed to publish event %d: %w", event.ID, err)
+				}
+			}
+			return len(batch), nil
+		})
+	if err != nil {
+		s.logger.Errorf("failed to relay events: %v", err)
+		return published, fmt.Errorf("failed to relay events: %w", err)
+	}
+	return published, nil
+}
+
+func (s *service) PurgePublishedEvents(ctx context.Context) (int64, error) {
+	purged, err := s.repository.DeletePublishedEvents(ctx, time.Now().Add(-s.opts.EventRetention))
+	if err != nil {
+		s.logger.Errorf("failed to purge published events: %v", err)
+		return 0, fmt.Errorf("failed to purge published events: %w", err)
+	}
+	return purged, nil
+}
+
+// WatchEvents calls fn with the matching events of the tenant in commit order as they are
+// committed, until the context is done. fn is called without events once the watch is established and
+// when it has been idle for a while. Events are read from the outbox, a watch resumes
+// after any event still kept there, see Options.EventRetention.
+func (s *service) WatchEvents(ctx context.Context, dto dto.WatchEventsDTO, fn func(batch []events.Event) error) error {
+	if err := dto.Validate(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+	for _, eventType := range dto.Types {
+		if _, ok := model.EventTypes[eventType]; !ok {
+			return apperror.BadRequestError(fmt.Sprintf("unknown event type %q", eventType))
+		}
+	}
+
+	// subscribed before the outbox is read, so that no event written meanwhile is missed
+	tenantID, _ := tenant.FromContext(ctx)
+	wake, unsubscribe := s.notifier.Subscribe(tenantID)
+	defer unsubscribe()
+
+	after := dto.AfterPosition
+	if after == 0 {
+		var err error
+		if after, err = s.repository.FindLastEventPosition(ctx); err != nil {
+			s.logger.Errorf("failed to find last event: %v", err)
+			return fmt.Errorf("failed to find last event: %w", err)
+		}
+	}
+
+	if err := fn(nil); err != nil {
+		return err
+	}
+	heartbeat := time.NewTicker(watchHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		batch, err := s.repository.FindEventsAfter(ctx, after, watchBatchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			s.logger.Errorf("failed to find events: %v", err)
+			return fmt.Errorf("failed to find events: %w", err)
+		}
+		full := len(batch) == watchBatchSize
+		if len(batch) > 0 {
+			// the events filtered out are skipped as well
+			after = batch[len(batch)-1].Position
+		}
+		matching := slices.DeleteFunc(batch, func(event events.Event) bool {
+			return !watchMatches(dto, event)
+		})
+		if len(matching) > 0 {
+			if err = fn(matching); err != nil {
+				return err
+			}
+			heartbeat.Reset(watchHeartbeat)
+		}
+		if full {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		case <-heartbeat.C:
+			if err = fn(nil); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func watchMatches(dto dto.WatchEventsDTO, event events.Event) bool {
+	if dto.UserUUID != "" && !strings.EqualFold(dto.UserUUID, event.UserUUID) {
+		return false
+	}
+	return len(dto.Types) == 0 || slices.Contains(dto.Types, event.Type)
+}
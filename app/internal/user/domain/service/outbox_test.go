@@ -0,0 +1,102 @@
+package service
+
+import (
+	"Users/internal/user/controller"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/events"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventsRepository holds committed outbox events in commit order.
+type eventsRepository struct {
+	Repository
+	mu        sync.Mutex
+	committed []events.Event
+}
+
+func (r *eventsRepository) commit(event events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.Position = int64(len(r.committed) + 1)
+	r.committed = append(r.committed, event)
+}
+
+func (r *eventsRepository) FindLastEventPosition(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.committed)), nil
+}
+
+func (r *eventsRepository) FindEventsAfter(ctx context.Context, afterPosition int64,
+	limit int) ([]events.Event, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	batch := make([]events.Event, 0)
+	for _, event := range r.committed {
+		if event.Position > afterPosition && len(batch) < limit {
+			batch = append(batch, event)
+		}
+	}
+	return batch, nil
+}
+
+type wakeNotifier struct {
+	wake chan struct{}
+}
+
+func (n *wakeNotifier) Subscribe(tenantID string) (<-chan struct{}, func()) {
+	return n.wake, func() {}
+}
+
+// watch runs a watch until it has received want events and returns their ids.
+func watch(t *testing.T, s controller.Service, filter dto.WatchEventsDTO, want int, started func()) []int64 {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ids := make([]int64, 0)
+	err := s.WatchEvents(ctx, filter, func(batch []events.Event) error {
+		if batch == nil && started != nil {
+			started()
+			started = nil
+		}
+		for _, event := range batch {
+			ids = append(ids, event.ID)
+		}
+		if len(ids) >= want {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	return ids
+}
+
+func TestWatchEventsFollowsCommitOrder(t *testing.T) {
+	repository := &eventsRepository{}
+	notifier := &wakeNotifier{wake: make(chan struct{}, 1)}
+	s := NewService(repository, nil, nil, nil, notifier, Options{}, newTestLogger())
+
+	// event 2 was written after event 1 but committed before it
+	for _, id := range []int64{2, 1, 3} {
+		repository.commit(events.Event{ID: id, Type: model.EventUserUpdated})
+	}
+
+	ids := watch(t, s, dto.WatchEventsDTO{AfterPosition: 1}, 2, nil)
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("resumed after the first committed event with events %v, want [1 3]", ids)
+	}
+
+	ids = watch(t, s, dto.WatchEventsDTO{}, 1, func() {
+		repository.commit(events.Event{ID: 4, Type: model.EventUserCreated})
+		notifier.wake <- struct{}{}
+	})
+	if len(ids) != 1 || ids[0] != 4 {
+		t.Errorf("new watch got events %v, want only the one committed after it started [4]", ids)
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/sms"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var phoneCodeMessages = map[string]string{
+	model.PhoneCodeVerify: "%s is your verification code. It expires in %s.",
+	model.PhoneCodeLogin:  "%s is your login code. It expires in %s. Do not share it with anyone.",
+}
+
+func (s *service) SetPhone(ctx context.Context, dto dto.SetPhoneDTO) error {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	phone := ""
+	if dto.Phone != "" {
+		var err error
+		if phone, err = model.NormalizePhone(dto.Phone); err != nil {
+			return err
+		}
+	}
+
+	user, err := s.findForUpdate(ctx, dto.UUID, dto.Password, nil)
+	if err != nil {
+		return err
+	}
+	if phone == user.Phone {
+		return nil
+	}
+	if phone != "" {
+		if err = s.checkPhoneAvailable(ctx, phone); err != nil {
+			return err
+		}
+	}
+
+	// a new phone has to be verified again and cannot be a second factor until then
+	user.Phone = phone
+	user.PhoneVerifiedAt = nil
+	user.PhoneSecondFactor = false
+	user.UpdatedAt = time.Now().UTC()
+	if err = s.updatePhone(ctx, user); err != nil {
+		return err
+	}
+
+	if phone == "" {
+		return nil
+	}
+	return s.sendPhoneCode(ctx, user, model.PhoneCodeVerify)
+}
+
+func (s *service) SendPhoneCode(ctx context.Context, uuid string) error {
+	user, err := s.repository.FindByUUID(ctx, uuid)
+	if err != nil {
+		return err
+	}
+	if user.Phone == "" {
+		return apperror.BadRequestError("user has no phone")
+	}
+	if user.PhoneVerified() {
+		return apperror.BadRequestError("phone is already verified")
+	}
+	return s.sendPhoneCode(ctx, user, model.PhoneCodeVerify)
+}
+
+func (s *service) VerifyPhone(ctx context.Context, dto dto.VerifyPhoneDTO) error {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	user, err := s.repository.FindByUUID(ctx, dto.UUID)
+	if err != nil {
+		return err
+	}
+	if user.Phone == "" {
+		return apperror.BadRequestError("user has no phone")
+	}
+	if user.PhoneVerified() {
+		return apperror.BadRequestError("phone is already verified")
+	}
+
+	if err = s.verifyPhoneCode(ctx, user, model.PhoneCodeVerify, dto.Code); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	user.PhoneVerifiedAt = &now
+	user.UpdatedAt = now
+	return s.updatePhone(ctx, user)
+}
+
+func (s *service) SetPhoneSecondFactor(ctx context.Context, dto dto.PhoneSecondFactorDTO) error {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	user, err := s.findForUpdate(ctx, dto.UUID, dto.Password, nil)
+	if err != nil {
+		return err
+	}
+	if dto.Enabled && !user.PhoneVerified() {
+		return apperror.BadRequestError("phone must be verified to be used as second factor")
+	}
+	if user.PhoneSecondFactor == dto.Enabled {
+		return nil
+	}
+
+	user.PhoneSecondFactor = dto.Enabled
+	user.UpdatedAt = time.Now().UTC()
+	return s.updatePhone(ctx, user)
+}
+
+// checkSecondFactor sends a login code if none is given and verifies it otherwise.
+func (s *service) checkSecondFactor(ctx context.Context, user model.User, code string) error {
+	if code != "" {
+		return s.verifyPhoneCode(ctx, user, model.PhoneCodeLogin, code)
+	}
+	if err := s.sendPhoneCode(ctx, user, model.PhoneCodeLogin); err != nil {
+		return err
+	}
+	return apperror.UnauthorizedError("second factor required, a code has been sent to the verified phone")
+}
+
+func (s *service) checkPhoneAvailable(ctx context.Context, phone string) error {
+	_, err := s.repository.FindByPhone(ctx, phone)
+	if err == nil {
+		return apperror.BadRequestError("User with this phone already exists")
+	}
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil
+	}
+	s.logger.Errorf("failed to find user by phone: %v", err)
+	return fmt.Errorf("failed to find user by phone: %w", err)
+}
+
+func (s *service) updatePhone(ctx context.Context, user model.User) error {
+	err := s.repository.UpdatePhone(ctx, user)
+	if err != nil {
+		s.logger.Errorf("failed to update phone: %v", err)
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
+		return fmt.Errorf("failed to update phone: %w", err)
+	}
+	return nil
+}
+
+// sendPhoneCode sends a one-time code to the user's phone. Sends are limited per user
+// and per number, so that neither an account nor a number can be flooded.
+func (s *service) sendPhoneCode(ctx context.Context, user model.User, purpose string) error {
+	now := time.Now().UTC()
+	sent, lastSentAt, err := s.repository.PhoneCodeStats(ctx, user.UUID, user.Phone, now.Add(-time.Hour))
+	if err != nil {
+		s.logger.Errorf("failed to count sent phone codes: %v", err)
+		return fmt.Errorf("failed to count sent phone codes: %w", err)
+	}
+	if lastSentAt != nil && now.Sub(*lastSentAt) < s.opts.PhoneResendInterval {
+		wait := s.opts.PhoneResendInterval - now.Sub(*lastSentAt)
+		return apperror.TooManyRequestsError(fmt.Sprintf("a code has been sent recently, retry in %s",
+			wait.Round(time.Second)))
+	}
+	if sent >= s.opts.PhoneMaxSendsPerHour {
+		return apperror.TooManyRequestsError("too many codes have been sent within an hour, retry later")
+	}
+
+	code, plainCode, err := model.NewPhoneCode(user, purpose, now, s.opts.PhoneCodeTTL)
+	if err != nil {
+		return fmt.Errorf("failed to generate phone code: %w", err)
+	}
+	if err = s.repository.CreatePhoneCode(ctx, code); err != nil {
+		s.logger.Errorf("failed to create phone code: %v", err)
+		return fmt.Errorf("failed to create phone code: %w", err)
+	}
+
+	err = s.smsSender.Send(ctx, sms.Message{
+		To:   user.Phone,
+		Body: fmt.Sprintf(phoneCodeMessages[purpose], plainCode, s.opts.PhoneCodeTTL),
+	})
+	if err != nil {
+		s.logger.Errorf("failed to send phone code: %v", err)
+		return fmt.Errorf("failed to send phone code: %w", err)
+	}
+	return nil
+}
+
+func (s *service) verifyPhoneCode(ctx context.Context, user model.User, purpose, code string) error {
+	err := s.repository.VerifyPhoneCode(ctx, user.UUID, purpose, func(phoneCode model.PhoneCode) error {
+		return phoneCode.Check(code, user.Phone, time.Now().UTC(), s.opts.PhoneCodeMaxAttempts)
+	})
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return apperror.BadRequestError("no code has been sent, request a new one")
+		}
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
+		s.logger.Errorf("failed to verify phone code: %v", err)
+		return fmt.Errorf("failed to verify phone code: %w", err)
+	}
+	return nil
+}
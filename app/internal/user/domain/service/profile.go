@@ -0,0 +1,85 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *service) GetProfile(ctx context.Context, uuid string) (model.Profile, error) {
+	profile, err := s.repository.FindProfile(ctx, uuid)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return model.Profile{}, err
+		}
+		s.logger.Errorf("failed to get profile: %v", err)
+		return model.Profile{}, fmt.Errorf("failed to get profile: %w", err)
+	}
+	return profile, nil
+}
+
+// PatchProfile applies a merge patch to the profile. Attributes are checked against
+// the profile attributes schema when one is defined.
+func (s *service) PatchProfile(ctx context.Context, dto dto.PatchProfileDTO) (model.Profile, error) {
+	schema, err := s.repository.FindProfileSchema(ctx)
+	hasSchema := err == nil
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		s.logger.Errorf("failed to get profile attributes schema: %v", err)
+		return model.Profile{}, fmt.Errorf("failed to get profile attributes schema: %w", err)
+	}
+
+	profile, err := s.repository.UpdateProfile(ctx, dto.UUID, model.AuditProfileUpdated, func(existing model.Profile) (model.Profile, error) {
+		patched, err := model.NewPatchedProfile(existing, dto.Patch)
+		if err != nil {
+			return model.Profile{}, err
+		}
+		if hasSchema {
+			if err = schema.ValidateAttributes(patched.Attributes); err != nil {
+				return model.Profile{}, err
+			}
+		}
+		return patched, nil
+	})
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return model.Profile{}, err
+		}
+		s.logger.Errorf("failed to patch profile: %v", err)
+		return model.Profile{}, fmt.Errorf("failed to patch profile: %w", err)
+	}
+	return profile, nil
+}
+
+func (s *service) GetProfileSchema(ctx context.Context) (model.ProfileSchema, error) {
+	schema, err := s.repository.FindProfileSchema(ctx)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return model.ProfileSchema{}, err
+		}
+		s.logger.Errorf("failed to get profile attributes schema: %v", err)
+		return model.ProfileSchema{}, fmt.Errorf("failed to get profile attributes schema: %w", err)
+	}
+	return schema, nil
+}
+
+// UpdateProfileSchema replaces the profile attributes schema. Existing profiles
+// are not revalidated, the schema applies to their next change.
+func (s *service) UpdateProfileSchema(ctx context.Context, raw json.RawMessage) error {
+	schema := model.ProfileSchema{Schema: raw, UpdatedAt: time.Now().UTC()}
+	if _, err := schema.Compile(); err != nil {
+		return apperror.BadRequestError(fmt.Sprintf("invalid JSON Schema: %v", err))
+	}
+
+	err := s.repository.SaveProfileSchema(ctx, schema)
+	if err != nil {
+		s.logger.Errorf("failed to save profile attributes schema: %v", err)
+		return fmt.Errorf("failed to save profile attributes schema: %w", err)
+	}
+	return nil
+}
@@ -2,47 +2,264 @@ package service
 
 import (
 	"Users/internal/apperror"
+	"Users/internal/config"
 	"Users/internal/user/controller"
 	"Users/internal/user/domain/dto"
 	"Users/internal/user/domain/model"
+	"Users/pkg/authz"
+	"Users/pkg/blob"
+	"Users/pkg/events"
 	"Users/pkg/logging"
+	"Users/pkg/mail"
+	"Users/pkg/sms"
+	"Users/pkg/utils"
 	"context"
 	"errors"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"time"
 )
 
 type Repository interface {
 	Create(ctx context.Context, user model.User) (string, error)
-	FindAll(ctx context.Context) ([]model.User, error)
+	FindAll(ctx context.Context, filter dto.UserFilter) ([]model.User, error)
+	Stream(ctx context.Context, filter dto.UserFilter, batchSize int, fn func(users []model.User) error) error
 	FindByUUID(ctx context.Context, uuid string) (model.User, error)
+	FindByUUIDs(ctx context.Context, uuids []string) ([]model.User, error)
 	FindByEmail(ctx context.Context, email string) (model.User, error)
-	Update(ctx context.Context, user model.User) error
-	Delete(ctx context.Context, uuid string) error
+	FindByUsername(ctx context.Context, username string) (model.User, error)
+	// FindByPhone finds the user having the phone verified.
+	FindByPhone(ctx context.Context, phone string) (model.User, error)
+	// IsUsernameReleased reports whether another user than exceptUUID gave up the username after releasedAfter.
+	IsUsernameReleased(ctx context.Context, username, exceptUUID string, releasedAfter time.Time) (bool, error)
+	// Update writes the fields of user and stores emailChange, if any, in one transaction.
+	// An update changing nothing but the email leaves the user as it is.
+	Update(ctx context.Context, user model.User, emailChange *model.EmailChange) error
+	UpdatePhone(ctx context.Context, user model.User) error
+	UpdateLastLogin(ctx context.Context, uuid string, at time.Time) error
+	UpdateStatus(ctx context.Context, uuid string, from, to model.Status, reason string) error
+	Delete(ctx context.Context, uuid string, version int64) error
+	Restore(ctx context.Context, uuid string, deletedAfter time.Time) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	BeginImport(ctx context.Context) (ImportTx, error)
+	FindProfile(ctx context.Context, uuid string) (model.Profile, error)
+	// UpdateProfile locks the profile while fn computes its new state, the change is audited
+	// as the action.
+	UpdateProfile(ctx context.Context, uuid, action string,
+		fn func(profile model.Profile) (model.Profile, error)) (model.Profile, error)
+	FindProfileSchema(ctx context.Context) (model.ProfileSchema, error)
+	SaveProfileSchema(ctx context.Context, schema model.ProfileSchema) error
+	// ConfirmEmailChange switches the user to the new email of the pending change.
+	ConfirmEmailChange(ctx context.Context, confirmTokenHash []byte, now time.Time) (model.EmailChange, error)
+	// RevertEmailChange cancels a pending change or switches the user back to the old email.
+	RevertEmailChange(ctx context.Context, revertTokenHash []byte, createdAfter, now time.Time) (model.EmailChange, error)
+	CreatePhoneCode(ctx context.Context, code model.PhoneCode) error
+	// PhoneCodeStats counts the codes sent to the user or the phone since the given time
+	// and tells when the last of them was sent.
+	PhoneCodeStats(ctx context.Context, userUUID, phone string, since time.Time) (int, *time.Time, error)
+	// VerifyPhoneCode locks the latest unused code of the user while check runs,
+	// the code is consumed if the check passes and its attempts are counted otherwise.
+	VerifyPhoneCode(ctx context.Context, userUUID, purpose string, check func(code model.PhoneCode) error) error
+	// CreateOrganization stores the organization together with the membership of its owner.
+	CreateOrganization(ctx context.Context, organization model.Organization, owner model.Membership) (model.Organization, error)
+	FindOrganization(ctx context.Context, uuid string) (model.Organization, error)
+	FindMembership(ctx context.Context, organizationUUID, userUUID string) (model.Membership, error)
+	FindMemberships(ctx context.Context, userUUID string) ([]model.Membership, error)
+	FindMembers(ctx context.Context, organizationUUID string) ([]model.Member, error)
+	// UpdateMemberRole and DeleteMember refuse to leave the organization without an owner.
+	// They lock the organization while authorize checks the role of the acting member,
+	// empty if it is none, and of the changed one.
+	UpdateMemberRole(ctx context.Context, organizationUUID, actorUUID, userUUID string, role model.Role,
+		now time.Time, authorize func(actor, member model.Role) error) error
+	DeleteMember(ctx context.Context, organizationUUID, actorUUID, userUUID string,
+		authorize func(actor, member model.Role) error) error
+	// CreateInvitation revokes the pending invitations of the email and stores the new one.
+	CreateInvitation(ctx context.Context, invitation model.Invitation) (model.Invitation, error)
+	FindInvitations(ctx context.Context, organizationUUID string, now time.Time) ([]model.Invitation, error)
+	RevokeInvitation(ctx context.Context, organizationUUID, id string, now time.Time) error
+	// AcceptInvitation locks the pending invitation while accept turns it into a membership.
+	AcceptInvitation(ctx context.Context, tokenHash []byte, now time.Time,
+		accept func(invitation model.Invitation) (model.Membership, error)) (model.Membership, error)
+	FindTenants(ctx context.Context) ([]string, error)
+	CreateGroup(ctx context.Context, group model.Group) (string, error)
+	FindGroups(ctx context.Context) ([]model.Group, error)
+	FindGroup(ctx context.Context, uuid string) (model.Group, error)
+	FindSubgroups(ctx context.Context, uuid string) ([]model.Group, error)
+	UpdateGroup(ctx context.Context, group model.Group) error
+	DeleteGroup(ctx context.Context, uuid string) error
+	AddGroupMember(ctx context.Context, groupUUID, userUUID string, now time.Time) error
+	RemoveGroupMember(ctx context.Context, groupUUID, userUUID string) error
+	// AddSubgroup rejects a nesting that would make a group its own descendant.
+	AddSubgroup(ctx context.Context, parentUUID, childUUID string, now time.Time) error
+	RemoveSubgroup(ctx context.Context, parentUUID, childUUID string) error
+	// FindUserGroups resolves the direct groups of the user and every group they are nested in.
+	FindUserGroups(ctx context.Context, userUUID string) ([]model.EffectiveGroup, error)
+	// FindGroupMembers resolves the members of the group and of all of its subgroups.
+	FindGroupMembers(ctx context.Context, groupUUID string) ([]model.GroupMember, error)
+	// FindTuples finds the relation tuples of the object, of all its relations if relation is empty.
+	FindTuples(ctx context.Context, object authz.Object, relation string) ([]authz.Tuple, error)
+	// FindObjectIDs lists the objects of the namespace that appear in a tuple.
+	FindObjectIDs(ctx context.Context, namespace string) ([]string, error)
+	WriteTuples(ctx context.Context, tuples []authz.Tuple, now time.Time) error
+	DeleteTuples(ctx context.Context, tuples []authz.Tuple) error
+	FindAuthzSchema(ctx context.Context) (string, error)
+	SaveAuthzSchema(ctx context.Context, source string, now time.Time) error
+	// RelayEvents passes the oldest unpublished outbox events of the tenant to publish in
+	// commit order, the events publish reports as sent are marked published. It returns no
+	// events while another relay is publishing those of the tenant.
+	RelayEvents(ctx context.Context, limit int, now time.Time,
+		publish func(batch []events.Event) (int, error)) (int, error)
+	DeletePublishedEvents(ctx context.Context, publishedBefore time.Time) (int64, error)
+	// FindLastEventPosition returns the position of the latest committed outbox event, zero
+	// without events.
+	FindLastEventPosition(ctx context.Context) (int64, error)
+	// FindEventsAfter returns the committed events after the position in commit order.
+	FindEventsAfter(ctx context.Context, afterPosition int64, limit int) ([]events.Event, error)
+	CreateWebhook(ctx context.Context, webhook model.WebhookSubscription) (string, error)
+	FindWebhooks(ctx context.Context) ([]model.WebhookSubscription, error)
+	FindWebhook(ctx context.Context, uuid string) (model.WebhookSubscription, error)
+	UpdateWebhook(ctx context.Context, webhook model.WebhookSubscription) error
+	DeleteWebhook(ctx context.Context, uuid string) error
+	FindWebhookDeliveries(ctx context.Context, webhookUUID string, filter dto.DeliveryFilter) ([]model.WebhookDelivery, error)
+	// ClaimWebhookDeliveries leases the due deliveries until leaseUntil, so that other
+	// instances do not send them while they are in flight.
+	ClaimWebhookDeliveries(ctx context.Context, limit int, now, leaseUntil time.Time) ([]model.WebhookDelivery, error)
+	SaveWebhookAttempt(ctx context.Context, delivery model.WebhookDelivery) error
+	RedeliverWebhook(ctx context.Context, webhookUUID string, deliveryID int64, now time.Time) error
+	// AppendAuditEntry seals the entry onto the audit log chain of the tenant in a transaction
+	// of its own. The changes of users are audited in their transactions by the repository,
+	// the actor and the origin of the request taken from audit.FromContext.
+	AppendAuditEntry(ctx context.Context, entry model.AuditEntry) error
+	FindAuditEntries(ctx context.Context, filter dto.AuditFilter) ([]model.AuditEntry, error)
+	// FindAuditChain returns the entries after the given one, oldest first.
+	FindAuditChain(ctx context.Context, afterID int64, limit int) ([]model.AuditEntry, error)
+}
+
+// ImportTx is a transaction in which imported users are written.
+type ImportTx interface {
+	ExistingEmails(ctx context.Context, emails []string) ([]string, error)
+	CopyUsers(ctx context.Context, users []model.User) (int64, error)
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// Notifier wakes up the watchers of a tenant when its events are written, by any replica.
+type Notifier interface {
+	Subscribe(tenantID string) (<-chan struct{}, func())
+}
+
+type Options struct {
+	// DeletedGracePeriod is how long a deleted user can be restored before it is purged.
+	DeletedGracePeriod time.Duration
+	// RequireVerification makes new users pending instead of active.
+	RequireVerification bool
+	// AvatarBaseURL is the public address avatar URLs start with.
+	AvatarBaseURL string
+	AvatarMaxSize int64
+	// AvatarSizes are the edge sizes in pixels of the avatar thumbnails.
+	AvatarSizes []int
+	// PublicURL is the address of the HTTP API that links in emails start with.
+	PublicURL string
+	// EmailChangeTTL is how long the new address can confirm an email change.
+	EmailChangeTTL time.Duration
+	// EmailChangeRevertPeriod is how long the old address can revert an email change.
+	EmailChangeRevertPeriod time.Duration
+	// UsernameReclaimPeriod is how long a released username is kept from other users.
+	UsernameReclaimPeriod time.Duration
+	// PhoneCodeTTL is how long a one-time code sent by SMS can be used.
+	PhoneCodeTTL time.Duration
+	// PhoneCodeMaxAttempts is how many wrong guesses invalidate a code.
+	PhoneCodeMaxAttempts int
+	// PhoneResendInterval is the least time between two codes sent to a user or a number.
+	PhoneResendInterval time.Duration
+	// PhoneMaxSendsPerHour limits the codes sent to a user or a number within an hour.
+	PhoneMaxSendsPerHour int
+	// InvitationTTL is how long an invitation to an organization can be accepted.
+	InvitationTTL time.Duration
+	// AuthzCheckCacheTTL is how long authorization check results are cached, zero disables the cache.
+	AuthzCheckCacheTTL  time.Duration
+	AuthzCheckCacheSize int
+	// EventRetention is how long published events are kept in the outbox.
+	EventRetention time.Duration
+	// WebhookRetry spaces the attempts of webhook deliveries.
+	WebhookRetry model.RetryPolicy
+}
+
+// NewOptions reads the service options from the configuration.
+func NewOptions(cfg config.Config) Options {
+	return Options{
+		DeletedGracePeriod:      cfg.Users.DeletedGracePeriod,
+		RequireVerification:     cfg.Users.RequireVerification,
+		AvatarBaseURL:           cfg.Avatars.BaseURL,
+		AvatarMaxSize:           cfg.Avatars.MaxSize,
+		AvatarSizes:             cfg.Avatars.Sizes,
+		PublicURL:               cfg.HTTP.PublicURL,
+		EmailChangeTTL:          cfg.Users.EmailChangeTTL,
+		EmailChangeRevertPeriod: cfg.Users.EmailChangeRevertPeriod,
+		UsernameReclaimPeriod:   cfg.Users.UsernameReclaimPeriod,
+		PhoneCodeTTL:            cfg.Phone.CodeTTL,
+		PhoneCodeMaxAttempts:    cfg.Phone.CodeMaxAttempts,
+		PhoneResendInterval:     cfg.Phone.ResendInterval,
+		PhoneMaxSendsPerHour:    cfg.Phone.MaxSendsPerHour,
+		InvitationTTL:           cfg.Organizations.InvitationTTL,
+		AuthzCheckCacheTTL:      cfg.Authz.CheckCacheTTL,
+		AuthzCheckCacheSize:     cfg.Authz.CheckCacheSize,
+		EventRetention:          cfg.Events.Retention,
+		WebhookRetry: model.RetryPolicy{
+			MaxAttempts:    cfg.Webhooks.MaxAttempts,
+			InitialBackoff: cfg.Webhooks.InitialBackoff,
+			MaxBackoff:     cfg.Webhooks.MaxBackoff,
+		},
+	}
 }
 
 type service struct {
 	repository Repository
+	storage    blob.Storage
+	mailer     mail.Sender
+	smsSender  sms.Sender
+	notifier   Notifier
+	authzCache *authz.Cache
+	opts       Options
 	logger     *logging.Logger
 }
 
-func NewService(userRepository Repository, logger *logging.Logger) controller.Service {
+func NewService(userRepository Repository, storage blob.Storage, mailer mail.Sender, smsSender sms.Sender,
+	notifier Notifier, opts Options, logger *logging.Logger) controller.Service {
 	return &service{
 		repository: userRepository,
+		storage:    storage,
+		mailer:     mailer,
+		smsSender:  smsSender,
+		notifier:   notifier,
+		authzCache: authz.NewCache(opts.AuthzCheckCacheTTL, opts.AuthzCheckCacheSize),
+		opts:       opts,
 		logger:     logger,
 	}
 }
 
+func (s *service) initialStatus() model.Status {
+	if s.opts.RequireVerification {
+		return model.StatusPending
+	}
+	return model.StatusActive
+}
+
 func (s *service) Create(ctx context.Context, dto dto.CreateUserDTO) (string, error) {
 	if dto.Password != dto.RepeatedPassword {
 		return "", apperror.BadRequestError("password does not match repeated password")
 	}
 
-	user, err := model.NewCreatedUser(dto)
+	user, err := model.NewCreatedUser(dto, s.initialStatus(), time.Now().UTC())
 	if err != nil {
 		s.logger.Errorf("failed to create user: %v", err)
 		return "", err
 	}
+	if user.Username != "" {
+		if err = s.checkUsernameClaimable(ctx, user.Username, ""); err != nil {
+			return "", err
+		}
+	}
 
 	var userUUID string
 	userUUID, err = s.repository.Create(ctx, user)
@@ -51,12 +268,15 @@ func (s *service) Create(ctx context.Context, dto dto.CreateUserDTO) (string, er
 		s.logger.Errorf("failed to create user: %v", err)
 		return userUUID, fmt.Errorf("failed to create user: %w", err)
 	}
-
 	return userUUID, nil
 }
 
-func (s *service) GetAll(ctx context.Context) ([]model.User, error) {
-	users, err := s.repository.FindAll(ctx)
+func (s *service) GetAll(ctx context.Context, filter dto.UserFilter) ([]model.User, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, apperror.BadRequestError(err.Error())
+	}
+
+	users, err := s.repository.FindAll(ctx, filter)
 
 	if err != nil {
 		s.logger.Errorf("failed to find all users: %v", err)
@@ -65,6 +285,23 @@ func (s *service) GetAll(ctx context.Context) ([]model.User, error) {
 	return users, nil
 }
 
+func (s *service) Stream(ctx context.Context, dto dto.StreamUsersDTO, fn func(users []model.User) error) error {
+	if err := dto.Validate(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+
+	err := s.repository.Stream(ctx, dto.Filter, dto.BatchSize, fn)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			s.logger.Info("users stream canceled by client")
+			return err
+		}
+		s.logger.Errorf("failed to stream users: %v", err)
+		return fmt.Errorf("failed to stream users: %w", err)
+	}
+	return nil
+}
+
 func (s *service) GetByUUID(ctx context.Context, uuid string) (model.User, error) {
 	user, err := s.repository.FindByUUID(ctx, uuid)
 	if err != nil {
@@ -77,62 +314,291 @@ func (s *service) GetByUUID(ctx context.Context, uuid string) (model.User, error
 	return user, nil
 }
 
-func (s *service) GetByEmailAndPassword(ctx context.Context, email, password string) (model.User, error) {
-	user, err := s.repository.FindByEmail(ctx, email)
+func (s *service) GetByUUIDs(ctx context.Context, dto dto.GetUsersByUUIDsDTO) (model.UsersBatch, error) {
+	if err := dto.Validate(); err != nil {
+		return model.UsersBatch{}, apperror.BadRequestError(err.Error())
+	}
+
+	// ids are deduplicated keeping the order of their first occurrence,
+	// malformed ones are reported as missing instead of failing the whole query
+	order := make([]string, 0, len(dto.UUIDs))
+	seen := make(map[string]struct{}, len(dto.UUIDs))
+	valid := make([]string, 0, len(dto.UUIDs))
+	for _, uuid := range dto.UUIDs {
+		key := strings.ToLower(uuid)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		order = append(order, uuid)
+		if utils.IsValidUUID(uuid) {
+			valid = append(valid, key)
+		}
+	}
+
+	var found []model.User
+	if len(valid) > 0 {
+		var err error
+		found, err = s.repository.FindByUUIDs(ctx, valid)
+		if err != nil {
+			s.logger.Errorf("failed to find users by uuids: %v", err)
+			return model.UsersBatch{}, fmt.Errorf("failed to find users by uuids: %w", err)
+		}
+	}
+
+	byUUID := make(map[string]model.User, len(found))
+	for _, user := range found {
+		byUUID[strings.ToLower(user.UUID)] = user
+	}
+
+	batch := model.UsersBatch{
+		Users:   make([]model.PublicUser, 0, len(found)),
+		Missing: make([]string, 0),
+	}
+	for _, uuid := range order {
+		if user, ok := byUUID[strings.ToLower(uuid)]; ok {
+			batch.Users = append(batch.Users, model.NewPublicUser(user))
+		} else {
+			batch.Missing = append(batch.Missing, uuid)
+		}
+	}
+	return batch, nil
+}
+
+// GetByLoginAndPassword authenticates a user by email, verified phone or username. Users having
+// the phone as second factor are sent a code when none is given, the login being repeated with it.
+// Every attempt is audited, an attempt that cannot be audited fails.
+func (s *service) GetByLoginAndPassword(ctx context.Context, dto dto.LoginDTO) (model.User, error) {
+	user, err := s.authenticate(ctx, dto)
+	if err != nil {
+		if auditErr := s.auditLogin(ctx, model.AuditLoginFailed, user.UUID, err); auditErr != nil {
+			return model.User{}, auditErr
+		}
+		return model.User{}, err
+	}
+	if err = s.auditLogin(ctx, model.AuditLoginSucceeded, user.UUID, nil); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// authenticate returns the user found by the login along with the error of a failed attempt.
+func (s *service) authenticate(ctx context.Context, dto dto.LoginDTO) (model.User, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return model.User{}, apperror.BadRequestError(err.Error())
+	}
+
+	user, err := s.findByLogin(ctx, dto.Login)
 	if err != nil {
-		s.logger.Errorf("failed to find user by email: %v", err)
-		if errors.Is(err, apperror.ErrNotFound) {
-			return user, err
+		s.logger.Errorf("failed to find user by login: %v", err)
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return model.User{}, err
 		}
-		return user, fmt.Errorf("failed to find user by email: %w", err)
+		return model.User{}, fmt.Errorf("failed to find user by login: %w", err)
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+	if err = user.CheckPassword(dto.Password); err != nil {
+		if errors.Is(err, model.ErrPasswordMismatch) {
 			return user, apperror.BadRequestError("incorrect password")
 		}
 		return user, fmt.Errorf("failed to compare passwords: %w", err)
 	}
 
+	if err = user.CanAuthenticate(); err != nil {
+		return user, err
+	}
+
+	if user.PhoneSecondFactor {
+		if err = s.checkSecondFactor(ctx, user, dto.Code); err != nil {
+			return user, err
+		}
+	}
+
+	now := time.Now().UTC()
+	if err = s.repository.UpdateLastLogin(ctx, user.UUID, now); err != nil {
+		// a missed login timestamp must not prevent the login itself
+		s.logger.Errorf("failed to update last login: %v", err)
+	} else {
+		user.LastLoginAt = &now
+	}
+
 	return user, nil
 }
 
 func (s *service) Update(ctx context.Context, dto dto.UpdateUserDTO) error {
-	user, err := s.repository.FindByUUID(ctx, dto.UUID)
+	user, err := s.findForUpdate(ctx, dto.UUID, dto.Password, dto.ExpectedVersion)
 	if err != nil {
 		return err
 	}
+	return s.update(ctx, user, dto)
+}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(dto.Password))
+func (s *service) Patch(ctx context.Context, dto dto.PatchUserDTO) error {
+	user, err := s.findForUpdate(ctx, dto.UUID, dto.Password, dto.ExpectedVersion)
 	if err != nil {
-		return apperror.BadRequestError("incorrect password")
+		return err
 	}
 
-	updatedUser, err := model.NewUpdatedUser(user, dto)
+	update, err := model.NewPatchUpdate(user, dto.Format, dto.Patch)
 	if err != nil {
 		return err
 	}
+	return s.update(ctx, user, update)
+}
 
-	err = s.repository.Update(ctx, updatedUser)
+func (s *service) Replace(ctx context.Context, dto dto.ReplaceUserDTO) error {
+	return s.UpdateMasked(ctx, model.NewReplaceUpdate(dto))
+}
+
+func (s *service) UpdateMasked(ctx context.Context, dto dto.MaskedUpdateUserDTO) error {
+	update, err := model.NewMaskedUpdate(dto)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.findForUpdate(ctx, dto.UUID, dto.Password, dto.ExpectedVersion)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, user, update)
+}
+
+// findForUpdate loads the user and checks the current password and the expected version.
+func (s *service) findForUpdate(ctx context.Context, uuid, password string, expectedVersion *int64) (model.User, error) {
+	user, err := s.repository.FindByUUID(ctx, uuid)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	err = user.CheckPassword(password)
+	if err != nil {
+		return model.User{}, apperror.BadRequestError("incorrect password")
+	}
+
+	if err = user.CheckVersion(expectedVersion); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+func (s *service) update(ctx context.Context, user model.User, dto dto.UpdateUserDTO) error {
+	newEmail, err := model.RequestedEmail(user, dto)
+	if err != nil {
+		return err
+	}
+	if newEmail != "" {
+		if err = s.checkEmailAvailable(ctx, newEmail); err != nil {
+			return err
+		}
+	}
+
+	updatedUser, err := model.NewUpdatedUser(user, dto, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if updatedUser.Username != "" && updatedUser.Username != user.Username {
+		if err = s.checkUsernameClaimable(ctx, updatedUser.Username, user.UUID); err != nil {
+			return err
+		}
+	}
+
+	// a new email is only requested here, the new address confirms it
+	var emailChange *model.EmailChange
+	if newEmail != "" {
+		change, err := model.NewEmailChange(user, newEmail, time.Now().UTC(), s.opts.EmailChangeTTL)
+		if err != nil {
+			return fmt.Errorf("failed to generate email change tokens: %w", err)
+		}
+		emailChange = &change
+	}
+
+	err = s.repository.Update(ctx, updatedUser, emailChange)
 
 	if err != nil {
 		s.logger.Errorf("failed to update user: %v", err)
+		if apperror.IsPreconditionFailed(err) {
+			return err
+		}
 		return fmt.Errorf("failed to update user: %w", err)
 	}
+
+	if emailChange != nil {
+		s.sendEmailChangeLinks(ctx, *emailChange)
+	}
 	return nil
 }
 
-func (s *service) Delete(ctx context.Context, uuid string) error {
-	_, err := s.repository.FindByUUID(ctx, uuid)
+func (s *service) ChangeStatus(ctx context.Context, dto dto.ChangeUserStatusDTO) error {
+	to := model.Status(dto.Status)
+	if to == model.StatusDeleted {
+		return apperror.BadRequestError("users are deleted with the delete operation")
+	}
+	if (to == model.StatusSuspended || to == model.StatusLocked) && dto.Reason == "" {
+		return apperror.BadRequestError("reason must not be empty")
+	}
+
+	user, err := s.repository.FindByUUID(ctx, dto.UUID)
 	if err != nil {
 		return err
 	}
+	if err = user.Status.ValidateTransition(to); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
 
-	err = s.repository.Delete(ctx, uuid)
+	err = s.repository.UpdateStatus(ctx, user.UUID, user.Status, to, dto.Reason)
+	if err != nil {
+		s.logger.Errorf("failed to change user status: %v", err)
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
+		return fmt.Errorf("failed to change user status: %w", err)
+	}
+	s.logger.Infof("user %s status changed from %s to %s", user.UUID, user.Status, to)
+	return nil
+}
+
+func (s *service) Delete(ctx context.Context, dto dto.DeleteUserDTO) error {
+	user, err := s.repository.FindByUUID(ctx, dto.UUID)
+	if err != nil {
+		return err
+	}
+
+	if err = user.CheckVersion(dto.ExpectedVersion); err != nil {
+		return err
+	}
+
+	err = s.repository.Delete(ctx, user.UUID, user.Version)
 
 	if err != nil {
 		s.logger.Errorf("failed to delete user: %v", err)
+		if apperror.IsPreconditionFailed(err) {
+			return err
+		}
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
-	return err
+	return nil
+}
+
+func (s *service) Restore(ctx context.Context, uuid string) error {
+	err := s.repository.Restore(ctx, uuid, time.Now().Add(-s.opts.DeletedGracePeriod))
+	if err != nil {
+		s.logger.Errorf("failed to restore user: %v", err)
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+	return nil
+}
+
+func (s *service) PurgeDeleted(ctx context.Context) (int64, error) {
+	purged, err := s.repository.Purge(ctx, time.Now().Add(-s.opts.DeletedGracePeriod))
+	if err != nil {
+		s.logger.Errorf("failed to purge deleted users: %v", err)
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+	return purged, nil
 }
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"Users/pkg/tenant"
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// tenantQuery is appended to mailed links, which are opened without the bearer token naming the tenant.
+func tenantQuery(ctx context.Context) string {
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return "&tenant=" + url.QueryEscape(id)
+}
+
+func (s *service) GetTenants(ctx context.Context) ([]string, error) {
+	tenants, err := s.repository.FindTenants(ctx)
+	if err != nil {
+		s.logger.Errorf("failed to find tenants: %v", err)
+		return nil, fmt.Errorf("failed to find tenants: %w", err)
+	}
+	return tenants, nil
+}
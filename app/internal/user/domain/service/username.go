@@ -0,0 +1,94 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/model"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (s *service) findByLogin(ctx context.Context, login string) (model.User, error) {
+	if strings.HasPrefix(strings.TrimSpace(login), "+") {
+		phone, err := model.NormalizePhone(login)
+		if err != nil {
+			return model.User{}, err
+		}
+		return s.repository.FindByPhone(ctx, phone)
+	}
+	if strings.Contains(login, "@") {
+		email, err := model.NormalizeEmail(login)
+		if err != nil {
+			return model.User{}, err
+		}
+		return s.repository.FindByEmail(ctx, email)
+	}
+
+	username, err := model.NormalizeUsername(login)
+	if err != nil {
+		return model.User{}, err
+	}
+	return s.repository.FindByUsername(ctx, username)
+}
+
+func (s *service) CheckUsername(ctx context.Context, username string) (model.UsernameAvailability, error) {
+	availability := model.NewUsernameAvailability(username)
+	if !availability.Available {
+		return availability, nil
+	}
+
+	reason, err := s.usernameUnavailability(ctx, availability.Username, "")
+	if err != nil {
+		return model.UsernameAvailability{}, err
+	}
+	if reason != "" {
+		availability.Available = false
+		availability.Reason = reason
+		availability.Message = usernameUnavailableMessage(availability.Username, reason)
+	}
+	return availability, nil
+}
+
+// checkUsernameClaimable fails unless the normalized username can be taken by the user,
+// who may take back a username of their own at any time.
+func (s *service) checkUsernameClaimable(ctx context.Context, username, userUUID string) error {
+	reason, err := s.usernameUnavailability(ctx, username, userUUID)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return apperror.BadRequestError(usernameUnavailableMessage(username, reason))
+	}
+	return nil
+}
+
+func (s *service) usernameUnavailability(ctx context.Context, username, userUUID string) (string, error) {
+	holder, err := s.repository.FindByUsername(ctx, username)
+	if err == nil && holder.UUID != userUUID {
+		return model.UsernameTaken, nil
+	}
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		s.logger.Errorf("failed to find user by username: %v", err)
+		return "", fmt.Errorf("failed to find user by username: %w", err)
+	}
+
+	released, err := s.repository.IsUsernameReleased(ctx, username, userUUID,
+		time.Now().UTC().Add(-s.opts.UsernameReclaimPeriod))
+	if err != nil {
+		s.logger.Errorf("failed to check username history: %v", err)
+		return "", fmt.Errorf("failed to check username history: %w", err)
+	}
+	if released {
+		return model.UsernameRecentlyReleased, nil
+	}
+	return "", nil
+}
+
+func usernameUnavailableMessage(username, reason string) string {
+	if reason == model.UsernameRecentlyReleased {
+		return fmt.Sprintf("username %q has been released recently and cannot be claimed yet", username)
+	}
+	return "User with this username already exists"
+}
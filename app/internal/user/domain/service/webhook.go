@@ -0,0 +1,169 @@
+package service
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/webhook"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// webhookLeaseMargin is added to the request timeout when deliveries are claimed, so
+// that a lease only runs out on deliveries whose instance went away.
+const webhookLeaseMargin = 30 * time.Second
+
+func (s *service) CreateWebhook(ctx context.Context, dto dto.CreateWebhookDTO) (model.CreatedWebhook, error) {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return model.CreatedWebhook{}, apperror.BadRequestError(err.Error())
+	}
+	subscription, err := model.NewWebhookSubscription(dto.URL, dto.Events, dto.Description, time.Now().UTC())
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return model.CreatedWebhook{}, err
+		}
+		s.logger.Errorf("failed to create webhook: %v", err)
+		return model.CreatedWebhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	webhookUUID, err := s.repository.CreateWebhook(ctx, subscription)
+	if err != nil {
+		s.logger.Errorf("failed to create webhook: %v", err)
+		return model.CreatedWebhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return model.CreatedWebhook{UUID: webhookUUID, Secret: subscription.Secret}, nil
+}
+
+func (s *service) GetWebhooks(ctx context.Context) ([]model.WebhookSubscription, error) {
+	webhooks, err := s.repository.FindWebhooks(ctx)
+	if err != nil {
+		s.logger.Errorf("failed to find webhooks: %v", err)
+		return nil, fmt.Errorf("failed to find webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (s *service) GetWebhook(ctx context.Context, uuid string) (model.WebhookSubscription, error) {
+	subscription, err := s.repository.FindWebhook(ctx, uuid)
+	if err != nil {
+		if errors.Is(err, apperror.ErrNotFound) {
+			return model.WebhookSubscription{}, err
+		}
+		s.logger.Errorf("failed to find webhook by uuid: %v", err)
+		return model.WebhookSubscription{}, fmt.Errorf("failed to find webhook by uuid: %w", err)
+	}
+	return subscription, nil
+}
+
+func (s *service) UpdateWebhook(ctx context.Context, dto dto.UpdateWebhookDTO) error {
+	if err := dto.ValidateEmptyFields(); err != nil {
+		return apperror.BadRequestError(err.Error())
+	}
+	subscription, err := s.GetWebhook(ctx, dto.UUID)
+	if err != nil {
+		return err
+	}
+	if err = subscription.Update(dto.URL, dto.Events, dto.Description, *dto.Active, time.Now().UTC()); err != nil {
+		return err
+	}
+	return s.webhookError(s.repository.UpdateWebhook(ctx, subscription), "update webhook")
+}
+
+func (s *service) DeleteWebhook(ctx context.Context, uuid string) error {
+	return s.webhookError(s.repository.DeleteWebhook(ctx, uuid), "delete webhook")
+}
+
+func (s *service) GetWebhookDeliveries(ctx context.Context, webhookUUID string,
+	filter dto.DeliveryFilter) ([]model.WebhookDelivery, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, apperror.BadRequestError(err.Error())
+	}
+	if filter.Status != "" && !model.DeliveryStatus(filter.Status).Valid() {
+		return nil, apperror.BadRequestError(fmt.Sprintf("unknown delivery status %q", filter.Status))
+	}
+	if _, err := s.GetWebhook(ctx, webhookUUID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repository.FindWebhookDeliveries(ctx, webhookUUID, filter)
+	if err != nil {
+		s.logger.Errorf("failed to find webhook deliveries: %v", err)
+		return nil, fmt.Errorf("failed to find webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (s *service) RedeliverWebhook(ctx context.Context, webhookUUID string, deliveryID int64) error {
+	err := s.repository.RedeliverWebhook(ctx, webhookUUID, deliveryID, time.Now().UTC())
+	return s.webhookError(err, "redeliver webhook")
+}
+
+// DispatchWebhooks sends the due deliveries of the tenant concurrently and records the
+// outcome of every attempt, failed deliveries are retried with backoff.
+func (s *service) DispatchWebhooks(ctx context.Context, limit int, client *webhook.Client) (int, error) {
+	now := time.Now().UTC()
+	deliveries, err := s.repository.ClaimWebhookDeliveries(ctx, limit, now,
+		now.Add(client.Timeout()+webhookLeaseMargin))
+	if err != nil {
+		s.logger.Errorf("failed to claim webhook deliveries: %v", err)
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery model.WebhookDelivery) {
+			defer wg.Done()
+			s.deliverWebhook(ctx, client, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+func (s *service) deliverWebhook(ctx context.Context, client *webhook.Client, delivery model.WebhookDelivery) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		s.logger.Errorf("failed to marshal webhook delivery %d: %v", delivery.ID, err)
+		return
+	}
+
+	statusCode, sendErr := client.Send(ctx, webhook.Request{
+		URL:    delivery.URL,
+		Secret: delivery.Secret,
+		ID:     strconv.FormatInt(delivery.ID, 10),
+		Event:  delivery.Event.Type,
+		Body:   body,
+	}, time.Now().UTC())
+	if sendErr != nil && ctx.Err() != nil {
+		// the lease runs out and another poll sends the delivery again
+		return
+	}
+
+	delivery.RecordAttempt(statusCode, sendErr, time.Now().UTC(), s.opts.WebhookRetry)
+	if delivery.Status == model.DeliveryDead {
+		s.logger.Warnf("webhook delivery %d to %s is dead after %d attempts: %v",
+			delivery.ID, delivery.URL, delivery.Attempts, sendErr)
+	}
+	if err = s.repository.SaveWebhookAttempt(ctx, delivery); err != nil {
+		s.logger.Errorf("failed to save attempt of webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func (s *service) webhookError(err error, action string) error {
+	if err == nil {
+		return nil
+	}
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+	s.logger.Errorf("failed to %s: %v", action, err)
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
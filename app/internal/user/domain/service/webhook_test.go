@@ -0,0 +1,191 @@
+package service
+
+import (
+	"Users/internal/user/domain/model"
+	"Users/pkg/events"
+	"Users/pkg/webhook"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// deliveryRepository keeps the webhook deliveries in memory and hands out the pending ones
+// on every claim regardless of when they are due, so that a test walks through all the
+// attempts of a delivery without waiting for its backoff.
+type deliveryRepository struct {
+	Repository
+	mu         sync.Mutex
+	deliveries map[int64]model.WebhookDelivery
+	// attempts records every saved attempt in order
+	attempts []model.WebhookDelivery
+}
+
+func (r *deliveryRepository) ClaimWebhookDeliveries(ctx context.Context, limit int,
+	now, leaseUntil time.Time) ([]model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	claimed := make([]model.WebhookDelivery, 0)
+	for _, delivery := range r.deliveries {
+		if delivery.Status == model.DeliveryPending && len(claimed) < limit {
+			claimed = append(claimed, delivery)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *deliveryRepository) SaveWebhookAttempt(ctx context.Context, delivery model.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[delivery.ID] = delivery
+	r.attempts = append(r.attempts, delivery)
+	return nil
+}
+
+// receivedWebhook is a request as the test receiver saw it.
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+	err    error
+}
+
+// newWebhookReceiver answers the nth request with statuses[n], the last status repeats.
+func newWebhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, func() []receivedWebhook) {
+	t.Helper()
+	var mu sync.Mutex
+	var received []receivedWebhook
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		mu.Lock()
+		status := statuses[min(len(received), len(statuses)-1)]
+		received = append(received, receivedWebhook{header: r.Header.Clone(), body: body, err: err})
+		mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("receiver says " + http.StatusText(status)))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []receivedWebhook {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedWebhook{}, received...)
+	}
+}
+
+func newTestDelivery(url string) model.WebhookDelivery {
+	return model.WebhookDelivery{
+		ID:               42,
+		SubscriptionUUID: "9f0c3e1a-2b4d-4c6e-8f1a-3b5d7e9f1a2c",
+		Event: events.Event{
+			ID:         7,
+			Type:       model.EventUserUpdated,
+			UserUUID:   "3f1c1c5e-4b2a-4f4e-9a43-0c1d2e3f4a5b",
+			Payload:    json.RawMessage(`{"name":"Joe"}`),
+			OccurredAt: time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC),
+		},
+		Status: model.DeliveryPending,
+		URL:    url,
+		Secret: "whsec-test",
+	}
+}
+
+func TestDispatchWebhooksRetriesUntilDead(t *testing.T) {
+	server, received := newWebhookReceiver(t, http.StatusInternalServerError)
+	delivery := newTestDelivery(server.URL)
+	repository := &deliveryRepository{deliveries: map[int64]model.WebhookDelivery{delivery.ID: delivery}}
+	policy := model.RetryPolicy{MaxAttempts: 5, InitialBackoff: 30 * time.Second, MaxBackoff: 2 * time.Minute}
+	s := NewService(repository, nil, nil, nil, nil, Options{WebhookRetry: policy}, newTestLogger())
+	client := webhook.NewClient(5 * time.Second)
+
+	for i := 0; i < policy.MaxAttempts+2; i++ {
+		if _, err := s.DispatchWebhooks(context.Background(), 10, client); err != nil {
+			t.Fatalf("dispatch failed: %v", err)
+		}
+	}
+
+	requests := received()
+	if len(requests) != policy.MaxAttempts {
+		t.Fatalf("receiver got %d requests, want %d", len(requests), policy.MaxAttempts)
+	}
+	for i, request := range requests {
+		if request.err != nil {
+			t.Fatalf("request %d: failed to read body: %v", i, request.err)
+		}
+		err := webhook.Verify(delivery.Secret, request.header.Get(webhook.SignatureHeader), request.body,
+			time.Minute, time.Now())
+		if err != nil {
+			t.Errorf("request %d: %v", i, err)
+		}
+		if got := request.header.Get(webhook.IDHeader); got != "42" {
+			t.Errorf("request %d: delivery id header is %q, want the same on every retry", i, got)
+		}
+		if got := request.header.Get(webhook.EventHeader); got != model.EventUserUpdated {
+			t.Errorf("request %d: event header is %q", i, got)
+		}
+		if got := request.header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("request %d: content type is %q", i, got)
+		}
+		var event events.Event
+		if err = json.Unmarshal(request.body, &event); err != nil || event.ID != delivery.Event.ID {
+			t.Errorf("request %d: body %s is not the event: %v", i, request.body, err)
+		}
+	}
+
+	// the wait doubles after every failure up to the maximum
+	wantBackoffs := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 2 * time.Minute}
+	attempts := repository.attempts
+	if len(attempts) != policy.MaxAttempts {
+		t.Fatalf("%d attempts saved, want %d", len(attempts), policy.MaxAttempts)
+	}
+	for i, want := range wantBackoffs {
+		attempt := attempts[i]
+		if attempt.Status != model.DeliveryPending || attempt.NextAttemptAt == nil {
+			t.Fatalf("attempt %d: status %s, next attempt %v", i+1, attempt.Status, attempt.NextAttemptAt)
+		}
+		if got := attempt.NextAttemptAt.Sub(*attempt.LastAttemptAt); got != want {
+			t.Errorf("attempt %d: next attempt after %s, want %s", i+1, got, want)
+		}
+	}
+
+	final := repository.deliveries[delivery.ID]
+	if final.Status != model.DeliveryDead {
+		t.Errorf("final status is %s, want %s", final.Status, model.DeliveryDead)
+	}
+	if final.Attempts != policy.MaxAttempts || final.NextAttemptAt != nil {
+		t.Errorf("final delivery has %d attempts, next attempt %v", final.Attempts, final.NextAttemptAt)
+	}
+	if final.LastStatusCode != http.StatusInternalServerError || !strings.Contains(final.LastError, "receiver says") {
+		t.Errorf("final delivery recorded status %d, error %q", final.LastStatusCode, final.LastError)
+	}
+}
+
+func TestDispatchWebhooksSucceedsAfterRetry(t *testing.T) {
+	server, received := newWebhookReceiver(t, http.StatusServiceUnavailable, http.StatusNoContent)
+	delivery := newTestDelivery(server.URL)
+	repository := &deliveryRepository{deliveries: map[int64]model.WebhookDelivery{delivery.ID: delivery}}
+	policy := model.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: time.Minute}
+	s := NewService(repository, nil, nil, nil, nil, Options{WebhookRetry: policy}, newTestLogger())
+	client := webhook.NewClient(5 * time.Second)
+
+	for i := 0; i < 4; i++ {
+		if _, err := s.DispatchWebhooks(context.Background(), 10, client); err != nil {
+			t.Fatalf("dispatch failed: %v", err)
+		}
+	}
+
+	if got := len(received()); got != 2 {
+		t.Fatalf("receiver got %d requests, want 2", got)
+	}
+	final := repository.deliveries[delivery.ID]
+	if final.Status != model.DeliverySucceeded || final.Attempts != 2 {
+		t.Errorf("final delivery is %s after %d attempts", final.Status, final.Attempts)
+	}
+	if final.LastStatusCode != http.StatusNoContent || final.LastError != "" || final.NextAttemptAt != nil {
+		t.Errorf("final delivery recorded status %d, error %q, next attempt %v",
+			final.LastStatusCode, final.LastError, final.NextAttemptAt)
+	}
+}
@@ -0,0 +1,69 @@
+package purger
+
+import (
+	"Users/internal/user/controller"
+	"Users/pkg/logging"
+	"Users/pkg/tenant"
+	"context"
+	"time"
+)
+
+// Purger periodically hard deletes users whose soft delete is older than the grace period
+// and published events older than their retention, tenant by tenant because the storage
+// only sees the rows of one tenant at a time.
+type Purger struct {
+	service  controller.Service
+	interval time.Duration
+	logger   *logging.Logger
+}
+
+func NewPurger(service controller.Service, interval time.Duration, logger *logging.Logger) *Purger {
+	return &Purger{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (p *Purger) Run(ctx context.Context) error {
+	p.logger.Infof("deleted users purger started, interval: %s", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			p.logger.Info("deleted users purger stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) purge(ctx context.Context) {
+	tenants, err := p.service.GetTenants(ctx)
+	if err != nil {
+		// the service has already logged the error, the next tick retries
+		return
+	}
+	for _, id := range tenants {
+		purged, err := p.service.PurgeDeleted(tenant.WithID(ctx, id))
+		if err != nil {
+			continue
+		}
+		if purged > 0 {
+			p.logger.Infof("purged %d deleted users of tenant %s", purged, id)
+		}
+
+		purged, err = p.service.PurgePublishedEvents(tenant.WithID(ctx, id))
+		if err != nil {
+			continue
+		}
+		if purged > 0 {
+			p.logger.Infof("purged %d published events of tenant %s", purged, id)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package relay
+
+import (
+	"Users/internal/user/controller"
+	"Users/pkg/events"
+	"Users/pkg/logging"
+	"Users/pkg/tenant"
+	"context"
+	"time"
+)
+
+// Relay periodically publishes the events of the outbox, tenant by tenant because the
+// storage only sees the rows of one tenant at a time.
+type Relay struct {
+	service   controller.Service
+	publisher events.Publisher
+	interval  time.Duration
+	batchSize int
+	logger    *logging.Logger
+}
+
+func NewRelay(service controller.Service, publisher events.Publisher, interval time.Duration, batchSize int,
+	logger *logging.Logger) *Relay {
+	return &Relay{
+		service:   service,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) error {
+	r.logger.Infof("outbox relay started, interval: %s", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.relay(ctx)
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info("outbox relay stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) relay(ctx context.Context) {
+	tenants, err := r.service.GetTenants(ctx)
+	if err != nil {
+		// the service has already logged the error, the next tick retries
+		return
+	}
+	for _, id := range tenants {
+		tenantCtx := tenant.WithID(ctx, id)
+		// a full batch means more events are waiting
+		for ctx.Err() == nil {
+			published, err := r.service.RelayEvents(tenantCtx, r.batchSize, r.publisher)
+			if err != nil || published < r.batchSize {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package postgres
+
+import (
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/audit"
+	"Users/pkg/logging"
+	"Users/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"slices"
+	"time"
+)
+
+const auditColumns = `
+						id, occurred_at, actor, action, COALESCE(target_user_id::text, ''), changes::text, error,
+						ip, user_agent, request_id, prev_hash, hash
+`
+
+func scanAuditEntry(row pgx.Row) (model.AuditEntry, error) {
+	var entry model.AuditEntry
+	var changes string
+	err := row.Scan(&entry.ID, &entry.OccurredAt, &entry.Actor, &entry.Action, &entry.TargetUUID, &changes,
+		&entry.Error, &entry.IP, &entry.UserAgent, &entry.RequestID, &entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return entry, err
+	}
+	if err = json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+		return entry, fmt.Errorf("failed to unmarshal audit changes: %w", err)
+	}
+	if len(entry.Changes) == 0 {
+		entry.Changes = nil
+	}
+	entry.OccurredAt = entry.OccurredAt.UTC()
+	return entry, nil
+}
+
+// AppendAuditEntry seals the entry onto the chain in a transaction of its own, for the
+// actions that change no user, the others are audited in their transactions.
+func (r *repository) AppendAuditEntry(ctx context.Context, entry model.AuditEntry) error {
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback audit transaction: %v", rbErr)
+		}
+	}()
+
+	if err = appendAuditEntries(nCtx, tx, r.logger, []model.AuditEntry{entry}); err != nil {
+		return err
+	}
+	if err = tx.Commit(nCtx); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+// writeAudit records the action of the request source on the target user in the transaction
+// of the change, before and after being the user or its profile around it. It is the last
+// statement before the commit, see appendAuditEntries.
+func (r *repository) writeAudit(ctx context.Context, tx pgx.Tx, action, targetUUID string,
+	before, after interface{}, at time.Time) error {
+	entry, err := model.NewAuditEntry(audit.FromContext(ctx), action, targetUUID, before, after, at)
+	if err != nil {
+		return err
+	}
+	return appendAuditEntries(ctx, tx, r.logger, []model.AuditEntry{entry})
+}
+
+// appendAuditEntries seals the entries onto the audit log chain of the tenant. The lock of
+// the chain is held until the commit, so that entries are chained one after the other;
+// transactions append right before they commit to hold it as briefly as possible.
+func appendAuditEntries(ctx context.Context, tx pgx.Tx, logger *logging.Logger, entries []model.AuditEntry) error {
+	lockQuery := `
+				SELECT
+					pg_advisory_xact_lock(hashtextextended('audit_log:' || current_setting('app.tenant_id', TRUE), 0))
+	`
+	headQuery := `
+				SELECT
+					hash
+				FROM
+					audit_log
+				ORDER BY
+					id DESC
+				LIMIT 1
+	`
+	idQuery := `
+				SELECT
+					nextval(pg_get_serial_sequence('audit_log', 'id'))
+				FROM
+					generate_series(1, $1)
+	`
+	insertQuery := `
+				INSERT INTO audit_log
+					(id, occurred_at, actor, action, target_user_id, changes, error, ip, user_agent, request_id,
+					 prev_hash, hash)
+				SELECT
+					id, occurred_at, actor, action, NULLIF(target_user_id, '')::uuid, changes, error, ip,
+					user_agent, request_id, prev_hash, hash
+				FROM
+					unnest($1::bigint[], $2::timestamptz[], $3::text[], $4::text[], $5::text[], $6::text[]::jsonb[],
+						$7::text[], $8::text[], $9::text[], $10::text[], $11::text[], $12::text[])
+					AS e(id, occurred_at, actor, action, target_user_id, changes, error, ip, user_agent,
+						request_id, prev_hash, hash)
+	`
+	logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+	logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(headQuery)))
+	logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(idQuery)))
+	logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(insertQuery)))
+
+	if _, err := tx.Exec(ctx, lockQuery); err != nil {
+		return handleSQLError(err, logger)
+	}
+	var prevHash string
+	if err := tx.QueryRow(ctx, headQuery).Scan(&prevHash); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return handleSQLError(err, logger)
+	}
+
+	// taken under the lock, so that the ids follow the chain
+	rows, err := tx.Query(ctx, idQuery, len(entries))
+	if err != nil {
+		return handleSQLError(err, logger)
+	}
+	ids := make([]int64, 0, len(entries))
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return handleSQLError(err, logger)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return handleSQLError(err, logger)
+	}
+	slices.Sort(ids)
+
+	n := len(entries)
+	occurredAt, actors, actions := make([]time.Time, 0, n), make([]string, 0, n), make([]string, 0, n)
+	targets, changes, errs := make([]string, 0, n), make([]string, 0, n), make([]string, 0, n)
+	ips, userAgents, requestIDs := make([]string, 0, n), make([]string, 0, n), make([]string, 0, n)
+	prevHashes, hashes := make([]string, 0, n), make([]string, 0, n)
+	for i := range entries {
+		entry := &entries[i]
+		entry.ID = ids[i]
+		if err = entry.Seal(prevHash); err != nil {
+			return err
+		}
+		prevHash = entry.Hash
+
+		entryChanges := []byte("{}")
+		if len(entry.Changes) > 0 {
+			if entryChanges, err = json.Marshal(entry.Changes); err != nil {
+				return fmt.Errorf("failed to marshal audit changes: %w", err)
+			}
+		}
+		occurredAt = append(occurredAt, entry.OccurredAt)
+		actors = append(actors, entry.Actor)
+		actions = append(actions, entry.Action)
+		targets = append(targets, entry.TargetUUID)
+		changes = append(changes, string(entryChanges))
+		errs = append(errs, entry.Error)
+		ips = append(ips, entry.IP)
+		userAgents = append(userAgents, entry.UserAgent)
+		requestIDs = append(requestIDs, entry.RequestID)
+		prevHashes = append(prevHashes, entry.PrevHash)
+		hashes = append(hashes, entry.Hash)
+	}
+
+	if _, err = tx.Exec(ctx, insertQuery, ids, occurredAt, actors, actions, targets, changes, errs, ips,
+		userAgents, requestIDs, prevHashes, hashes); err != nil {
+		return handleSQLError(err, logger)
+	}
+	return nil
+}
+
+func (r *repository) FindAuditEntries(ctx context.Context, filter dto.AuditFilter) ([]model.AuditEntry, error) {
+	where, args := auditFilterClause(filter)
+	args = append(args, filter.Limit)
+	query := `
+				SELECT` + auditColumns + `
+				FROM
+					audit_log
+				` + where + `
+				ORDER BY
+					id DESC
+				LIMIT ` + fmt.Sprintf("$%d", len(args)) + `
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, args...)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	entries := make([]model.AuditEntry, 0)
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return entries, nil
+}
+
+func (r *repository) FindAuditChain(ctx context.Context, afterID int64, limit int) ([]model.AuditEntry, error) {
+	query := `
+				SELECT` + auditColumns + `
+				FROM
+					audit_log
+				WHERE
+					id > $1
+				ORDER BY
+					id
+				LIMIT $2
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, afterID, limit)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	entries := make([]model.AuditEntry, 0)
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return entries, nil
+}
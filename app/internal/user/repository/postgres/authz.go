@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"Users/pkg/authz"
+	"Users/pkg/utils"
+	"context"
+	"fmt"
+	"time"
+)
+
+// tupleArrays splits the tuples into the column arrays unnest takes.
+func tupleArrays(tuples []authz.Tuple) []interface{} {
+	columns := make([][]string, 6)
+	for _, tuple := range tuples {
+		values := []string{tuple.Object.Namespace, tuple.Object.ID, tuple.Relation,
+			tuple.Subject.Namespace, tuple.Subject.ID, tuple.Subject.Relation}
+		for i, value := range values {
+			columns[i] = append(columns[i], value)
+		}
+	}
+	args := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		args = append(args, column)
+	}
+	return args
+}
+
+func (r *repository) FindTuples(ctx context.Context, object authz.Object, relation string) ([]authz.Tuple, error) {
+	query := `
+				SELECT
+					namespace, object_id, relation, subject_namespace, subject_object_id, subject_relation
+				FROM
+					relation_tuples
+				WHERE
+					namespace = $1 AND object_id = $2 AND ($3 = '' OR relation = $3)
+				ORDER BY
+					relation, subject_namespace, subject_object_id, subject_relation
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, object.Namespace, object.ID, relation)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	tuples := make([]authz.Tuple, 0)
+	for rows.Next() {
+		var tuple authz.Tuple
+		err = rows.Scan(&tuple.Object.Namespace, &tuple.Object.ID, &tuple.Relation,
+			&tuple.Subject.Namespace, &tuple.Subject.ID, &tuple.Subject.Relation)
+		if err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, tuple)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return tuples, nil
+}
+
+func (r *repository) FindObjectIDs(ctx context.Context, namespace string) ([]string, error) {
+	query := `
+				SELECT
+					object_id
+				FROM
+					relation_tuples
+				WHERE
+					namespace = $1
+				UNION
+				SELECT
+					subject_object_id
+				FROM
+					relation_tuples
+				WHERE
+					subject_namespace = $1
+				ORDER BY
+					1
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, namespace)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return ids, nil
+}
+
+func (r *repository) WriteTuples(ctx context.Context, tuples []authz.Tuple, now time.Time) error {
+	query := `
+				INSERT INTO relation_tuples
+					(namespace, object_id, relation, subject_namespace, subject_object_id, subject_relation, created_at)
+				SELECT
+					*, $7::timestamptz
+				FROM
+					unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[])
+				ON CONFLICT DO NOTHING
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	if _, err := r.client.Exec(nCtx, query, append(tupleArrays(tuples), now)...); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+func (r *repository) DeleteTuples(ctx context.Context, tuples []authz.Tuple) error {
+	query := `
+				DELETE FROM
+					relation_tuples
+				WHERE
+					(namespace, object_id, relation, subject_namespace, subject_object_id, subject_relation) IN (
+						SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[])
+					)
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	if _, err := r.client.Exec(nCtx, query, tupleArrays(tuples)...); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+func (r *repository) FindAuthzSchema(ctx context.Context) (string, error) {
+	query := `
+				SELECT
+					source
+				FROM
+					authz_schemas
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	var source string
+	if err := r.client.QueryRow(nCtx, query).Scan(&source); err != nil {
+		return "", handleSQLError(err, r.logger)
+	}
+	return source, nil
+}
+
+func (r *repository) SaveAuthzSchema(ctx context.Context, source string, now time.Time) error {
+	query := `
+				INSERT INTO authz_schemas
+					(source, updated_at)
+				VALUES
+					($1, $2)
+				ON CONFLICT (tenant_id) DO UPDATE SET
+					source = EXCLUDED.source,
+					updated_at = EXCLUDED.updated_at
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	if _, err := r.client.Exec(nCtx, query, source, now); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
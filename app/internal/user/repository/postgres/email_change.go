@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/model"
+	"Users/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+const emailChangeColumns = `
+						id, user_id, old_email, new_email, created_at, expires_at, confirmed_at, reverted_at
+`
+
+func scanEmailChange(row pgx.Row) (model.EmailChange, error) {
+	var change model.EmailChange
+	err := row.Scan(&change.ID, &change.UserUUID, &change.OldEmail, &change.NewEmail,
+		&change.CreatedAt, &change.ExpiresAt, &change.ConfirmedAt, &change.RevertedAt)
+	return change, err
+}
+
+// createEmailChange cancels the pending email changes of the user and stores the new one.
+func (r *repository) createEmailChange(ctx context.Context, tx pgx.Tx, change model.EmailChange) error {
+	query := `
+				WITH cancelled AS (
+					UPDATE email_changes SET
+						cancelled_at = $5
+					WHERE
+						user_id = $1 AND confirmed_at IS NULL AND reverted_at IS NULL AND cancelled_at IS NULL
+				)
+				INSERT INTO email_changes
+				    (user_id, old_email, new_email, confirm_token_hash, revert_token_hash, created_at, expires_at)
+				VALUES
+				    ($1, $2, $3, $4, $6, $5, $7)
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	_, err := tx.Exec(ctx, query, change.UserUUID, change.OldEmail, change.NewEmail,
+		model.HashToken(change.ConfirmToken), change.CreatedAt, model.HashToken(change.RevertToken),
+		change.ExpiresAt)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+func (r *repository) ConfirmEmailChange(ctx context.Context, confirmTokenHash []byte,
+	now time.Time) (model.EmailChange, error) {
+	lockQuery := `
+				SELECT` + emailChangeColumns + `
+				FROM
+					email_changes
+				WHERE
+					confirm_token_hash = $1 AND expires_at > $2
+					AND confirmed_at IS NULL AND reverted_at IS NULL AND cancelled_at IS NULL
+				FOR UPDATE
+	`
+	// the email is only switched if it has not been changed by other means meanwhile
+	userQuery := `
+				UPDATE users SET
+					email = $2,
+					version = version + 1,
+					updated_at = $4
+				WHERE
+					id = $1 AND lower(email) = lower($3) AND deleted_at IS NULL
+				RETURNING
+					` + userColumns + `
+	`
+	confirmQuery := `
+				UPDATE email_changes SET
+					confirmed_at = $2
+				WHERE
+					id = $1
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(userQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(confirmQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return model.EmailChange{}, handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback email change transaction: %v", rbErr)
+		}
+	}()
+
+	change, err := scanEmailChange(tx.QueryRow(nCtx, lockQuery, confirmTokenHash, now))
+	if err != nil {
+		return model.EmailChange{}, handleSQLError(err, r.logger)
+	}
+
+	before, err := r.lockUser(nCtx, tx, change.UserUUID)
+	if err != nil {
+		return model.EmailChange{}, err
+	}
+	user, err := scanUser(tx.QueryRow(nCtx, userQuery, change.UserUUID, change.NewEmail, change.OldEmail, now))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.EmailChange{}, apperror.ConflictError("user email has been changed since the change was requested")
+		}
+		return model.EmailChange{}, handleSQLError(err, r.logger)
+	}
+	if err = r.writeUserEvent(nCtx, tx, model.EventUserUpdated, user, now); err != nil {
+		return model.EmailChange{}, err
+	}
+	if err = r.writeUserEvent(nCtx, tx, model.EventEmailChanged, user, now); err != nil {
+		return model.EmailChange{}, err
+	}
+
+	if _, err = tx.Exec(nCtx, confirmQuery, change.ID, now); err != nil {
+		return model.EmailChange{}, handleSQLError(err, r.logger)
+	}
+	if err = r.writeAudit(nCtx, tx, model.AuditEmailChanged, change.UserUUID, before, &user, now); err != nil {
+		return model.EmailChange{}, err
+	}
+	if err = tx.Commit(nCtx); err != nil {
+		return model.EmailChange{}, handleSQLError(err, r.logger)
+	}
+	change.ConfirmedAt = &now
+	return change, nil
+}
+
+func (r *repository) RevertEmailChange(ctx context.Context, revertTokenHash []byte,
+	createdAfter, now time.Time) (model.EmailChange, error) {
+	lockQuery := `
+				SELECT` + emailChangeColumns + `
+				FROM
+					email_changes
+				WHERE
+					revert_token_hash = $1 AND created_at > $2
+					AND reverted_at IS NULL AND cancelled_at IS NULL
+				FOR UPDATE
+	`
+	// a confirmed change is undone only while the user still has the new email
+	userQuery := `
+				UPDATE users SET
+					email = $2,
+					version = version + 1,
+					updated_at = $4
+				WHERE
+					id = $1 AND lower(email) = lower($3) AND deleted_at IS NULL
+				RETURNING
+					` + userColumns + `
+	`
+	revertQuery := `
+				UPDATE email_changes SET
+					reverted_at = $2
+				WHERE
+					id = $1
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(userQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(revertQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return model.EmailChange{}, handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback email change transaction: %v", rbErr)
+		}
+	}()
+
+	change, err := scanEmailChange(tx.QueryRow(nCtx, lockQuery, revertTokenHash, createdAfter))
+	if err != nil {
+		return model.EmailChange{}, handleSQLError(err, r.logger)
+	}
+
+	// a pending change is only cancelled, the user stays as it is
+	before, err := r.lockUser(nCtx, tx, change.UserUUID)
+	if err != nil {
+		return model.EmailChange{}, err
+	}
+	after := before
+	if change.ConfirmedAt != nil {
+		user, err := scanUser(tx.QueryRow(nCtx, userQuery, change.UserUUID, change.OldEmail, change.NewEmail, now))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return model.EmailChange{}, apperror.ConflictError("user email has been changed again since the change")
+			}
+			return model.EmailChange{}, handleSQLError(err, r.logger)
+		}
+		if err = r.writeUserEvent(nCtx, tx, model.EventUserUpdated, user, now); err != nil {
+			return model.EmailChange{}, err
+		}
+		if err = r.writeUserEvent(nCtx, tx, model.EventEmailChanged, user, now); err != nil {
+			return model.EmailChange{}, err
+		}
+		after = &user
+	}
+
+	if _, err = tx.Exec(nCtx, revertQuery, change.ID, now); err != nil {
+		return model.EmailChange{}, handleSQLError(err, r.logger)
+	}
+	if err = r.writeAudit(nCtx, tx, model.AuditEmailReverted, change.UserUUID, before, after, now); err != nil {
+		return model.EmailChange{}, err
+	}
+	if err = tx.Commit(nCtx); err != nil {
+		return model.EmailChange{}, handleSQLError(err, r.logger)
+	}
+	change.RevertedAt = &now
+	return change, nil
+}
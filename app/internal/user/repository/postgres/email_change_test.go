@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"Users/internal/user/domain/model"
+	"Users/pkg/tenant"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUpdateOfTheEmailOnlyKeepsTheUser(t *testing.T) {
+	repository, pool := newTestRepository(t)
+	ctx := tenant.WithID(context.Background(), createTestTenant(t, pool))
+
+	now := time.Now().UTC()
+	uuid, err := repository.Create(ctx, model.User{
+		Name:      "Email Changer",
+		Email:     "changer-" + randomSuffix(t) + "@example.com",
+		Password:  "not-a-real-hash",
+		Status:    model.StatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	user, err := repository.FindByUUID(ctx, uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		change, err := model.NewEmailChange(user, "changed-"+randomSuffix(t)+"@example.com", now, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		update := user
+		update.UpdatedAt = time.Now().UTC()
+		if err = repository.Update(ctx, update, &change); err != nil {
+			t.Fatalf("update %d failed: %v", i+1, err)
+		}
+	}
+
+	found, err := repository.FindByUUID(ctx, uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Version != user.Version || found.Email != user.Email {
+		t.Errorf("user has version %d and email %s, want them unchanged at %d and %s",
+			found.Version, found.Email, user.Version, user.Email)
+	}
+
+	var pending int
+	err = pool.QueryRow(context.Background(), `
+		SELECT count(*) FROM email_changes
+		WHERE user_id = $1 AND confirmed_at IS NULL AND reverted_at IS NULL AND cancelled_at IS NULL`,
+		uuid).Scan(&pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending != 1 {
+		t.Errorf("%d pending email changes, want the last one only", pending)
+	}
+}
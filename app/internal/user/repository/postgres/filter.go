@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"Users/internal/user/domain/dto"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// userFilterClause builds a WHERE clause for the filter, numbering its placeholders from $1.
+// Soft deleted users are always excluded.
+func userFilterClause(filter dto.UserFilter) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := make([]interface{}, 0, 8)
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OrganizationUUID != "" {
+		add("EXISTS (SELECT 1 FROM memberships m WHERE m.user_id = users.id AND m.organization_id = $%d)",
+			filter.OrganizationUUID)
+	}
+	if filter.Name != "" {
+		add("name ILIKE $%d", "%"+escapeLike(filter.Name)+"%")
+	}
+	if filter.Email != "" {
+		add("email ILIKE $%d", "%"+escapeLike(filter.Email)+"%")
+	}
+	addRange := func(column string, after, before time.Time) {
+		if !after.IsZero() {
+			add(column+" > $%d", after)
+		}
+		if !before.IsZero() {
+			add(column+" < $%d", before)
+		}
+	}
+	addRange("created_at", filter.CreatedAfter, filter.CreatedBefore)
+	addRange("updated_at", filter.UpdatedAfter, filter.UpdatedBefore)
+	addRange("last_login_at", filter.LastLoginAfter, filter.LastLoginBefore)
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// auditFilterClause builds a WHERE clause for the filter, numbering its placeholders from $1.
+func auditFilterClause(filter dto.AuditFilter) (string, []interface{}) {
+	conditions := make([]string, 0, 6)
+	args := make([]interface{}, 0, 6)
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.TargetUUID != "" {
+		add("target_user_id = $%d", filter.TargetUUID)
+	}
+	if !filter.From.IsZero() {
+		add("occurred_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("occurred_at < $%d", filter.To)
+	}
+	if filter.Before != 0 {
+		add("id < $%d", filter.Before)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,342 @@
+package postgres
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/model"
+	"Users/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+const groupColumns = `
+						g.id, g.name, g.description, g.created_at, g.updated_at
+`
+
+func scanGroup(row pgx.Row, extra ...interface{}) (model.Group, error) {
+	var group model.Group
+	dest := append([]interface{}{&group.UUID, &group.Name, &group.Description, &group.CreatedAt,
+		&group.UpdatedAt}, extra...)
+	err := row.Scan(dest...)
+	return group, err
+}
+
+func (r *repository) CreateGroup(ctx context.Context, group model.Group) (string, error) {
+	query := `
+				INSERT INTO groups
+					(name, description, created_at, updated_at)
+				VALUES
+					($1, $2, $3, $4)
+				RETURNING id
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	var groupUUID string
+	err := r.client.QueryRow(nCtx, query, group.Name, group.Description, group.CreatedAt,
+		group.UpdatedAt).Scan(&groupUUID)
+	if err != nil {
+		return "", handleSQLError(err, r.logger)
+	}
+	return groupUUID, nil
+}
+
+func (r *repository) FindGroups(ctx context.Context) ([]model.Group, error) {
+	query := `
+				SELECT` + groupColumns + `
+				FROM
+					groups g
+				ORDER BY
+					lower(g.name)
+	`
+	return r.findGroups(ctx, query)
+}
+
+func (r *repository) FindGroup(ctx context.Context, uuid string) (model.Group, error) {
+	query := `
+				SELECT` + groupColumns + `
+				FROM
+					groups g
+				WHERE
+					g.id = $1
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	group, err := scanGroup(r.client.QueryRow(nCtx, query, uuid))
+	if err != nil {
+		return model.Group{}, handleSQLError(err, r.logger)
+	}
+	return group, nil
+}
+
+func (r *repository) FindSubgroups(ctx context.Context, uuid string) ([]model.Group, error) {
+	query := `
+				SELECT` + groupColumns + `
+				FROM
+					group_subgroups s
+				JOIN
+					groups g ON g.id = s.child_id
+				WHERE
+					s.parent_id = $1
+				ORDER BY
+					lower(g.name)
+	`
+	return r.findGroups(ctx, query, uuid)
+}
+
+func (r *repository) findGroups(ctx context.Context, query string, args ...interface{}) ([]model.Group, error) {
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, args...)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	groups := make([]model.Group, 0)
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return groups, nil
+}
+
+func (r *repository) UpdateGroup(ctx context.Context, group model.Group) error {
+	query := `
+				UPDATE groups SET
+					name = $2,
+					description = $3,
+					updated_at = $4
+				WHERE
+					id = $1
+	`
+	return r.execOne(ctx, query, group.UUID, group.Name, group.Description, group.UpdatedAt)
+}
+
+func (r *repository) DeleteGroup(ctx context.Context, uuid string) error {
+	query := `
+				DELETE FROM
+					groups
+				WHERE
+					id = $1
+	`
+	return r.execOne(ctx, query, uuid)
+}
+
+func (r *repository) AddGroupMember(ctx context.Context, groupUUID, userUUID string, now time.Time) error {
+	query := `
+				INSERT INTO group_members
+					(group_id, user_id, created_at)
+				VALUES
+					($1, $2, $3)
+				ON CONFLICT DO NOTHING
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	if _, err := r.client.Exec(nCtx, query, groupUUID, userUUID, now); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+func (r *repository) RemoveGroupMember(ctx context.Context, groupUUID, userUUID string) error {
+	query := `
+				DELETE FROM
+					group_members
+				WHERE
+					group_id = $1 AND user_id = $2
+	`
+	return r.execOne(ctx, query, groupUUID, userUUID)
+}
+
+// AddSubgroup nests the child group in the parent unless the parent is already among
+// the descendants of the child. Nesting changes are serialized by the table lock, so
+// that two concurrent changes cannot close a cycle together.
+func (r *repository) AddSubgroup(ctx context.Context, parentUUID, childUUID string, now time.Time) error {
+	lockQuery := `
+				LOCK TABLE group_subgroups IN SHARE ROW EXCLUSIVE MODE
+	`
+	cycleQuery := `
+				WITH RECURSIVE descendants (id) AS (
+					SELECT $2::uuid
+					UNION
+					SELECT s.child_id FROM group_subgroups s JOIN descendants d ON s.parent_id = d.id
+				)
+				SELECT EXISTS (SELECT 1 FROM descendants WHERE id = $1)
+	`
+	insertQuery := `
+				INSERT INTO group_subgroups
+					(parent_id, child_id, created_at)
+				VALUES
+					($1, $2, $3)
+				ON CONFLICT DO NOTHING
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(cycleQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(insertQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback subgroup transaction: %v", rbErr)
+		}
+	}()
+
+	if _, err = tx.Exec(nCtx, lockQuery); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	var cycle bool
+	if err = tx.QueryRow(nCtx, cycleQuery, parentUUID, childUUID).Scan(&cycle); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	if cycle {
+		return apperror.ConflictError("nesting the group would create a cycle")
+	}
+	if _, err = tx.Exec(nCtx, insertQuery, parentUUID, childUUID, now); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+
+	if err = tx.Commit(nCtx); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+func (r *repository) RemoveSubgroup(ctx context.Context, parentUUID, childUUID string) error {
+	query := `
+				DELETE FROM
+					group_subgroups
+				WHERE
+					parent_id = $1 AND child_id = $2
+	`
+	return r.execOne(ctx, query, parentUUID, childUUID)
+}
+
+// execOne runs a statement that must change a row, ErrNotFound is returned otherwise.
+func (r *repository) execOne(ctx context.Context, query string, args ...interface{}) error {
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	tag, err := r.client.Exec(nCtx, query, args...)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// FindUserGroups resolves the groups of the user: the groups it is a direct member of
+// and, transitively, every group those are nested in.
+func (r *repository) FindUserGroups(ctx context.Context, userUUID string) ([]model.EffectiveGroup, error) {
+	query := `
+				WITH RECURSIVE effective (id) AS (
+					SELECT group_id FROM group_members WHERE user_id = $1
+					UNION
+					SELECT s.parent_id FROM group_subgroups s JOIN effective e ON s.child_id = e.id
+				)
+				SELECT` + groupColumns + `,
+					EXISTS (SELECT 1 FROM group_members m WHERE m.group_id = g.id AND m.user_id = $1)
+				FROM
+					effective e
+				JOIN
+					groups g ON g.id = e.id
+				ORDER BY
+					lower(g.name)
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, userUUID)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	groups := make([]model.EffectiveGroup, 0)
+	for rows.Next() {
+		var direct bool
+		group, err := scanGroup(rows, &direct)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, model.EffectiveGroup{Group: group, Direct: direct})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return groups, nil
+}
+
+// FindGroupMembers resolves the not deleted members of the group and of all of its
+// subgroups, transitively.
+func (r *repository) FindGroupMembers(ctx context.Context, groupUUID string) ([]model.GroupMember, error) {
+	query := `
+				WITH RECURSIVE descendants (id) AS (
+					SELECT $1::uuid
+					UNION
+					SELECT s.child_id FROM group_subgroups s JOIN descendants d ON s.parent_id = d.id
+				)
+				SELECT
+					u.id, u.name, u.email, COALESCE(u.username, ''), bool_or(m.group_id = $1)
+				FROM
+					descendants d
+				JOIN
+					group_members m ON m.group_id = d.id
+				JOIN
+					users u ON u.id = m.user_id
+				WHERE
+					u.deleted_at IS NULL
+				GROUP BY
+					u.id
+				ORDER BY
+					u.name, u.id
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, groupUUID)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	members := make([]model.GroupMember, 0)
+	for rows.Next() {
+		var member model.GroupMember
+		err = rows.Scan(&member.UserUUID, &member.Name, &member.Email, &member.Username, &member.Direct)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return members, nil
+}
@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"Users/internal/user/domain/model"
+	"Users/internal/user/domain/service"
+	"Users/pkg/audit"
+	"Users/pkg/events"
+	"Users/pkg/logging"
+	"Users/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+type importTx struct {
+	tx     pgx.Tx
+	logger *logging.Logger
+	// audited are the entries of the imported users, appended to the audit log on commit
+	audited []model.AuditEntry
+}
+
+func (r *repository) BeginImport(ctx context.Context) (service.ImportTx, error) {
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return &importTx{
+		tx:     tx,
+		logger: r.logger,
+	}, nil
+}
+
+func (t *importTx) ExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	query := `
+				SELECT
+					email
+				FROM
+					users
+				WHERE
+					lower(email) = ANY($1) AND deleted_at IS NULL
+	`
+	t.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := t.tx.Query(nCtx, query, emails)
+	if err != nil {
+		return nil, handleSQLError(err, t.logger)
+	}
+	defer rows.Close()
+
+	existing := make([]string, 0)
+	for rows.Next() {
+		var email string
+		if err = rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		existing = append(existing, email)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, t.logger)
+	}
+	return existing, nil
+}
+
+// CopyUsers inserts the users with a single statement and adds their creation events
+// to the outbox. COPY cannot be used because it is not supported on tables with row
+// level security. Their audit entries are written by Commit.
+func (t *importTx) CopyUsers(ctx context.Context, users []model.User) (int64, error) {
+	query := `
+				INSERT INTO users
+					(name, email, password, status, created_at, updated_at, password_changed_at)
+				SELECT
+					*
+				FROM
+					unnest($1::text[], $2::text[], $3::text[], $4::text[],
+						$5::timestamptz[], $6::timestamptz[], $7::timestamptz[])
+				RETURNING
+					` + userColumns + `
+	`
+	t.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	names := make([]string, 0, len(users))
+	emails := make([]string, 0, len(users))
+	passwords := make([]string, 0, len(users))
+	statuses := make([]string, 0, len(users))
+	createdAt := make([]time.Time, 0, len(users))
+	updatedAt := make([]time.Time, 0, len(users))
+	passwordChangedAt := make([]*time.Time, 0, len(users))
+	for _, user := range users {
+		names = append(names, user.Name)
+		emails = append(emails, user.Email)
+		passwords = append(passwords, user.Password)
+		statuses = append(statuses, string(user.Status))
+		createdAt = append(createdAt, user.CreatedAt)
+		updatedAt = append(updatedAt, user.UpdatedAt)
+		passwordChangedAt = append(passwordChangedAt, user.PasswordChangedAt)
+	}
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := t.tx.Query(nCtx, query, names, emails, passwords, statuses, createdAt, updatedAt, passwordChangedAt)
+	if err != nil {
+		return 0, handleSQLError(err, t.logger)
+	}
+	source := audit.FromContext(ctx)
+	created := make([]events.Event, 0, len(users))
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			rows.Close()
+			return 0, handleSQLError(err, t.logger)
+		}
+		event, err := model.NewUserEvent(model.EventUserCreated, user, user.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		entry, err := model.NewAuditEntry(source, model.AuditUserImported, user.UUID, nil, &user, user.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		created = append(created, event)
+		t.audited = append(t.audited, entry)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, handleSQLError(err, t.logger)
+	}
+
+	if err = writeEvents(nCtx, t.tx, t.logger, created); err != nil {
+		return 0, err
+	}
+	return int64(len(created)), nil
+}
+
+func (t *importTx) Commit(ctx context.Context) error {
+	if len(t.audited) > 0 {
+		if err := appendAuditEntries(ctx, t.tx, t.logger, t.audited); err != nil {
+			return err
+		}
+	}
+	if err := t.tx.Commit(ctx); err != nil {
+		return handleSQLError(err, t.logger)
+	}
+	return nil
+}
+
+func (t *importTx) Rollback(ctx context.Context) error {
+	err := t.tx.Rollback(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		return handleSQLError(err, t.logger)
+	}
+	return nil
+}
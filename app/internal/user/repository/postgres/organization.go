@@ -0,0 +1,447 @@
+package postgres
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/model"
+	"Users/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+func (r *repository) CreateOrganization(ctx context.Context, organization model.Organization,
+	owner model.Membership) (model.Organization, error) {
+	organizationQuery := `
+				INSERT INTO organizations
+					(name, created_at, updated_at)
+				VALUES
+					($1, $2, $3)
+				RETURNING id
+	`
+	membershipQuery := `
+				INSERT INTO memberships
+					(organization_id, user_id, role, created_at, updated_at)
+				VALUES
+					($1, $2, $3, $4, $5)
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(organizationQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(membershipQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return model.Organization{}, handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback organization transaction: %v", rbErr)
+		}
+	}()
+
+	err = tx.QueryRow(nCtx, organizationQuery, organization.Name, organization.CreatedAt,
+		organization.UpdatedAt).Scan(&organization.UUID)
+	if err != nil {
+		return model.Organization{}, handleSQLError(err, r.logger)
+	}
+	_, err = tx.Exec(nCtx, membershipQuery, organization.UUID, owner.UserUUID, owner.Role,
+		owner.CreatedAt, owner.UpdatedAt)
+	if err != nil {
+		return model.Organization{}, handleSQLError(err, r.logger)
+	}
+
+	if err = tx.Commit(nCtx); err != nil {
+		return model.Organization{}, handleSQLError(err, r.logger)
+	}
+	return organization, nil
+}
+
+func (r *repository) FindOrganization(ctx context.Context, uuid string) (model.Organization, error) {
+	query := `
+				SELECT
+					id, name, created_at, updated_at
+				FROM
+					organizations
+				WHERE
+					id = $1
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	var organization model.Organization
+	err := r.client.QueryRow(nCtx, query, uuid).Scan(&organization.UUID, &organization.Name,
+		&organization.CreatedAt, &organization.UpdatedAt)
+	if err != nil {
+		return model.Organization{}, handleSQLError(err, r.logger)
+	}
+	return organization, nil
+}
+
+func (r *repository) FindMembership(ctx context.Context, organizationUUID, userUUID string) (model.Membership, error) {
+	query := `
+				SELECT
+					m.organization_id, o.name, m.user_id, m.role, m.created_at, m.updated_at
+				FROM
+					memberships m
+				JOIN
+					organizations o ON o.id = m.organization_id
+				WHERE
+					m.organization_id = $1 AND m.user_id = $2
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	membership, err := scanMembership(r.client.QueryRow(nCtx, query, organizationUUID, userUUID))
+	if err != nil {
+		return model.Membership{}, handleSQLError(err, r.logger)
+	}
+	return membership, nil
+}
+
+func (r *repository) FindMemberships(ctx context.Context, userUUID string) ([]model.Membership, error) {
+	query := `
+				SELECT
+					m.organization_id, o.name, m.user_id, m.role, m.created_at, m.updated_at
+				FROM
+					memberships m
+				JOIN
+					organizations o ON o.id = m.organization_id
+				WHERE
+					m.user_id = $1
+				ORDER BY
+					o.name, o.id
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, userUUID)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	memberships := make([]model.Membership, 0)
+	for rows.Next() {
+		membership, err := scanMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return memberships, nil
+}
+
+func scanMembership(row pgx.Row) (model.Membership, error) {
+	var membership model.Membership
+	err := row.Scan(&membership.OrganizationUUID, &membership.OrganizationName, &membership.UserUUID,
+		&membership.Role, &membership.CreatedAt, &membership.UpdatedAt)
+	return membership, err
+}
+
+func (r *repository) FindMembers(ctx context.Context, organizationUUID string) ([]model.Member, error) {
+	query := `
+				SELECT
+					u.id, u.name, u.email, COALESCE(u.username, ''), m.role, m.created_at
+				FROM
+					memberships m
+				JOIN
+					users u ON u.id = m.user_id
+				WHERE
+					m.organization_id = $1 AND u.deleted_at IS NULL
+				ORDER BY
+					m.created_at, u.id
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, organizationUUID)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	members := make([]model.Member, 0)
+	for rows.Next() {
+		var member model.Member
+		err = rows.Scan(&member.UserUUID, &member.Name, &member.Email, &member.Username, &member.Role,
+			&member.JoinedAt)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return members, nil
+}
+
+func (r *repository) UpdateMemberRole(ctx context.Context, organizationUUID, actorUUID, userUUID string,
+	role model.Role, now time.Time, authorize func(actor, member model.Role) error) error {
+	query := `
+				UPDATE
+					memberships
+				SET
+					role = $3, updated_at = $4
+				WHERE
+					organization_id = $1 AND user_id = $2
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	return r.changeMembership(ctx, organizationUUID, actorUUID, userUUID, role != model.RoleOwner, authorize, query,
+		role, now)
+}
+
+func (r *repository) DeleteMember(ctx context.Context, organizationUUID, actorUUID, userUUID string,
+	authorize func(actor, member model.Role) error) error {
+	query := `
+				DELETE FROM
+					memberships
+				WHERE
+					organization_id = $1 AND user_id = $2
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	return r.changeMembership(ctx, organizationUUID, actorUUID, userUUID, true, authorize, query)
+}
+
+// changeMembership runs the query against the membership of the user once authorize
+// accepts the roles of the acting member, empty if it is none, and of the user. The
+// organization row is locked first, so that two owners demoting or removing each other
+// concurrently cannot leave the organization without any owner, and that the role of
+// the acting member cannot change before the query runs.
+func (r *repository) changeMembership(ctx context.Context, organizationUUID, actorUUID, userUUID string,
+	dropsOwner bool, authorize func(actor, member model.Role) error, query string, args ...interface{}) error {
+	lockQuery := `
+				SELECT
+					m.role,
+					(SELECT count(*) FROM memberships WHERE organization_id = $1 AND role = 'owner'),
+					(SELECT role FROM memberships WHERE organization_id = $1 AND user_id = $3)
+				FROM
+					organizations o
+				JOIN
+					memberships m ON m.organization_id = o.id
+				WHERE
+					o.id = $1 AND m.user_id = $2
+				FOR UPDATE OF o
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback membership transaction: %v", rbErr)
+		}
+	}()
+
+	var role model.Role
+	var owners int
+	var actor *model.Role
+	err = tx.QueryRow(nCtx, lockQuery, organizationUUID, userUUID, actorUUID).Scan(&role, &owners, &actor)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	var actorRole model.Role
+	if actor != nil {
+		actorRole = *actor
+	}
+	if err = authorize(actorRole, role); err != nil {
+		return err
+	}
+	if dropsOwner && role == model.RoleOwner && owners <= 1 {
+		return apperror.ConflictError("organization must keep at least one owner")
+	}
+
+	_, err = tx.Exec(nCtx, query, append([]interface{}{organizationUUID, userUUID}, args...)...)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	if err = tx.Commit(nCtx); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+const invitationColumns = `
+						id, organization_id, email, role, invited_by, created_at, expires_at, accepted_at
+`
+
+func scanInvitation(row pgx.Row) (model.Invitation, error) {
+	var invitation model.Invitation
+	var invitedBy *string
+	err := row.Scan(&invitation.ID, &invitation.OrganizationUUID, &invitation.Email, &invitation.Role,
+		&invitedBy, &invitation.CreatedAt, &invitation.ExpiresAt, &invitation.AcceptedAt)
+	if invitedBy != nil {
+		invitation.InvitedBy = *invitedBy
+	}
+	return invitation, err
+}
+
+// CreateInvitation stores the invitation and revokes the pending ones of the same
+// email address, so that only the latest mailed token can be accepted.
+func (r *repository) CreateInvitation(ctx context.Context, invitation model.Invitation) (model.Invitation, error) {
+	query := `
+				WITH revoked AS (
+					UPDATE invitations SET
+						revoked_at = $6
+					WHERE
+						organization_id = $1 AND lower(email) = lower($2)
+						AND accepted_at IS NULL AND revoked_at IS NULL
+				)
+				INSERT INTO invitations
+				    (organization_id, email, role, token_hash, invited_by, created_at, expires_at)
+				VALUES
+				    ($1, $2, $3, $4, NULLIF($5, '')::uuid, $6, $7)
+				RETURNING
+					id
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	err := r.client.QueryRow(nCtx, query, invitation.OrganizationUUID, invitation.Email, invitation.Role,
+		model.HashToken(invitation.Token), invitation.InvitedBy, invitation.CreatedAt,
+		invitation.ExpiresAt).Scan(&invitation.ID)
+	if err != nil {
+		return model.Invitation{}, handleSQLError(err, r.logger)
+	}
+	return invitation, nil
+}
+
+// FindInvitations returns the invitations of the organization that can still be accepted.
+func (r *repository) FindInvitations(ctx context.Context, organizationUUID string,
+	now time.Time) ([]model.Invitation, error) {
+	query := `
+				SELECT` + invitationColumns + `
+				FROM
+					invitations
+				WHERE
+					organization_id = $1 AND expires_at > $2 AND accepted_at IS NULL AND revoked_at IS NULL
+				ORDER BY
+					created_at, id
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, organizationUUID, now)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	invitations := make([]model.Invitation, 0)
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return invitations, nil
+}
+
+func (r *repository) RevokeInvitation(ctx context.Context, organizationUUID, id string, now time.Time) error {
+	query := `
+				UPDATE invitations SET
+					revoked_at = $3
+				WHERE
+					organization_id = $1 AND id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	tag, err := r.client.Exec(nCtx, query, organizationUUID, id, now)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+// AcceptInvitation locks the pending invitation with the token hash and lets accept turn
+// it into a membership, which is stored together with the acceptance of the invitation.
+func (r *repository) AcceptInvitation(ctx context.Context, tokenHash []byte, now time.Time,
+	accept func(invitation model.Invitation) (model.Membership, error)) (model.Membership, error) {
+	lockQuery := `
+				SELECT` + invitationColumns + `
+				FROM
+					invitations
+				WHERE
+					token_hash = $1 AND expires_at > $2 AND accepted_at IS NULL AND revoked_at IS NULL
+				FOR UPDATE
+	`
+	membershipQuery := `
+				INSERT INTO memberships
+					(organization_id, user_id, role, created_at, updated_at)
+				VALUES
+					($1, $2, $3, $4, $5)
+	`
+	acceptQuery := `
+				UPDATE invitations SET
+					accepted_at = $2,
+					accepted_by = $3
+				WHERE
+					id = $1
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(membershipQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(acceptQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return model.Membership{}, handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback invitation transaction: %v", rbErr)
+		}
+	}()
+
+	invitation, err := scanInvitation(tx.QueryRow(nCtx, lockQuery, tokenHash, now))
+	if err != nil {
+		return model.Membership{}, handleSQLError(err, r.logger)
+	}
+	membership, err := accept(invitation)
+	if err != nil {
+		return model.Membership{}, err
+	}
+
+	_, err = tx.Exec(nCtx, membershipQuery, membership.OrganizationUUID, membership.UserUUID, membership.Role,
+		membership.CreatedAt, membership.UpdatedAt)
+	if err != nil {
+		return model.Membership{}, handleSQLError(err, r.logger)
+	}
+	if _, err = tx.Exec(nCtx, acceptQuery, invitation.ID, now, membership.UserUUID); err != nil {
+		return model.Membership{}, handleSQLError(err, r.logger)
+	}
+	if err = tx.Commit(nCtx); err != nil {
+		return model.Membership{}, handleSQLError(err, r.logger)
+	}
+	return membership, nil
+}
@@ -0,0 +1,246 @@
+package postgres
+
+import (
+	"Users/internal/user/domain/model"
+	"Users/pkg/events"
+	"Users/pkg/logging"
+	"Users/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+// relayWaitTime bounds the transaction holding the relay lock of a tenant while its events
+// are published.
+const relayWaitTime = 30 * time.Second
+
+// EventsChannel is notified with the tenant of every event written to the outbox,
+// see migrations/019_outbox_notify.sql.
+const EventsChannel = "outbox_events"
+
+// writeEvents adds the events to the outbox in the transaction of the change they describe
+// and queues their deliveries to the webhooks subscribed to them. The events get their
+// positions when the transaction commits, see migrations/022_outbox_commit_order.sql.
+func writeEvents(ctx context.Context, tx pgx.Tx, logger *logging.Logger, batch []events.Event) error {
+	query := `
+				WITH inserted AS (
+					INSERT INTO outbox_events
+						(type, user_id, payload, occurred_at)
+					SELECT
+						*
+					FROM
+						unnest($1::text[], $2::text[]::uuid[], $3::text[]::jsonb[], $4::timestamptz[])
+					RETURNING
+						id, type, user_id, payload, occurred_at
+				)
+				INSERT INTO webhook_deliveries
+					(subscription_id, event_id, event_type, user_id, payload, occurred_at, next_attempt_at)
+				SELECT
+					s.id, e.id, e.type, e.user_id, e.payload, e.occurred_at, now()
+				FROM
+					inserted e
+				JOIN
+					webhook_subscriptions s ON s.active AND (cardinality(s.events) = 0 OR e.type = ANY(s.events))
+	`
+	logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	types := make([]string, 0, len(batch))
+	userUUIDs := make([]string, 0, len(batch))
+	payloads := make([]string, 0, len(batch))
+	occurredAt := make([]time.Time, 0, len(batch))
+	for _, event := range batch {
+		types = append(types, event.Type)
+		userUUIDs = append(userUUIDs, event.UserUUID)
+		payloads = append(payloads, string(event.Payload))
+		occurredAt = append(occurredAt, event.OccurredAt)
+	}
+
+	if _, err := tx.Exec(ctx, query, types, userUUIDs, payloads, occurredAt); err != nil {
+		return handleSQLError(err, logger)
+	}
+	return nil
+}
+
+// writeUserEvent adds a single event about the user to the outbox.
+func (r *repository) writeUserEvent(ctx context.Context, tx pgx.Tx, eventType string, user model.User,
+	at time.Time) error {
+	event, err := model.NewUserEvent(eventType, user, at)
+	if err != nil {
+		return err
+	}
+	return writeEvents(ctx, tx, r.logger, []events.Event{event})
+}
+
+func (r *repository) RelayEvents(ctx context.Context, limit int, now time.Time,
+	publish func(batch []events.Event) (int, error)) (int, error) {
+	// one relay publishes the events of a tenant at a time, the others skip the tenant
+	// instead of waiting for it. The key differs from the one of the positions, see
+	// migrations/022_outbox_commit_order.sql, so that writers never wait on a relay.
+	lockQuery := `
+				SELECT
+					pg_try_advisory_xact_lock(hashtextextended('outbox_relay:' || current_setting('app.tenant_id'), 0))
+	`
+	selectQuery := `
+				SELECT
+					id, position, tenant_id, type, user_id, payload::text, occurred_at
+				FROM
+					outbox_events
+				WHERE
+					published_at IS NULL AND position IS NOT NULL
+				ORDER BY
+					position
+				LIMIT $1
+	`
+	markQuery := `
+				UPDATE
+					outbox_events
+				SET
+					published_at = $2
+				WHERE
+					id = ANY($1)
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(selectQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(markQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, relayWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback relay transaction: %v", rbErr)
+		}
+	}()
+
+	var locked bool
+	if err = tx.QueryRow(nCtx, lockQuery).Scan(&locked); err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+	if !locked {
+		r.logger.Debug("another relay is publishing the events of the tenant")
+		return 0, nil
+	}
+
+	rows, err := tx.Query(nCtx, selectQuery, limit)
+	if err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+	batch := make([]events.Event, 0)
+	for rows.Next() {
+		var event events.Event
+		var payload string
+		if err = rows.Scan(&event.ID, &event.Position, &event.TenantID, &event.Type, &event.UserUUID, &payload,
+			&event.OccurredAt); err != nil {
+			rows.Close()
+			return 0, handleSQLError(err, r.logger)
+		}
+		event.Payload = []byte(payload)
+		batch = append(batch, event)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	published, publishErr := publish(batch)
+	if published > 0 {
+		ids := make([]int64, 0, published)
+		for _, event := range batch[:published] {
+			ids = append(ids, event.ID)
+		}
+		if _, err = tx.Exec(nCtx, markQuery, ids, now); err != nil {
+			return 0, handleSQLError(err, r.logger)
+		}
+		if err = tx.Commit(nCtx); err != nil {
+			return 0, handleSQLError(err, r.logger)
+		}
+	}
+	return published, publishErr
+}
+
+func (r *repository) DeletePublishedEvents(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	query := `
+				DELETE
+				FROM
+					outbox_events
+				WHERE
+					published_at < $1
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	cmdTag, err := r.client.Exec(nCtx, query, publishedBefore)
+	if err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+func (r *repository) FindLastEventPosition(ctx context.Context) (int64, error) {
+	query := `
+				SELECT
+					COALESCE(max(position), 0)
+				FROM
+					outbox_events
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	var position int64
+	if err := r.client.QueryRow(nCtx, query).Scan(&position); err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+	return position, nil
+}
+
+func (r *repository) FindEventsAfter(ctx context.Context, afterPosition int64, limit int) ([]events.Event, error) {
+	query := `
+				SELECT
+					id, position, tenant_id, type, user_id, payload::text, occurred_at
+				FROM
+					outbox_events
+				WHERE
+					position > $1
+				ORDER BY
+					position
+				LIMIT $2
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	rows, err := r.client.Query(nCtx, query, afterPosition, limit)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	batch := make([]events.Event, 0)
+	for rows.Next() {
+		var event events.Event
+		var payload string
+		if err = rows.Scan(&event.ID, &event.Position, &event.TenantID, &event.Type, &event.UserUUID, &payload,
+			&event.OccurredAt); err != nil {
+			return nil, handleSQLError(err, r.logger)
+		}
+		event.Payload = []byte(payload)
+		batch = append(batch, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return batch, nil
+}
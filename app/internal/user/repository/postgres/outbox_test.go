@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"Users/internal/user/domain/model"
+	"Users/pkg/events"
+	"Users/pkg/postgresql"
+	"Users/pkg/tenant"
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v4"
+	"testing"
+	"time"
+)
+
+func TestEventsFollowCommitOrder(t *testing.T) {
+	outbox, pool := newTestRepository(t)
+	logger := outbox.(*repository).logger
+	client := postgresql.NewTenantClient(pool)
+	tenantCtx := tenant.WithID(context.Background(), createTestTenant(t, pool))
+	// a writer waiting on the other for its whole transaction would run into the timeout
+	ctx, cancel := context.WithTimeout(tenantCtx, 10*time.Second)
+	defer cancel()
+
+	before, err := outbox.FindLastEventPosition(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(userUUID string) func() error {
+		tx, err := client.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = tx.Rollback(context.Background()) })
+		event := events.Event{
+			Type:       model.EventUserUpdated,
+			UserUUID:   userUUID,
+			Payload:    json.RawMessage(`{}`),
+			OccurredAt: time.Now().UTC(),
+		}
+		if err = writeEvents(ctx, tx, logger, []events.Event{event}); err != nil {
+			t.Fatalf("failed to write event: %v", err)
+		}
+		return func() error { return tx.Commit(ctx) }
+	}
+
+	firstUUID := "00000000-0000-4000-8000-" + randomSuffix(t)
+	secondUUID := "00000000-0000-4000-8000-" + randomSuffix(t)
+	commitFirst := write(firstUUID)
+	commitSecond := write(secondUUID)
+	if err = commitSecond(); err != nil {
+		t.Fatalf("the second writer failed to commit while the first is open: %v", err)
+	}
+
+	batch, err := outbox.FindEventsAfter(ctx, before, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 1 || batch[0].UserUUID != secondUUID {
+		t.Fatalf("got %d events before the first writer committed, want the second one only", len(batch))
+	}
+
+	if err = commitFirst(); err != nil {
+		t.Fatal(err)
+	}
+	batch, err = outbox.FindEventsAfter(ctx, before, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch) != 2 || batch[0].UserUUID != secondUUID || batch[1].UserUUID != firstUUID {
+		t.Fatalf("got events %+v, want the second then the first in commit order", batch)
+	}
+	if batch[1].ID > batch[0].ID || batch[1].Position < batch[0].Position {
+		t.Errorf("the first writer has id %d at position %d, the second id %d at position %d",
+			batch[1].ID, batch[1].Position, batch[0].ID, batch[0].Position)
+	}
+
+	last, err := outbox.FindLastEventPosition(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != batch[1].Position {
+		t.Errorf("last position %d, want %d", last, batch[1].Position)
+	}
+}
+
+func TestRelayPublishesInCommitOrderOneRelayAtATime(t *testing.T) {
+	outbox, pool := newTestRepository(t)
+	logger := outbox.(*repository).logger
+	client := postgresql.NewTenantClient(pool)
+	tenantCtx := tenant.WithID(context.Background(), createTestTenant(t, pool))
+	// a relay waiting on the other would run into the timeout
+	ctx, cancel := context.WithTimeout(tenantCtx, 10*time.Second)
+	defer cancel()
+
+	begin := func(userUUID string) pgx.Tx {
+		tx, err := client.Begin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = tx.Rollback(context.Background()) })
+		event := events.Event{
+			Type:       model.EventUserUpdated,
+			UserUUID:   userUUID,
+			Payload:    json.RawMessage(`{}`),
+			OccurredAt: time.Now().UTC(),
+		}
+		if err = writeEvents(ctx, tx, logger, []events.Event{event}); err != nil {
+			t.Fatalf("failed to write event: %v", err)
+		}
+		return tx
+	}
+	firstUUID := "00000000-0000-4000-8000-" + randomSuffix(t)
+	secondUUID := "00000000-0000-4000-8000-" + randomSuffix(t)
+	first := begin(firstUUID)
+	second := begin(secondUUID)
+	if err := second.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	publishing := make(chan []events.Event)
+	done := make(chan struct{})
+	relayed := make(chan error)
+	go func() {
+		_, err := outbox.RelayEvents(ctx, 10, time.Now().UTC(), func(batch []events.Event) (int, error) {
+			publishing <- batch
+			<-done
+			return len(batch), nil
+		})
+		relayed <- err
+	}()
+
+	var batch []events.Event
+	select {
+	case batch = <-publishing:
+	case err := <-relayed:
+		t.Fatalf("first relay returned before publishing: %v", err)
+	}
+	published, err := outbox.RelayEvents(ctx, 10, time.Now().UTC(), func(batch []events.Event) (int, error) {
+		t.Errorf("second relay got %d events while the first is publishing", len(batch))
+		return 0, nil
+	})
+	if err != nil || published != 0 {
+		t.Errorf("second relay published %d events with error %v, want it to skip the tenant", published, err)
+	}
+	close(done)
+	if err = <-relayed; err != nil {
+		t.Fatalf("first relay failed: %v", err)
+	}
+
+	if len(batch) != 2 || batch[0].UserUUID != secondUUID || batch[1].UserUUID != firstUUID {
+		t.Fatalf("relayed events %+v, want the second then the first in commit order", batch)
+	}
+
+	published, err = outbox.RelayEvents(ctx, 10, time.Now().UTC(), func(batch []events.Event) (int, error) {
+		return len(batch), nil
+	})
+	if err != nil || published != 0 {
+		t.Errorf("relayed %d events again with error %v, want them marked published", published, err)
+	}
+}
@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"Users/internal/apperror"
+	"Users/internal/user/domain/model"
+	"Users/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+func (r *repository) FindByPhone(ctx context.Context, phone string) (model.User, error) {
+	query := `
+				SELECT
+					` + userColumns + `
+				FROM
+					users
+				WHERE
+					phone = $1 AND phone_verified_at IS NOT NULL AND deleted_at IS NULL
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	usr, err := scanUser(r.client.QueryRow(nCtx, query, phone))
+	if err != nil {
+		return model.User{}, handleSQLError(err, r.logger)
+	}
+	return usr, nil
+}
+
+func (r *repository) UpdatePhone(ctx context.Context, user model.User) error {
+	query := `
+				UPDATE
+					users
+				SET
+					phone = NULLIF($1, ''), phone_verified_at = $2, phone_second_factor = $3, updated_at = $4,
+					version = version + 1
+				WHERE
+					id = $5 AND version = $6 AND deleted_at IS NULL
+				RETURNING
+					` + userColumns + `
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback phone update transaction: %v", rbErr)
+		}
+	}()
+
+	before, err := r.lockUser(nCtx, tx, user.UUID)
+	if err != nil {
+		return err
+	}
+	updated, err := scanUser(tx.QueryRow(nCtx, query, user.Phone, user.PhoneVerifiedAt, user.PhoneSecondFactor,
+		user.UpdatedAt, user.UUID, user.Version))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.PreconditionFailedError("user has been changed or deleted concurrently")
+		}
+		return handleSQLError(err, r.logger)
+	}
+	if err = r.writeUserEvent(nCtx, tx, model.EventUserUpdated, updated, updated.UpdatedAt); err != nil {
+		return err
+	}
+	if err = r.writeAudit(nCtx, tx, model.AuditPhoneChanged, user.UUID, before, &updated, updated.UpdatedAt); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(nCtx); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+func (r *repository) CreatePhoneCode(ctx context.Context, code model.PhoneCode) error {
+	query := `
+				INSERT INTO phone_codes
+					(user_id, phone, purpose, code_hash, created_at, expires_at)
+				VALUES
+					($1, $2, $3, $4, $5, $6)
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	_, err := r.client.Exec(nCtx, query, code.UserUUID, code.Phone, code.Purpose, code.CodeHash,
+		code.CreatedAt, code.ExpiresAt)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+func (r *repository) PhoneCodeStats(ctx context.Context, userUUID, phone string,
+	since time.Time) (int, *time.Time, error) {
+	query := `
+				SELECT
+					count(*), max(created_at)
+				FROM
+					phone_codes
+				WHERE
+					(user_id = $1 OR phone = $2) AND created_at > $3
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	var sent int
+	var lastSentAt *time.Time
+	err := r.client.QueryRow(nCtx, query, userUUID, phone, since).Scan(&sent, &lastSentAt)
+	if err != nil {
+		return 0, nil, handleSQLError(err, r.logger)
+	}
+	return sent, lastSentAt, nil
+}
+
+func (r *repository) VerifyPhoneCode(ctx context.Context, userUUID, purpose string,
+	check func(code model.PhoneCode) error) error {
+	lockQuery := `
+				SELECT
+					id, user_id, phone, purpose, code_hash, attempts, created_at, expires_at
+				FROM
+					phone_codes
+				WHERE
+					user_id = $1 AND purpose = $2 AND consumed_at IS NULL
+				ORDER BY
+					created_at DESC
+				LIMIT 1
+				FOR UPDATE
+	`
+	attemptQuery := `
+				UPDATE phone_codes SET
+					attempts = attempts + 1
+				WHERE
+					id = $1
+	`
+	consumeQuery := `
+				UPDATE phone_codes SET
+					consumed_at = now()
+				WHERE
+					id = $1
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(attemptQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(consumeQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback phone code transaction: %v", rbErr)
+		}
+	}()
+
+	var code model.PhoneCode
+	err = tx.QueryRow(nCtx, lockQuery, userUUID, purpose).Scan(&code.ID, &code.UserUUID, &code.Phone,
+		&code.Purpose, &code.CodeHash, &code.Attempts, &code.CreatedAt, &code.ExpiresAt)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+
+	// a failed check is counted even though the check error is returned
+	if checkErr := check(code); checkErr != nil {
+		if _, err = tx.Exec(nCtx, attemptQuery, code.ID); err != nil {
+			return handleSQLError(err, r.logger)
+		}
+		if err = tx.Commit(nCtx); err != nil {
+			return handleSQLError(err, r.logger)
+		}
+		return checkErr
+	}
+
+	if _, err = tx.Exec(nCtx, consumeQuery, code.ID); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	if err = tx.Commit(nCtx); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
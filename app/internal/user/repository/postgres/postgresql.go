@@ -2,8 +2,11 @@ package postgres
 
 import (
 	"Users/internal/apperror"
+	"Users/internal/user/domain/dto"
 	"Users/internal/user/domain/model"
 	"Users/internal/user/domain/service"
+	"Users/pkg/audit"
+	"Users/pkg/events"
 	"Users/pkg/logging"
 	"Users/pkg/postgresql"
 	"Users/pkg/utils"
@@ -12,11 +15,27 @@ import (
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"strings"
 	"time"
 )
 
 const queryWaitTime = 5 * time.Second
 
+// usernameConstraint and phoneConstraint are the unique indexes of usernames and
+// verified phones among not deleted users, membershipConstraint is the primary key
+// of memberships and groupNameConstraint the unique index of group names.
+const (
+	usernameConstraint   = "users_username_active_key"
+	phoneConstraint      = "users_phone_active_key"
+	membershipConstraint = "memberships_pkey"
+	groupNameConstraint  = "groups_name_key"
+)
+
+// userColumns are selected by every user query in the order scanUser expects.
+const userColumns = `id, name, email, COALESCE(username, ''), COALESCE(phone, ''), password, status,
+					status_reason, version, phone_second_factor,
+					created_at, updated_at, last_login_at, password_changed_at, phone_verified_at`
+
 type repository struct {
 	client postgresql.Client
 	logger *logging.Logger
@@ -41,9 +60,23 @@ func handleSQLError(err error, logger *logging.Logger) error {
 		logger.Error(newErr)
 
 		if pgErr.Code == "23505" { //uniqueness violation
+			switch pgErr.ConstraintName {
+			case usernameConstraint:
+				return apperror.BadRequestError("User with this username already exists")
+			case phoneConstraint:
+				return apperror.BadRequestError("User with this phone already exists")
+			case membershipConstraint:
+				return apperror.ConflictError("User is already a member of the organization")
+			case groupNameConstraint:
+				return apperror.ConflictError("Group with this name already exists")
+			}
 			return apperror.BadRequestError("User with this email already exists")
 		} else if pgErr.Code == "22P02" { //invalid uuid syntax
 			return apperror.ErrNotFound
+		} else if pgErr.Code == "42501" { //row of another tenant referenced, see migration 014
+			return apperror.ErrNotFound
+		} else if pgErr.Code == "23503" && strings.HasSuffix(pgErr.ConstraintName, "_tenant_id_fkey") {
+			return apperror.BadRequestError("unknown tenant")
 		}
 		return newErr
 	}
@@ -51,39 +84,94 @@ func handleSQLError(err error, logger *logging.Logger) error {
 	return err
 }
 
+func scanUser(row pgx.Row) (model.User, error) {
+	var usr model.User
+	err := row.Scan(&usr.UUID, &usr.Name, &usr.Email, &usr.Username, &usr.Phone, &usr.Password, &usr.Status,
+		&usr.StatusReason, &usr.Version, &usr.PhoneSecondFactor,
+		&usr.CreatedAt, &usr.UpdatedAt, &usr.LastLoginAt, &usr.PasswordChangedAt, &usr.PhoneVerifiedAt)
+	return usr, err
+}
+
+// lockUser locks the user for the rest of the transaction and returns it as it is before
+// the change, for the audit log. Nil is returned if there is no such user.
+func (r *repository) lockUser(ctx context.Context, tx pgx.Tx, uuid string) (*model.User, error) {
+	query := `
+				SELECT
+					` + userColumns + `
+				FROM
+					users
+				WHERE
+					id = $1
+				FOR UPDATE
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	user, err := scanUser(tx.QueryRow(ctx, query, uuid))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, handleSQLError(err, r.logger)
+	}
+	return &user, nil
+}
+
 func (r *repository) Create(ctx context.Context, user model.User) (string, error) {
 	query := `
 				INSERT INTO users
-					(name, email, password)
+					(name, email, username, password, status, created_at, updated_at, password_changed_at)
 				VALUES
-					($1, $2, $3)
-				RETURNING id;
+					($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8)
+				RETURNING
+					` + userColumns + `
 	`
 	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
 
-	var userUUID string
 	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
 	defer cancel()
-	err := r.client.QueryRow(nCtx, query, user.Name, user.Email, user.Password).Scan(&userUUID)
+
+	tx, err := r.client.Begin(nCtx)
 	if err != nil {
 		return "", handleSQLError(err, r.logger)
 	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback create transaction: %v", rbErr)
+		}
+	}()
 
-	return userUUID, nil
+	created, err := scanUser(tx.QueryRow(nCtx, query, user.Name, user.Email, user.Username, user.Password,
+		user.Status, user.CreatedAt, user.UpdatedAt, user.PasswordChangedAt))
+	if err != nil {
+		return "", handleSQLError(err, r.logger)
+	}
+	if err = r.writeUserEvent(nCtx, tx, model.EventUserCreated, created, created.CreatedAt); err != nil {
+		return "", err
+	}
+	if err = r.writeAudit(nCtx, tx, model.AuditUserCreated, created.UUID, nil, &created, created.CreatedAt); err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(nCtx); err != nil {
+		return "", handleSQLError(err, r.logger)
+	}
+	return created.UUID, nil
 }
 
-func (r *repository) FindAll(ctx context.Context) ([]model.User, error) {
+func (r *repository) FindAll(ctx context.Context, filter dto.UserFilter) ([]model.User, error) {
+	where, args := userFilterClause(filter)
 	query := `
 				SELECT
-					id, name, email, password
+					` + userColumns + `
 				FROM
 					users
+				` + where + `
 	`
 	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
 
 	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
 	defer cancel()
-	rows, err := r.client.Query(nCtx, query)
+	rows, err := r.client.Query(nCtx, query, args...)
 	if err != nil {
 		return nil, handleSQLError(err, r.logger)
 	}
@@ -91,8 +179,7 @@ func (r *repository) FindAll(ctx context.Context) ([]model.User, error) {
 
 	users := make([]model.User, 0)
 	for rows.Next() {
-		var usr model.User
-		err = rows.Scan(&usr.UUID, &usr.Name, &usr.Email, &usr.Password)
+		usr, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -104,93 +191,503 @@ func (r *repository) FindAll(ctx context.Context) ([]model.User, error) {
 	return users, nil
 }
 
+func (r *repository) Stream(ctx context.Context, filter dto.UserFilter, batchSize int,
+	fn func(users []model.User) error) error {
+	where, args := userFilterClause(filter)
+	declareQuery := `
+				DECLARE users_stream NO SCROLL CURSOR FOR
+				SELECT
+					` + userColumns + `
+				FROM
+					users
+				` + where + `
+				ORDER BY
+					updated_at, id
+	`
+	fetchQuery := fmt.Sprintf("FETCH FORWARD %d FROM users_stream", batchSize)
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(declareQuery)))
+
+	tx, err := r.client.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback stream transaction: %v", rbErr)
+		}
+	}()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	_, err = tx.Exec(nCtx, declareQuery, args...)
+	cancel()
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		users, err := r.fetchBatch(ctx, tx, fetchQuery, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+
+		// the next batch is fetched only after the consumer has accepted the current one
+		if err = fn(users); err != nil {
+			return err
+		}
+
+		if len(users) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (r *repository) fetchBatch(ctx context.Context, tx pgx.Tx, query string, batchSize int) ([]model.User, error) {
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := tx.Query(nCtx, query)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0, batchSize)
+	for rows.Next() {
+		usr, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, usr)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return users, nil
+}
+
 func (r *repository) FindByUUID(ctx context.Context, uuid string) (model.User, error) {
 	query := `
 				SELECT
-					id, name, email, password
+					` + userColumns + `
 				FROM
 					users
 				WHERE
-					id = $1
+					id = $1 AND deleted_at IS NULL
 	`
 	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
 
-	var usr model.User
 	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
 	defer cancel()
-	err := r.client.QueryRow(nCtx, query, uuid).Scan(&usr.UUID, &usr.Name, &usr.Email, &usr.Password)
+	usr, err := scanUser(r.client.QueryRow(nCtx, query, uuid))
 	if err != nil {
 		return model.User{}, handleSQLError(err, r.logger)
 	}
 	return usr, nil
 }
 
+func (r *repository) FindByUUIDs(ctx context.Context, uuids []string) ([]model.User, error) {
+	query := `
+				SELECT
+					` + userColumns + `
+				FROM
+					users
+				WHERE
+					id = ANY($1) AND deleted_at IS NULL
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, uuids)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0, len(uuids))
+	for rows.Next() {
+		usr, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, usr)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return users, nil
+}
+
 func (r *repository) FindByEmail(ctx context.Context, email string) (model.User, error) {
 	query := `
 				SELECT
-					id, name, email, password
+					` + userColumns + `
 				FROM
 					users
 				WHERE
-					email = $1
+					lower(email) = lower($1) AND deleted_at IS NULL
     `
 	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
 
-	var usr model.User
 	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
 	defer cancel()
-	err := r.client.QueryRow(nCtx, query, email).Scan(&usr.UUID, &usr.Name, &usr.Email, &usr.Password)
+	usr, err := scanUser(r.client.QueryRow(nCtx, query, email))
 	if err != nil {
 		return model.User{}, handleSQLError(err, r.logger)
 	}
 	return usr, nil
 }
 
-func (r *repository) Update(ctx context.Context, user model.User) error {
+// Update writes the fields of user and stores emailChange, if any, in one transaction.
+// An update changing nothing but the email leaves the user as it is, the email only
+// changes once the new address confirms it.
+func (r *repository) Update(ctx context.Context, user model.User, emailChange *model.EmailChange) error {
+	lockQuery := `
+				SELECT
+					` + userColumns + `
+				FROM
+					users
+				WHERE
+					id = $1 AND version = $2 AND deleted_at IS NULL
+				FOR UPDATE
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback update transaction: %v", rbErr)
+		}
+	}()
+
+	before, err := scanUser(tx.QueryRow(nCtx, lockQuery, user.UUID, user.Version))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.PreconditionFailedError("user has been changed or deleted concurrently")
+		}
+		return handleSQLError(err, r.logger)
+	}
+
+	changed := before.Name != user.Name || before.Username != user.Username || before.Password != user.Password
+	if changed || emailChange == nil {
+		if err = r.updateUser(nCtx, tx, before, user); err != nil {
+			return err
+		}
+	}
+	if emailChange != nil {
+		if err = r.createEmailChange(nCtx, tx, *emailChange); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(nCtx); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+// updateUser writes the fields of user locked as before, with its events and audit entry.
+func (r *repository) updateUser(ctx context.Context, tx pgx.Tx, before, user model.User) error {
+	updateQuery := `
+				UPDATE
+					users
+				SET
+					name = $1, email = $2, username = NULLIF($3, ''), password = $4, updated_at = $5,
+					password_changed_at = $6, version = version + 1
+				WHERE
+					id = $7
+				RETURNING
+					` + userColumns + `
+	`
+	// a replaced username is remembered so that others cannot claim it right away
+	historyQuery := `
+				INSERT INTO username_history
+					(user_id, username, released_at)
+				VALUES
+					($1, $2, $3)
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(updateQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(historyQuery)))
+
+	updated, err := scanUser(tx.QueryRow(ctx, updateQuery, user.Name, user.Email, user.Username, user.Password,
+		user.UpdatedAt, user.PasswordChangedAt, user.UUID))
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	if err = r.writeUserEvent(ctx, tx, model.EventUserUpdated, updated, updated.UpdatedAt); err != nil {
+		return err
+	}
+	if updated.Password != before.Password {
+		if err = r.writeUserEvent(ctx, tx, model.EventPasswordChanged, updated, updated.UpdatedAt); err != nil {
+			return err
+		}
+	}
+
+	if before.Username != "" && before.Username != user.Username {
+		if _, err = tx.Exec(ctx, historyQuery, user.UUID, before.Username, user.UpdatedAt); err != nil {
+			return handleSQLError(err, r.logger)
+		}
+	}
+	return r.writeAudit(ctx, tx, model.AuditUserUpdated, user.UUID, &before, &updated, updated.UpdatedAt)
+}
+
+// UpdateLastLogin records a login without changing the version, logins must not
+// fail the conditional writes of clients holding the user.
+func (r *repository) UpdateLastLogin(ctx context.Context, uuid string, at time.Time) error {
+	query := `
+				UPDATE
+					users
+				SET
+					last_login_at = $2
+				WHERE
+					id = $1 AND deleted_at IS NULL
+    `
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	_, err := r.client.Exec(nCtx, query, uuid, at)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+
+	return nil
+}
+
+func (r *repository) UpdateStatus(ctx context.Context, uuid string, from, to model.Status, reason string) error {
 	query := `
 				UPDATE
 					users
 				SET
-					name = $1, email = $2, password = $3
+					status = $3, status_reason = $4, status_changed_at = now(), updated_at = now(),
+					version = version + 1
 				WHERE
-					id = $4
+					id = $1 AND status = $2 AND deleted_at IS NULL
+				RETURNING
+					` + userColumns + `
     `
 	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
 
 	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
 	defer cancel()
-	cmdTag, err := r.client.Exec(nCtx, query, user.Name, user.Email, user.Password, user.UUID)
+
+	tx, err := r.client.Begin(nCtx)
 	if err != nil {
 		return handleSQLError(err, r.logger)
 	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback status transaction: %v", rbErr)
+		}
+	}()
 
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("no rows were updated")
+	before, err := r.lockUser(nCtx, tx, uuid)
+	if err != nil {
+		return err
+	}
+	updated, err := scanUser(tx.QueryRow(nCtx, query, uuid, from, to, reason))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.BadRequestError("user status has been changed concurrently")
+		}
+		return handleSQLError(err, r.logger)
+	}
+	if err = r.writeUserEvent(nCtx, tx, model.EventUserStatusChanged, updated, updated.UpdatedAt); err != nil {
+		return err
+	}
+	if err = r.writeAudit(nCtx, tx, model.AuditUserStatusChanged, uuid, before, &updated, updated.UpdatedAt); err != nil {
+		return err
 	}
 
+	if err = tx.Commit(nCtx); err != nil {
+		return handleSQLError(err, r.logger)
+	}
 	return nil
 }
 
-func (r *repository) Delete(ctx context.Context, uuid string) error {
+func (r *repository) Delete(ctx context.Context, uuid string, version int64) error {
 	query := `
-				DELETE
-				FROM
+				UPDATE
 					users
+				SET
+					deleted_at = $3, status = 'deleted', status_before_delete = status,
+					status_changed_at = $3, version = version + 1
 				WHERE
-					id = $1
+					id = $1 AND version = $2 AND deleted_at IS NULL
+				RETURNING
+					` + userColumns + `
+    `
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback delete transaction: %v", rbErr)
+		}
+	}()
+
+	before, err := r.lockUser(nCtx, tx, uuid)
+	if err != nil {
+		return err
+	}
+	deletedAt := time.Now().UTC()
+	deleted, err := scanUser(tx.QueryRow(nCtx, query, uuid, version, deletedAt))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperror.PreconditionFailedError("user has been changed or deleted concurrently")
+		}
+		return handleSQLError(err, r.logger)
+	}
+	if err = r.writeUserEvent(nCtx, tx, model.EventUserDeleted, deleted, deletedAt); err != nil {
+		return err
+	}
+	if err = r.writeAudit(nCtx, tx, model.AuditUserDeleted, uuid, before, &deleted, deletedAt); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(nCtx); err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+func (r *repository) Restore(ctx context.Context, uuid string, deletedAfter time.Time) error {
+	query := `
+				UPDATE
+					users
+				SET
+					deleted_at = NULL, status = COALESCE(status_before_delete, 'active'),
+					status_before_delete = NULL, status_changed_at = now(), updated_at = now(),
+					version = version + 1
+				WHERE
+					id = $1 AND deleted_at > $2
+				RETURNING
+					` + userColumns + `
     `
 	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
 
 	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
 	defer cancel()
-	cmdTag, err := r.client.Exec(nCtx, query, uuid)
+
+	tx, err := r.client.Begin(nCtx)
 	if err != nil {
 		return handleSQLError(err, r.logger)
 	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback restore transaction: %v", rbErr)
+		}
+	}()
 
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("no rows were deleted")
+	before, err := r.lockUser(nCtx, tx, uuid)
+	if err != nil {
+		return err
+	}
+	restored, err := scanUser(tx.QueryRow(nCtx, query, uuid, deletedAfter))
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	if err = r.writeUserEvent(nCtx, tx, model.EventUserRestored, restored, restored.UpdatedAt); err != nil {
+		return err
+	}
+	if err = r.writeAudit(nCtx, tx, model.AuditUserRestored, uuid, before, &restored, restored.UpdatedAt); err != nil {
+		return err
 	}
 
+	if err = tx.Commit(nCtx); err != nil {
+		return handleSQLError(err, r.logger)
+	}
 	return nil
 }
+
+func (r *repository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := `
+				DELETE
+				FROM
+					users
+				WHERE
+					deleted_at < $1
+				RETURNING
+					` + userColumns + `
+    `
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback purge transaction: %v", rbErr)
+		}
+	}()
+
+	rows, err := tx.Query(nCtx, query, deletedBefore)
+	if err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+	purgedAt := time.Now().UTC()
+	source := audit.FromContext(ctx)
+	batch := make([]events.Event, 0)
+	entries := make([]model.AuditEntry, 0)
+	for rows.Next() {
+		purged, err := scanUser(rows)
+		if err != nil {
+			rows.Close()
+			return 0, handleSQLError(err, r.logger)
+		}
+		event, err := model.NewUserEvent(model.EventUserPurged, purged, purgedAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		entry, err := model.NewAuditEntry(source, model.AuditUserPurged, purged.UUID, &purged, nil, purgedAt)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, event)
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+
+	if len(batch) > 0 {
+		if err = writeEvents(nCtx, tx, r.logger, batch); err != nil {
+			return 0, err
+		}
+		if err = appendAuditEntries(nCtx, tx, r.logger, entries); err != nil {
+			return 0, err
+		}
+	}
+	if err = tx.Commit(nCtx); err != nil {
+		return 0, handleSQLError(err, r.logger)
+	}
+	return int64(len(batch)), nil
+}
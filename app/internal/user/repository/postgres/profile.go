@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"Users/internal/user/domain/model"
+	"Users/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+)
+
+// profileQuery selects the profile of a not deleted user, a user without a profile row gets an empty one.
+const profileQuery = `
+				SELECT
+					u.id,
+					COALESCE(p.currency, ''), COALESCE(p.locale, ''), COALESCE(p.timezone, ''),
+					COALESCE(p.avatar_url, ''), COALESCE(p.avatar_id, ''), COALESCE(p.date_format, ''),
+					COALESCE(p.attributes, '{}'), p.updated_at
+				FROM
+					users u
+				LEFT JOIN
+					user_profiles p ON p.user_id = u.id
+				WHERE
+					u.id = $1 AND u.deleted_at IS NULL
+`
+
+func scanProfile(row pgx.Row) (model.Profile, error) {
+	var profile model.Profile
+	err := row.Scan(&profile.UserUUID, &profile.Currency, &profile.Locale, &profile.Timezone,
+		&profile.AvatarURL, &profile.AvatarID, &profile.DateFormat, &profile.Attributes, &profile.UpdatedAt)
+	if profile.Attributes == nil {
+		profile.Attributes = make(map[string]interface{})
+	}
+	return profile, err
+}
+
+func (r *repository) FindProfile(ctx context.Context, uuid string) (model.Profile, error) {
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(profileQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	profile, err := scanProfile(r.client.QueryRow(nCtx, profileQuery, uuid))
+	if err != nil {
+		return model.Profile{}, handleSQLError(err, r.logger)
+	}
+	return profile, nil
+}
+
+func (r *repository) UpdateProfile(ctx context.Context, uuid, action string,
+	fn func(profile model.Profile) (model.Profile, error)) (model.Profile, error) {
+	// the user row is locked as the profile row may not exist yet
+	lockQuery := profileQuery + `
+				FOR UPDATE OF u
+	`
+	upsertQuery := `
+				INSERT INTO user_profiles
+				    (user_id, currency, locale, timezone, avatar_url, avatar_id, date_format, attributes, updated_at)
+				VALUES
+				    ($1, $2, $3, $4, $5, $6, $7, $8, now())
+				ON CONFLICT (user_id) DO UPDATE SET
+					currency = EXCLUDED.currency,
+					locale = EXCLUDED.locale,
+					timezone = EXCLUDED.timezone,
+					avatar_url = EXCLUDED.avatar_url,
+					avatar_id = EXCLUDED.avatar_id,
+					date_format = EXCLUDED.date_format,
+					attributes = EXCLUDED.attributes,
+					updated_at = EXCLUDED.updated_at
+				RETURNING
+					updated_at
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(lockQuery)))
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(upsertQuery)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		return model.Profile{}, handleSQLError(err, r.logger)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(context.Background()); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			r.logger.Errorf("failed to rollback profile transaction: %v", rbErr)
+		}
+	}()
+
+	existing, err := scanProfile(tx.QueryRow(nCtx, lockQuery, uuid))
+	if err != nil {
+		return model.Profile{}, handleSQLError(err, r.logger)
+	}
+
+	profile, err := fn(existing)
+	if err != nil {
+		return model.Profile{}, err
+	}
+
+	attributes, err := json.Marshal(profile.Attributes)
+	if err != nil {
+		return model.Profile{}, fmt.Errorf("failed to marshal profile attributes: %w", err)
+	}
+	err = tx.QueryRow(nCtx, upsertQuery, uuid, profile.Currency, profile.Locale, profile.Timezone,
+		profile.AvatarURL, profile.AvatarID, profile.DateFormat, attributes).Scan(&profile.UpdatedAt)
+	if err != nil {
+		return model.Profile{}, handleSQLError(err, r.logger)
+	}
+	if err = r.writeAudit(nCtx, tx, action, uuid, &existing, &profile, *profile.UpdatedAt); err != nil {
+		return model.Profile{}, err
+	}
+
+	if err = tx.Commit(nCtx); err != nil {
+		return model.Profile{}, handleSQLError(err, r.logger)
+	}
+	return profile, nil
+}
+
+func (r *repository) FindProfileSchema(ctx context.Context) (model.ProfileSchema, error) {
+	query := `
+				SELECT
+					schema, updated_at
+				FROM
+					profile_attributes_schema
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	var schema []byte
+	var profileSchema model.ProfileSchema
+	err := r.client.QueryRow(nCtx, query).Scan(&schema, &profileSchema.UpdatedAt)
+	if err != nil {
+		return model.ProfileSchema{}, handleSQLError(err, r.logger)
+	}
+	profileSchema.Schema = schema
+	return profileSchema, nil
+}
+
+func (r *repository) SaveProfileSchema(ctx context.Context, schema model.ProfileSchema) error {
+	query := `
+				INSERT INTO profile_attributes_schema
+				    (schema, updated_at)
+				VALUES
+				    ($1, $2)
+				ON CONFLICT (id) DO UPDATE SET
+					schema = EXCLUDED.schema,
+					updated_at = EXCLUDED.updated_at
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	_, err := r.client.Exec(nCtx, query, []byte(schema.Schema), schema.UpdatedAt)
+	if err != nil {
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"Users/pkg/utils"
+	"context"
+	"fmt"
+)
+
+// FindTenants lists every tenant, the tenants table itself is not isolated.
+func (r *repository) FindTenants(ctx context.Context) ([]string, error) {
+	query := `
+				SELECT
+					id
+				FROM
+					tenants
+				ORDER BY
+					created_at, id
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	tenants := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return tenants, nil
+}
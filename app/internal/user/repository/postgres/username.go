@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"Users/internal/user/domain/model"
+	"Users/pkg/utils"
+	"context"
+	"fmt"
+	"time"
+)
+
+func (r *repository) FindByUsername(ctx context.Context, username string) (model.User, error) {
+	query := `
+				SELECT
+					` + userColumns + `
+				FROM
+					users
+				WHERE
+					lower(username) = lower($1) AND deleted_at IS NULL
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	usr, err := scanUser(r.client.QueryRow(nCtx, query, username))
+	if err != nil {
+		return model.User{}, handleSQLError(err, r.logger)
+	}
+	return usr, nil
+}
+
+func (r *repository) IsUsernameReleased(ctx context.Context, username, exceptUUID string,
+	releasedAfter time.Time) (bool, error) {
+	// the username of a deleted user counts as released at the deletion
+	query := `
+				SELECT
+					EXISTS (
+						SELECT 1 FROM username_history
+						WHERE lower(username) = lower($1) AND user_id::TEXT <> $2 AND released_at > $3
+					) OR EXISTS (
+						SELECT 1 FROM users
+						WHERE lower(username) = lower($1) AND id::TEXT <> $2 AND deleted_at > $3
+					)
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	var released bool
+	err := r.client.QueryRow(nCtx, query, username, exceptUUID, releasedAfter).Scan(&released)
+	if err != nil {
+		return false, handleSQLError(err, r.logger)
+	}
+	return released, nil
+}
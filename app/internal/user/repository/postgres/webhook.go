@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"Users/internal/user/domain/dto"
+	"Users/internal/user/domain/model"
+	"Users/pkg/utils"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+const webhookColumns = `
+						w.id, w.url, w.events, w.description, w.secret, w.active, w.created_at, w.updated_at
+`
+
+const deliveryColumns = `
+						d.id, d.subscription_id, s.tenant_id, d.event_id, d.event_type, d.user_id, d.payload::text,
+						d.occurred_at, d.status, d.attempts, d.next_attempt_at, d.last_attempt_at,
+						d.last_status_code, d.last_error, d.created_at
+`
+
+func scanWebhook(row pgx.Row) (model.WebhookSubscription, error) {
+	var webhook model.WebhookSubscription
+	err := row.Scan(&webhook.UUID, &webhook.URL, &webhook.Events, &webhook.Description, &webhook.Secret,
+		&webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+	return webhook, err
+}
+
+func scanDelivery(row pgx.Row, extra ...interface{}) (model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	var payload string
+	dest := append([]interface{}{&delivery.ID, &delivery.SubscriptionUUID, &delivery.Event.TenantID,
+		&delivery.Event.ID, &delivery.Event.Type, &delivery.Event.UserUUID, &payload, &delivery.Event.OccurredAt,
+		&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastAttemptAt,
+		&delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt}, extra...)
+	err := row.Scan(dest...)
+	delivery.Event.Payload = []byte(payload)
+	return delivery, err
+}
+
+func (r *repository) CreateWebhook(ctx context.Context, webhook model.WebhookSubscription) (string, error) {
+	query := `
+				INSERT INTO webhook_subscriptions
+					(url, events, description, secret, active, created_at, updated_at)
+				VALUES
+					($1, $2, $3, $4, $5, $6, $7)
+				RETURNING id
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	var webhookUUID string
+	err := r.client.QueryRow(nCtx, query, webhook.URL, webhook.Events, webhook.Description, webhook.Secret,
+		webhook.Active, webhook.CreatedAt, webhook.UpdatedAt).Scan(&webhookUUID)
+	if err != nil {
+		return "", handleSQLError(err, r.logger)
+	}
+	return webhookUUID, nil
+}
+
+func (r *repository) FindWebhooks(ctx context.Context) ([]model.WebhookSubscription, error) {
+	query := `
+				SELECT` + webhookColumns + `
+				FROM
+					webhook_subscriptions w
+				ORDER BY
+					w.created_at
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	webhooks := make([]model.WebhookSubscription, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return webhooks, nil
+}
+
+func (r *repository) FindWebhook(ctx context.Context, uuid string) (model.WebhookSubscription, error) {
+	query := `
+				SELECT` + webhookColumns + `
+				FROM
+					webhook_subscriptions w
+				WHERE
+					w.id = $1
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	webhook, err := scanWebhook(r.client.QueryRow(nCtx, query, uuid))
+	if err != nil {
+		return model.WebhookSubscription{}, handleSQLError(err, r.logger)
+	}
+	return webhook, nil
+}
+
+func (r *repository) UpdateWebhook(ctx context.Context, webhook model.WebhookSubscription) error {
+	query := `
+				UPDATE webhook_subscriptions SET
+					url = $2,
+					events = $3,
+					description = $4,
+					active = $5,
+					updated_at = $6
+				WHERE
+					id = $1
+	`
+	return r.execOne(ctx, query, webhook.UUID, webhook.URL, webhook.Events, webhook.Description,
+		webhook.Active, webhook.UpdatedAt)
+}
+
+// DeleteWebhook deletes the subscription together with its deliveries.
+func (r *repository) DeleteWebhook(ctx context.Context, uuid string) error {
+	query := `
+				DELETE FROM
+					webhook_subscriptions
+				WHERE
+					id = $1
+	`
+	return r.execOne(ctx, query, uuid)
+}
+
+func (r *repository) FindWebhookDeliveries(ctx context.Context, webhookUUID string,
+	filter dto.DeliveryFilter) ([]model.WebhookDelivery, error) {
+	query := `
+				SELECT` + deliveryColumns + `
+				FROM
+					webhook_deliveries d
+				JOIN
+					webhook_subscriptions s ON s.id = d.subscription_id
+				WHERE
+					d.subscription_id = $1
+					AND ($2 = '' OR d.status = $2)
+					AND ($3::bigint = 0 OR d.id < $3)
+				ORDER BY
+					d.id DESC
+				LIMIT $4
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, webhookUUID, filter.Status, filter.Before, filter.Limit)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return deliveries, nil
+}
+
+func (r *repository) ClaimWebhookDeliveries(ctx context.Context, limit int, now,
+	leaseUntil time.Time) ([]model.WebhookDelivery, error) {
+	// deliveries of paused subscriptions wait until they are active again
+	query := `
+				UPDATE
+					webhook_deliveries d
+				SET
+					next_attempt_at = $2
+				FROM
+					webhook_subscriptions s
+				WHERE
+					s.id = d.subscription_id
+					AND d.id IN (
+						SELECT
+							due.id
+						FROM
+							webhook_deliveries due
+						JOIN
+							webhook_subscriptions ds ON ds.id = due.subscription_id
+						WHERE
+							due.status = 'pending' AND due.next_attempt_at <= $1 AND ds.active
+						ORDER BY
+							due.next_attempt_at, due.id
+						LIMIT $3
+						FOR UPDATE OF due SKIP LOCKED
+					)
+				RETURNING` + deliveryColumns + `, s.url, s.secret
+	`
+	r.logger.Trace(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(query)))
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	for rows.Next() {
+		var url, secret string
+		delivery, err := scanDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		delivery.URL, delivery.Secret = url, secret
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err, r.logger)
+	}
+	return deliveries, nil
+}
+
+func (r *repository) SaveWebhookAttempt(ctx context.Context, delivery model.WebhookDelivery) error {
+	query := `
+				UPDATE webhook_deliveries SET
+					status = $2,
+					attempts = $3,
+					next_attempt_at = $4,
+					last_attempt_at = $5,
+					last_status_code = $6,
+					last_error = $7
+				WHERE
+					id = $1
+	`
+	return r.execOne(ctx, query, delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.LastAttemptAt, delivery.LastStatusCode, delivery.LastError)
+}
+
+// RedeliverWebhook makes the delivery due again with a fresh set of attempts.
+func (r *repository) RedeliverWebhook(ctx context.Context, webhookUUID string, deliveryID int64,
+	now time.Time) error {
+	query := `
+				UPDATE webhook_deliveries SET
+					status = 'pending',
+					attempts = 0,
+					next_attempt_at = $3
+				WHERE
+					id = $2 AND subscription_id = $1
+	`
+	return r.execOne(ctx, query, webhookUUID, deliveryID, now)
+}
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX users_updated_at_id_idx ON users (updated_at, id);
@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('pending', 'active', 'suspended', 'locked', 'deleted')),
+    ADD COLUMN status_reason TEXT NOT NULL DEFAULT '',
+    ADD COLUMN status_changed_at TIMESTAMPTZ,
+    -- status to return to when a soft deleted user is restored
+    ADD COLUMN status_before_delete VARCHAR(16);
+
+UPDATE users
+SET status = 'deleted', status_before_delete = 'active'
+WHERE deleted_at IS NOT NULL;
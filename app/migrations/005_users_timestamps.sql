@@ -0,0 +1,10 @@
+ALTER TABLE users
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN last_login_at TIMESTAMPTZ,
+    ADD COLUMN password_changed_at TIMESTAMPTZ;
+
+-- the real creation time of existing users is unknown, their last update is the best guess
+UPDATE users SET created_at = updated_at WHERE created_at > updated_at;
+
+CREATE INDEX users_created_at_idx ON users (created_at);
+CREATE INDEX users_last_login_at_idx ON users (last_login_at);
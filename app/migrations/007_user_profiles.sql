@@ -0,0 +1,18 @@
+CREATE TABLE user_profiles (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    currency VARCHAR(3) NOT NULL DEFAULT '',
+    locale VARCHAR(35) NOT NULL DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT '',
+    avatar_url TEXT NOT NULL DEFAULT '',
+    date_format VARCHAR(16) NOT NULL DEFAULT '',
+    -- free form keys, validated against the profile attributes schema
+    attributes JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- single row holding the admin defined JSON Schema of profile attributes
+CREATE TABLE profile_attributes_schema (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    schema JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
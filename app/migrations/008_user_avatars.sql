@@ -0,0 +1,3 @@
+ALTER TABLE user_profiles
+    -- content hash of the uploaded avatar, its thumbnails live in the blob storage
+    ADD COLUMN avatar_id VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,31 @@
+-- addresses that only differ in case or surrounding spaces belong to the same mailbox,
+-- such duplicates among not deleted users have to be merged or renamed by hand first
+DO $$
+DECLARE
+    conflicts TEXT;
+BEGIN
+    SELECT string_agg(format('%s (%s)', normalized, ids), '; ')
+    INTO conflicts
+    FROM (
+        SELECT lower(btrim(email)) AS normalized, string_agg(id::TEXT, ', ' ORDER BY id) AS ids
+        FROM users
+        WHERE deleted_at IS NULL
+        GROUP BY lower(btrim(email))
+        HAVING count(*) > 1
+    ) duplicates;
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'users with emails equal after normalization: %', conflicts
+            USING HINT = 'change or delete all but one user of every listed email and rerun the migration';
+    END IF;
+END
+$$;
+
+-- internationalized domains are converted to punycode by the application on the next change
+UPDATE users
+SET email = lower(btrim(email))
+WHERE email <> lower(btrim(email));
+
+DROP INDEX users_email_active_key;
+
+CREATE UNIQUE INDEX users_email_active_key ON users (lower(email)) WHERE deleted_at IS NULL;
@@ -0,0 +1,17 @@
+CREATE TABLE email_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    old_email VARCHAR(255) NOT NULL,
+    new_email VARCHAR(255) NOT NULL,
+    -- only SHA-256 hashes of the mailed tokens are kept
+    confirm_token_hash BYTEA NOT NULL UNIQUE,
+    revert_token_hash BYTEA NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    confirmed_at TIMESTAMPTZ,
+    reverted_at TIMESTAMPTZ,
+    -- set when a newer change of the same user supersedes a pending one
+    cancelled_at TIMESTAMPTZ
+);
+
+CREATE INDEX email_changes_user_id_idx ON email_changes (user_id);
@@ -0,0 +1,15 @@
+ALTER TABLE users
+    -- optional handle, stored lowercased like emails
+    ADD COLUMN username VARCHAR(30);
+
+CREATE UNIQUE INDEX users_username_active_key ON users (lower(username)) WHERE deleted_at IS NULL;
+
+-- usernames given up by their users, others can claim them only after the reclaim period
+CREATE TABLE username_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    username VARCHAR(30) NOT NULL,
+    released_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX username_history_username_idx ON username_history (lower(username), released_at);
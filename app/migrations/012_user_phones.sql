@@ -0,0 +1,26 @@
+ALTER TABLE users
+    -- E.164 number, it identifies the user only once verified
+    ADD COLUMN phone VARCHAR(16),
+    ADD COLUMN phone_verified_at TIMESTAMPTZ,
+    -- logins with a password also require a code sent to the verified phone
+    ADD COLUMN phone_second_factor BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX users_phone_active_key ON users (phone)
+    WHERE deleted_at IS NULL AND phone_verified_at IS NOT NULL;
+
+-- one-time codes sent by SMS, the sent codes also back the rate limiting
+CREATE TABLE phone_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    phone VARCHAR(16) NOT NULL,
+    -- verify confirms the phone, login is the second factor of a login
+    purpose VARCHAR(16) NOT NULL,
+    code_hash BYTEA NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ
+);
+
+CREATE INDEX phone_codes_user_id_idx ON phone_codes (user_id, created_at);
+CREATE INDEX phone_codes_phone_idx ON phone_codes (phone, created_at);
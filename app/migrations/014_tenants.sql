@@ -0,0 +1,82 @@
+-- customers sharing the database, every user belongs to exactly one of them
+CREATE TABLE tenants (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- owns the users that existed before tenants, see tenant.DefaultID
+INSERT INTO tenants (id, name) VALUES ('00000000-0000-0000-0000-000000000000', 'default');
+
+-- app.tenant_id is set per transaction by postgresql.NewTenantClient,
+-- rows are written to the tenant of the transaction unless given explicitly
+ALTER TABLE users
+    ADD COLUMN tenant_id UUID NOT NULL REFERENCES tenants (id) DEFAULT '00000000-0000-0000-0000-000000000000';
+
+ALTER TABLE users
+    ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID;
+
+ALTER TABLE organizations
+    ADD COLUMN tenant_id UUID NOT NULL REFERENCES tenants (id) DEFAULT '00000000-0000-0000-0000-000000000000';
+
+ALTER TABLE organizations
+    ALTER COLUMN tenant_id SET DEFAULT NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID;
+
+CREATE INDEX users_tenant_id_idx ON users (tenant_id);
+CREATE INDEX organizations_tenant_id_idx ON organizations (tenant_id);
+
+-- emails, usernames and phones are unique within a tenant only
+DROP INDEX users_email_active_key;
+CREATE UNIQUE INDEX users_email_active_key ON users (tenant_id, lower(email)) WHERE deleted_at IS NULL;
+
+DROP INDEX users_username_active_key;
+CREATE UNIQUE INDEX users_username_active_key ON users (tenant_id, lower(username)) WHERE deleted_at IS NULL;
+
+DROP INDEX users_phone_active_key;
+CREATE UNIQUE INDEX users_phone_active_key ON users (tenant_id, phone)
+    WHERE deleted_at IS NULL AND phone_verified_at IS NOT NULL;
+
+-- FORCE applies the policies to the table owner as well, which the application connects as.
+-- Without app.tenant_id the policies match no row.
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+CREATE POLICY users_tenant_isolation ON users
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID);
+
+ALTER TABLE organizations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organizations FORCE ROW LEVEL SECURITY;
+CREATE POLICY organizations_tenant_isolation ON organizations
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID);
+
+-- rows of users and organizations are visible with their parent, the subqueries
+-- are themselves filtered by the policies above
+ALTER TABLE user_profiles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE user_profiles FORCE ROW LEVEL SECURITY;
+CREATE POLICY user_profiles_tenant_isolation ON user_profiles
+    USING (EXISTS (SELECT 1 FROM users WHERE users.id = user_profiles.user_id));
+
+ALTER TABLE email_changes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE email_changes FORCE ROW LEVEL SECURITY;
+CREATE POLICY email_changes_tenant_isolation ON email_changes
+    USING (EXISTS (SELECT 1 FROM users WHERE users.id = email_changes.user_id));
+
+ALTER TABLE username_history ENABLE ROW LEVEL SECURITY;
+ALTER TABLE username_history FORCE ROW LEVEL SECURITY;
+CREATE POLICY username_history_tenant_isolation ON username_history
+    USING (EXISTS (SELECT 1 FROM users WHERE users.id = username_history.user_id));
+
+ALTER TABLE phone_codes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE phone_codes FORCE ROW LEVEL SECURITY;
+CREATE POLICY phone_codes_tenant_isolation ON phone_codes
+    USING (EXISTS (SELECT 1 FROM users WHERE users.id = phone_codes.user_id));
+
+ALTER TABLE memberships ENABLE ROW LEVEL SECURITY;
+ALTER TABLE memberships FORCE ROW LEVEL SECURITY;
+CREATE POLICY memberships_tenant_isolation ON memberships
+    USING (EXISTS (SELECT 1 FROM organizations WHERE organizations.id = memberships.organization_id)
+        AND EXISTS (SELECT 1 FROM users WHERE users.id = memberships.user_id));
+
+ALTER TABLE invitations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE invitations FORCE ROW LEVEL SECURITY;
+CREATE POLICY invitations_tenant_isolation ON invitations
+    USING (EXISTS (SELECT 1 FROM organizations WHERE organizations.id = invitations.organization_id));
@@ -0,0 +1,49 @@
+-- groups of users independent of roles, a group can contain other groups
+CREATE TABLE groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants (id)
+        DEFAULT NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX groups_name_key ON groups (tenant_id, lower(name));
+
+CREATE TABLE group_members (
+    group_id UUID NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX group_members_user_id_idx ON group_members (user_id);
+
+-- the members of a child group are members of its parent, cycles are rejected by the application
+CREATE TABLE group_subgroups (
+    parent_id UUID NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    child_id UUID NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (parent_id, child_id),
+    CHECK (parent_id <> child_id)
+);
+
+CREATE INDEX group_subgroups_child_id_idx ON group_subgroups (child_id);
+
+ALTER TABLE groups ENABLE ROW LEVEL SECURITY;
+ALTER TABLE groups FORCE ROW LEVEL SECURITY;
+CREATE POLICY groups_tenant_isolation ON groups
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID);
+
+ALTER TABLE group_members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE group_members FORCE ROW LEVEL SECURITY;
+CREATE POLICY group_members_tenant_isolation ON group_members
+    USING (EXISTS (SELECT 1 FROM groups WHERE groups.id = group_members.group_id)
+        AND EXISTS (SELECT 1 FROM users WHERE users.id = group_members.user_id));
+
+ALTER TABLE group_subgroups ENABLE ROW LEVEL SECURITY;
+ALTER TABLE group_subgroups FORCE ROW LEVEL SECURITY;
+CREATE POLICY group_subgroups_tenant_isolation ON group_subgroups
+    USING (EXISTS (SELECT 1 FROM groups WHERE groups.id = group_subgroups.parent_id)
+        AND EXISTS (SELECT 1 FROM groups WHERE groups.id = group_subgroups.child_id));
@@ -0,0 +1,35 @@
+-- relation tuples object#relation@subject, see pkg/authz
+CREATE TABLE relation_tuples (
+    tenant_id UUID NOT NULL REFERENCES tenants (id)
+        DEFAULT NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID,
+    namespace VARCHAR(64) NOT NULL,
+    object_id VARCHAR(255) NOT NULL,
+    relation VARCHAR(64) NOT NULL,
+    subject_namespace VARCHAR(64) NOT NULL,
+    subject_object_id VARCHAR(255) NOT NULL,
+    -- empty when the subject is an object, the relation of the subject set otherwise
+    subject_relation VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, namespace, object_id, relation, subject_namespace, subject_object_id, subject_relation)
+);
+
+CREATE INDEX relation_tuples_subject_idx
+    ON relation_tuples (tenant_id, subject_namespace, subject_object_id, subject_relation);
+
+-- source of the schema of namespaces and relation rewrites of a tenant
+CREATE TABLE authz_schemas (
+    tenant_id UUID PRIMARY KEY REFERENCES tenants (id)
+        DEFAULT NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID,
+    source TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE relation_tuples ENABLE ROW LEVEL SECURITY;
+ALTER TABLE relation_tuples FORCE ROW LEVEL SECURITY;
+CREATE POLICY relation_tuples_tenant_isolation ON relation_tuples
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID);
+
+ALTER TABLE authz_schemas ENABLE ROW LEVEL SECURITY;
+ALTER TABLE authz_schemas FORCE ROW LEVEL SECURITY;
+CREATE POLICY authz_schemas_tenant_isolation ON authz_schemas
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID);
@@ -0,0 +1,21 @@
+-- domain events written in the transaction of the change they describe and
+-- published by the relay, see internal/user/relay
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants (id)
+        DEFAULT NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID,
+    type VARCHAR(64) NOT NULL,
+    -- no foreign key, the events of a user outlive its purge
+    user_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (tenant_id, id) WHERE published_at IS NULL;
+CREATE INDEX outbox_events_published_at_idx ON outbox_events (tenant_id, published_at);
+
+ALTER TABLE outbox_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox_events FORCE ROW LEVEL SECURITY;
+CREATE POLICY outbox_events_tenant_isolation ON outbox_events
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', TRUE), '')::UUID);
@@ -0,0 +1,14 @@
+-- wakes the event watchers of every replica up when events of a tenant are
+-- committed, the notification only carries the tenant, watchers read the events
+-- from the outbox. Notifications with the same payload are folded into one per
+-- transaction.
+CREATE FUNCTION notify_outbox_events() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('outbox_events', NEW.tenant_id::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER outbox_events_notify
+    AFTER INSERT ON outbox_events
+    FOR EACH ROW EXECUTE FUNCTION notify_outbox_events();
@@ -0,0 +1,23 @@
+-- the application switches to this role in every transaction, see postgresql.NewTenantClient.
+-- Superusers and roles with BYPASSRLS are not subject to the tenant policies, so connecting
+-- as the postgres user would otherwise let every tenant read the others. users_app has
+-- neither attribute and owns no table.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'users_app') THEN
+        CREATE ROLE users_app NOLOGIN NOSUPERUSER NOBYPASSRLS;
+    END IF;
+END
+$$;
+
+-- migrations run as the user the application connects as, which has to be a member to switch
+GRANT users_app TO CURRENT_USER;
+
+GRANT USAGE ON SCHEMA public TO users_app;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO users_app;
+GRANT USAGE, SELECT, UPDATE ON ALL SEQUENCES IN SCHEMA public TO users_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO users_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT, UPDATE ON SEQUENCES TO users_app;
+
+-- entries are only ever appended
+REVOKE UPDATE, DELETE ON audit_log FROM users_app;
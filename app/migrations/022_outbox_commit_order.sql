@@ -0,0 +1,30 @@
+-- the place of an event among the events of its tenant in commit order. Ids are taken
+-- when the events are written, so a transaction committing after another one can hold
+-- the smaller ids and a watcher reading past the last id it has seen would miss them.
+-- Positions are assigned while the transaction commits instead, under a lock of the
+-- tenant that is held from then to the end of the commit only: writers of a tenant
+-- no longer wait on each other for the whole of their transactions.
+ALTER TABLE outbox_events ADD COLUMN position BIGINT;
+
+CREATE SEQUENCE outbox_events_position_seq;
+GRANT USAGE, SELECT, UPDATE ON SEQUENCE outbox_events_position_seq TO users_app;
+
+-- the events written before are committed, their ids are their order
+UPDATE outbox_events SET position = id;
+SELECT setval('outbox_events_position_seq', COALESCE((SELECT max(id) FROM outbox_events), 0) + 1, FALSE);
+
+CREATE INDEX outbox_events_position_idx ON outbox_events (tenant_id, position);
+
+CREATE FUNCTION assign_outbox_event_position() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtextextended('outbox_events:' || NEW.tenant_id::TEXT, 0));
+    UPDATE outbox_events SET position = nextval('outbox_events_position_seq') WHERE id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- deferred, so that it runs when the transaction commits
+CREATE CONSTRAINT TRIGGER outbox_events_position
+    AFTER INSERT ON outbox_events
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION assign_outbox_event_position();
@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"context"
+)
+
+// Anonymous is the actor of requests without an authenticated identity, such as the
+// email change links mailed to users.
+const Anonymous = "anonymous"
+
+// Source describes who performs a request and from where, it is recorded with every
+// audit log entry written on behalf of the request.
+type Source struct {
+	// Actor is the subject of the bearer token the request was authenticated with
+	Actor     string
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type ctxKey struct{}
+
+// WithSource returns a copy of ctx carrying the source of the request.
+func WithSource(ctx context.Context, source Source) context.Context {
+	return context.WithValue(ctx, ctxKey{}, source)
+}
+
+// FromContext returns the source of the request, an anonymous one if there is none.
+func FromContext(ctx context.Context) Source {
+	source, _ := ctx.Value(ctxKey{}).(Source)
+	if source.Actor == "" {
+		source.Actor = Anonymous
+	}
+	return source
+}
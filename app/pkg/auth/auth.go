@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"Users/internal/config"
+	"Users/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"slices"
+	"strings"
+)
+
+// minSecretLength is the shortest HS256 secret accepted, shorter ones can be brute forced.
+const minSecretLength = 32
+
+// maxSubjectLength bounds the subject, which is recorded as the actor of the audit log.
+const maxSubjectLength = 255
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	// Subject is the user or the service the token was issued to.
+	Subject string
+	// TenantID is the only tenant the request can act on.
+	TenantID string
+	// Admin is whether the token carries the admin scope, which the admin operations require.
+	Admin bool
+}
+
+type claims struct {
+	TenantID string `json:"tenant_id"`
+	// Scope is the space separated list of the scopes granted to the token.
+	Scope string `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Verifier authenticates the bearer tokens issued by the gateway.
+type Verifier struct {
+	secret     []byte
+	adminScope string
+	parser     *jwt.Parser
+}
+
+func NewVerifier(cfg config.Config) (*Verifier, error) {
+	if len(cfg.Auth.Secret) < minSecretLength {
+		return nil, fmt.Errorf("auth secret must be at least %d bytes", minSecretLength)
+	}
+	if cfg.Auth.AdminScope == "" {
+		return nil, errors.New("auth admin scope must not be empty")
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Auth.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Auth.Issuer))
+	}
+	if cfg.Auth.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Auth.Audience))
+	}
+	return &Verifier{
+		secret:     []byte(cfg.Auth.Secret),
+		adminScope: cfg.Auth.AdminScope,
+		parser:     jwt.NewParser(options...),
+	}, nil
+}
+
+// Verify checks the signature, the expiry, the issuer and the audience of token and
+// returns the identity it carries.
+func (v *Verifier) Verify(token string) (Identity, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, func(*jwt.Token) (interface{}, error) {
+		return v.secret, nil
+	})
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if c.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+	if len(c.Subject) > maxSubjectLength {
+		return Identity{}, fmt.Errorf("%w: subject longer than %d bytes", ErrInvalidToken, maxSubjectLength)
+	}
+	if !utils.IsValidUUID(c.TenantID) {
+		return Identity{}, fmt.Errorf("%w: tenant_id must be a uuid", ErrInvalidToken)
+	}
+	return Identity{
+		Subject:  c.Subject,
+		TenantID: c.TenantID,
+		Admin:    slices.Contains(strings.Fields(c.Scope), v.adminScope),
+	}, nil
+}
+
+// BearerToken returns the token of an Authorization header value, false if it is not a bearer one.
+func BearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+type ctxKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the authenticated caller of the request.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, identity)
+}
+
+// FromContext returns the authenticated caller of the request, false if there is none.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(ctxKey{}).(Identity)
+	return identity, ok
+}
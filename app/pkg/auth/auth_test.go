@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"Users/internal/config"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret = "0123456789abcdef0123456789abcdef"
+	testTenant = "5d0c8a47-1e2b-4c6f-9a3d-8b7e6f5a4c21"
+)
+
+func testToken(t *testing.T, method jwt.SigningMethod, key interface{}, c claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, c).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func newTestVerifier(t *testing.T) *Verifier {
+	t.Helper()
+	var cfg config.Config
+	cfg.Auth.Secret = testSecret
+	cfg.Auth.Issuer = "gateway"
+	cfg.Auth.AdminScope = "users:admin"
+	verifier, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier
+}
+
+func TestVerify(t *testing.T) {
+	verifier := newTestVerifier(t)
+
+	valid := claims{TenantID: testTenant, RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "admin",
+		Issuer:    "gateway",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+	identity, err := verifier.Verify(testToken(t, jwt.SigningMethodHS256, []byte(testSecret), valid))
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if identity != (Identity{Subject: "admin", TenantID: testTenant}) {
+		t.Errorf("got identity %+v", identity)
+	}
+
+	expired, noTenant, otherIssuer, noExpiry, longSubject := valid, valid, valid, valid, valid
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	longSubject.Subject = strings.Repeat("a", maxSubjectLength+1)
+	noTenant.TenantID = ""
+	otherIssuer.Issuer = "somebody"
+	noExpiry.ExpiresAt = nil
+
+	tests := map[string]string{
+		"wrong secret": testToken(t, jwt.SigningMethodHS256, []byte("another secret of thirty-two bytes"), valid),
+		"none alg":     testToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid),
+		"expired":      testToken(t, jwt.SigningMethodHS256, []byte(testSecret), expired),
+		"no expiry":    testToken(t, jwt.SigningMethodHS256, []byte(testSecret), noExpiry),
+		"no tenant":    testToken(t, jwt.SigningMethodHS256, []byte(testSecret), noTenant),
+		"other issuer": testToken(t, jwt.SigningMethodHS256, []byte(testSecret), otherIssuer),
+		"long subject": testToken(t, jwt.SigningMethodHS256, []byte(testSecret), longSubject),
+		"malformed":    "not.a.token",
+		"empty":        "",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := verifier.Verify(token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("got error %v, want an invalid token", err)
+			}
+		})
+	}
+}
+
+func TestVerifyAdminScope(t *testing.T) {
+	verifier := newTestVerifier(t)
+	tests := map[string]bool{
+		"":                        false,
+		"users:read":              false,
+		"users:admin":             true,
+		"users:read users:admin":  true,
+		"users:administrator":     false,
+		"users:admin:impersonate": false,
+	}
+	for scope, admin := range tests {
+		c := claims{TenantID: testTenant, Scope: scope, RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "admin",
+			Issuer:    "gateway",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}}
+		identity, err := verifier.Verify(testToken(t, jwt.SigningMethodHS256, []byte(testSecret), c))
+		if err != nil {
+			t.Fatalf("token with scope %q rejected: %v", scope, err)
+		}
+		if identity.Admin != admin {
+			t.Errorf("scope %q gave admin %t, want %t", scope, identity.Admin, admin)
+		}
+	}
+}
+
+func TestNewVerifierRejectsShortSecret(t *testing.T) {
+	var cfg config.Config
+	cfg.Auth.Secret = "short"
+	if _, err := NewVerifier(cfg); err == nil {
+		t.Error("short secret accepted")
+	}
+}
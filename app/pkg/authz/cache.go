@@ -0,0 +1,104 @@
+package authz
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Cache keeps check results per tenant for a short time. Writing tuples or the schema
+// of a tenant invalidates its results in this process only, other instances keep
+// serving theirs until they expire, so the TTL bounds how stale a result can be.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu        sync.Mutex
+	entries   map[string]cacheEntry
+	revisions map[string]uint64
+}
+
+type cacheEntry struct {
+	allowed   bool
+	expiresAt time.Time
+}
+
+// NewCache returns a cache holding up to maxEntries results, a zero TTL disables it.
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]cacheEntry),
+		revisions:  make(map[string]uint64),
+	}
+}
+
+func (c *Cache) key(tenant string, revision uint64, check string) string {
+	return tenant + "\x00" + strconv.FormatUint(revision, 10) + "\x00" + check
+}
+
+// Revision returns the current revision of the results of the tenant. A check takes it
+// before it reads the schema or the tuples, and gets and sets its result at it.
+func (c *Cache) Revision(tenant string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.revisions[tenant]
+}
+
+func (c *Cache) Get(tenant string, revision uint64, check string) (allowed, ok bool) {
+	if c.ttl <= 0 {
+		return false, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if revision != c.revisions[tenant] {
+		return false, false
+	}
+	key := c.key(tenant, revision, check)
+	entry, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return false, false
+	}
+	return entry.allowed, true
+}
+
+// Set keeps the result of a check that started at the revision. The result is dropped
+// if the tenant has been invalidated since, it may have been computed from what the
+// invalidating write replaced.
+func (c *Cache) Set(tenant string, revision uint64, check string, allowed bool) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if revision != c.revisions[tenant] {
+		return
+	}
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		for key, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		// all entries are live, starting over is cheaper than tracking their use
+		if len(c.entries) >= c.maxEntries {
+			c.entries = make(map[string]cacheEntry)
+		}
+	}
+	c.entries[c.key(tenant, revision, check)] = cacheEntry{allowed: allowed, expiresAt: now.Add(c.ttl)}
+}
+
+// Invalidate drops the results of the tenant, they are no longer reachable and
+// expire from the cache with time. It is called once the write has committed.
+func (c *Cache) Invalidate(tenant string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revisions[tenant]++
+}
@@ -0,0 +1,60 @@
+package authz
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	testTenant = "5d0c8a47-1e2b-4c6f-9a3d-8b7e6f5a4c21"
+	testCheck  = "document:readme#viewer@user:alice"
+)
+
+func TestCacheKeepsResultsOfTheRevision(t *testing.T) {
+	cache := NewCache(time.Minute, 10)
+
+	revision := cache.Revision(testTenant)
+	cache.Set(testTenant, revision, testCheck, true)
+	if allowed, ok := cache.Get(testTenant, cache.Revision(testTenant), testCheck); !ok || !allowed {
+		t.Fatalf("got allowed %t, cached %t, want the cached result", allowed, ok)
+	}
+
+	cache.Invalidate(testTenant)
+	if _, ok := cache.Get(testTenant, cache.Revision(testTenant), testCheck); ok {
+		t.Error("result is still cached after the tenant was invalidated")
+	}
+	if _, ok := cache.Get(testTenant, revision, testCheck); ok {
+		t.Error("result is served to a check that started before the invalidation")
+	}
+}
+
+func TestCacheDropsResultsOfChecksOverlappingAWrite(t *testing.T) {
+	cache := NewCache(time.Minute, 10)
+
+	// a check starts, reads the tuple before it is deleted and finishes after
+	revision := cache.Revision(testTenant)
+	if _, ok := cache.Get(testTenant, revision, testCheck); ok {
+		t.Fatal("empty cache has a result")
+	}
+	cache.Invalidate(testTenant)
+	cache.Set(testTenant, revision, testCheck, true)
+
+	if allowed, ok := cache.Get(testTenant, cache.Revision(testTenant), testCheck); ok {
+		t.Errorf("the result read before the write is cached as allowed %t", allowed)
+	}
+
+	// other tenants are not affected
+	other := "00000000-0000-0000-0000-000000000000"
+	cache.Set(other, cache.Revision(other), testCheck, true)
+	if _, ok := cache.Get(other, cache.Revision(other), testCheck); !ok {
+		t.Error("result of another tenant is not cached")
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	cache := NewCache(0, 10)
+	cache.Set(testTenant, cache.Revision(testTenant), testCheck, true)
+	if _, ok := cache.Get(testTenant, cache.Revision(testTenant), testCheck); ok {
+		t.Error("disabled cache returned a result")
+	}
+}
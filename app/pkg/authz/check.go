@@ -0,0 +1,216 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxDepth bounds the chain of relations a check or an expansion follows.
+const DefaultMaxDepth = 32
+
+var ErrMaxDepth = errors.New("maximum depth of relations exceeded")
+
+// TupleReader reads the stored tuples of a relation on an object.
+type TupleReader interface {
+	FindTuples(ctx context.Context, object Object, relation string) ([]Tuple, error)
+}
+
+// Checker evaluates the relations of a schema over stored tuples.
+type Checker struct {
+	schema   *Schema
+	reader   TupleReader
+	maxDepth int
+}
+
+func NewChecker(schema *Schema, reader TupleReader) *Checker {
+	return &Checker{
+		schema:   schema,
+		reader:   reader,
+		maxDepth: DefaultMaxDepth,
+	}
+}
+
+// Check reports whether the subject has the relation on the object.
+func (c *Checker) Check(ctx context.Context, object Object, relation string, subject Subject) (bool, error) {
+	return c.check(ctx, object, relation, subject, make(map[Subject]bool))
+}
+
+// check evaluates the relation, path holds the relations being evaluated to end cycles.
+func (c *Checker) check(ctx context.Context, object Object, relation string, subject Subject,
+	path map[Subject]bool) (bool, error) {
+	// the subject has a relation on its own object, e.g. group:admins#member is group:admins#member
+	if subject.Object == object && subject.Relation == relation {
+		return true, nil
+	}
+	current := Subject{Object: object, Relation: relation}
+	if path[current] {
+		return false, nil
+	}
+	if len(path) >= c.maxDepth {
+		return false, ErrMaxDepth
+	}
+	rel, err := c.schema.Relation(object.Namespace, relation)
+	if err != nil {
+		return false, err
+	}
+
+	path[current] = true
+	defer delete(path, current)
+	return c.evaluate(ctx, object, rel, rel.Rewrite, subject, path)
+}
+
+func (c *Checker) evaluate(ctx context.Context, object Object, relation *Relation, expr Expr, subject Subject,
+	path map[Subject]bool) (bool, error) {
+	switch e := expr.(type) {
+	case This:
+		tuples, err := c.reader.FindTuples(ctx, object, relation.Name)
+		if err != nil {
+			return false, err
+		}
+		for _, tuple := range tuples {
+			if tuple.Subject == subject {
+				return true, nil
+			}
+		}
+		for _, tuple := range tuples {
+			if tuple.Subject.Relation == "" {
+				continue
+			}
+			if _, err = c.schema.Relation(tuple.Subject.Namespace, tuple.Subject.Relation); err != nil {
+				// usersets of relations removed from the schema are ignored
+				continue
+			}
+			ok, err := c.check(ctx, tuple.Subject.Object, tuple.Subject.Relation, subject, path)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case ComputedUserset:
+		return c.check(ctx, object, e.Relation, subject, path)
+	case TupleToUserset:
+		tuples, err := c.reader.FindTuples(ctx, object, e.Tupleset)
+		if err != nil {
+			return false, err
+		}
+		for _, tuple := range tuples {
+			if _, err = c.schema.Relation(tuple.Subject.Namespace, e.Relation); err != nil {
+				// objects of other namespaces in the tupleset do not have the relation
+				continue
+			}
+			ok, err := c.check(ctx, tuple.Subject.Object, e.Relation, subject, path)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case SetOperation:
+		left, err := c.evaluate(ctx, object, relation, e.Left, subject, path)
+		if err != nil {
+			return false, err
+		}
+		switch e.Op {
+		case Union:
+			if left {
+				return true, nil
+			}
+		case Intersection, Exclusion:
+			if !left {
+				return false, nil
+			}
+		}
+		right, err := c.evaluate(ctx, object, relation, e.Right, subject, path)
+		if err != nil {
+			return false, err
+		}
+		if e.Op == Exclusion {
+			return !right, nil
+		}
+		return right, nil
+	}
+	return false, fmt.Errorf("unknown rewrite %T", expr)
+}
+
+// Node is a node of the tree returned by Expand. Leaves list the subjects of the tuples
+// of a relation, usersets among them are not expanded further.
+type Node struct {
+	// Operation is union, intersection or exclusion, it is empty for leaves
+	Operation string   `json:"operation,omitempty"`
+	Object    Object   `json:"object"`
+	Relation  string   `json:"relation"`
+	Subjects  []string `json:"subjects,omitempty"`
+	Children  []Node   `json:"children,omitempty"`
+}
+
+var operationNames = map[Operator]string{
+	Union:        "union",
+	Intersection: "intersection",
+	Exclusion:    "exclusion",
+}
+
+// Expand returns the tree of subjects having the relation on the object, following the
+// rewrites of the schema.
+func (c *Checker) Expand(ctx context.Context, object Object, relation string) (Node, error) {
+	return c.expand(ctx, object, relation, 0)
+}
+
+func (c *Checker) expand(ctx context.Context, object Object, relation string, depth int) (Node, error) {
+	if depth > c.maxDepth {
+		return Node{}, ErrMaxDepth
+	}
+	rel, err := c.schema.Relation(object.Namespace, relation)
+	if err != nil {
+		return Node{}, err
+	}
+	return c.expandExpr(ctx, object, rel, rel.Rewrite, depth)
+}
+
+func (c *Checker) expandExpr(ctx context.Context, object Object, relation *Relation, expr Expr,
+	depth int) (Node, error) {
+	node := Node{Object: object, Relation: relation.Name}
+	switch e := expr.(type) {
+	case This:
+		tuples, err := c.reader.FindTuples(ctx, object, relation.Name)
+		if err != nil {
+			return Node{}, err
+		}
+		node.Subjects = make([]string, 0, len(tuples))
+		for _, tuple := range tuples {
+			node.Subjects = append(node.Subjects, tuple.Subject.String())
+		}
+		return node, nil
+	case ComputedUserset:
+		return c.expand(ctx, object, e.Relation, depth+1)
+	case TupleToUserset:
+		tuples, err := c.reader.FindTuples(ctx, object, e.Tupleset)
+		if err != nil {
+			return Node{}, err
+		}
+		node.Operation = operationNames[Union]
+		for _, tuple := range tuples {
+			if _, err = c.schema.Relation(tuple.Subject.Namespace, e.Relation); err != nil {
+				continue
+			}
+			child, err := c.expand(ctx, tuple.Subject.Object, e.Relation, depth+1)
+			if err != nil {
+				return Node{}, err
+			}
+			node.Children = append(node.Children, child)
+		}
+		return node, nil
+	case SetOperation:
+		left, err := c.expandExpr(ctx, object, relation, e.Left, depth)
+		if err != nil {
+			return Node{}, err
+		}
+		right, err := c.expandExpr(ctx, object, relation, e.Right, depth)
+		if err != nil {
+			return Node{}, err
+		}
+		node.Operation = operationNames[e.Op]
+		node.Children = []Node{left, right}
+		return node, nil
+	}
+	return Node{}, fmt.Errorf("unknown rewrite %T", expr)
+}
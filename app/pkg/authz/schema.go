@@ -0,0 +1,419 @@
+package authz
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Schema declares the namespaces, their relations and how each relation is computed.
+// Its source is written in a small language:
+//
+//	// a comment
+//	namespace user {}
+//
+//	namespace group {
+//		relation member
+//	}
+//
+//	namespace document {
+//		relation parent
+//		relation owner
+//		relation editor = this | owner
+//		relation viewer = this | editor | parent->viewer
+//		relation commenter = viewer & (this - banned)
+//		relation banned
+//	}
+//
+// A relation without a rewrite only holds through its own tuples. The rewrite operands
+// are this (the tuples of the relation), another relation of the same object, and
+// tupleset->relation, which follows the tuples of the tupleset relation to their subject
+// objects and takes the relation there. The operators | (union), & (intersection) and
+// - (exclusion) are applied left to right, parentheses group.
+type Schema struct {
+	Namespaces map[string]*Namespace
+}
+
+type Namespace struct {
+	Name      string
+	Relations map[string]*Relation
+}
+
+type Relation struct {
+	Name    string
+	Rewrite Expr
+}
+
+// AllowsDirect reports whether tuples can be written for the relation.
+func (r *Relation) AllowsDirect() bool {
+	return containsThis(r.Rewrite)
+}
+
+func containsThis(expr Expr) bool {
+	switch e := expr.(type) {
+	case This:
+		return true
+	case SetOperation:
+		return containsThis(e.Left) || containsThis(e.Right)
+	}
+	return false
+}
+
+// Expr is a relation rewrite: This, ComputedUserset, TupleToUserset or SetOperation.
+type Expr interface {
+	String() string
+}
+
+// This is the set of subjects of the tuples of the relation itself.
+type This struct{}
+
+func (This) String() string {
+	return "this"
+}
+
+// ComputedUserset is the set of subjects having another relation on the same object.
+type ComputedUserset struct {
+	Relation string
+}
+
+func (c ComputedUserset) String() string {
+	return c.Relation
+}
+
+// TupleToUserset follows the tuples of the tupleset relation to their subject objects
+// and takes the subjects having the relation on those.
+type TupleToUserset struct {
+	Tupleset string
+	Relation string
+}
+
+func (t TupleToUserset) String() string {
+	return t.Tupleset + "->" + t.Relation
+}
+
+type Operator string
+
+const (
+	Union        Operator = "|"
+	Intersection Operator = "&"
+	Exclusion    Operator = "-"
+)
+
+type SetOperation struct {
+	Op    Operator
+	Left  Expr
+	Right Expr
+}
+
+func (s SetOperation) String() string {
+	return fmt.Sprintf("(%s %s %s)", s.Left, s.Op, s.Right)
+}
+
+// Relation returns the relation of the namespace, an error names what is undeclared.
+func (s *Schema) Relation(namespace, relation string) (*Relation, error) {
+	ns, ok := s.Namespaces[namespace]
+	if !ok {
+		return nil, fmt.Errorf("namespace %q is not declared in the schema", namespace)
+	}
+	rel, ok := ns.Relations[relation]
+	if !ok {
+		return nil, fmt.Errorf("relation %q is not declared in namespace %q", relation, namespace)
+	}
+	return rel, nil
+}
+
+// ValidateTuple checks that the tuple refers to declared namespaces and relations and
+// that its relation can be written directly.
+func (s *Schema) ValidateTuple(tuple Tuple) error {
+	relation, err := s.Relation(tuple.Object.Namespace, tuple.Relation)
+	if err != nil {
+		return err
+	}
+	if !relation.AllowsDirect() {
+		return fmt.Errorf("relation %q of namespace %q is computed, its rewrite does not include this",
+			tuple.Relation, tuple.Object.Namespace)
+	}
+	if tuple.Subject.Relation != "" {
+		_, err = s.Relation(tuple.Subject.Namespace, tuple.Subject.Relation)
+		return err
+	}
+	if _, ok := s.Namespaces[tuple.Subject.Namespace]; !ok {
+		return fmt.Errorf("namespace %q is not declared in the schema", tuple.Subject.Namespace)
+	}
+	return nil
+}
+
+// ParseSchema parses and validates the source of a schema.
+func ParseSchema(source string) (*Schema, error) {
+	p := &parser{lexer: lexer{src: []rune(source), line: 1, col: 1}}
+	p.next()
+
+	schema := &Schema{Namespaces: make(map[string]*Namespace)}
+	for p.tok.kind != tokenEOF {
+		ns, err := p.namespace()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := schema.Namespaces[ns.Name]; ok {
+			return nil, fmt.Errorf("namespace %q is declared twice", ns.Name)
+		}
+		schema.Namespaces[ns.Name] = ns
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	for _, ns := range schema.Namespaces {
+		for _, rel := range ns.Relations {
+			if err := validateExpr(ns, rel, rel.Rewrite); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return schema, nil
+}
+
+func validateExpr(ns *Namespace, rel *Relation, expr Expr) error {
+	switch e := expr.(type) {
+	case ComputedUserset:
+		if _, ok := ns.Relations[e.Relation]; !ok {
+			return fmt.Errorf("relation %s.%s refers to undeclared relation %q", ns.Name, rel.Name, e.Relation)
+		}
+	case TupleToUserset:
+		if _, ok := ns.Relations[e.Tupleset]; !ok {
+			return fmt.Errorf("relation %s.%s refers to undeclared relation %q", ns.Name, rel.Name, e.Tupleset)
+		}
+	case SetOperation:
+		if err := validateExpr(ns, rel, e.Left); err != nil {
+			return err
+		}
+		return validateExpr(ns, rel, e.Right)
+	}
+	return nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenSymbol
+)
+
+type token struct {
+	kind      tokenKind
+	text      string
+	line, col int
+}
+
+type lexer struct {
+	src       []rune
+	pos       int
+	line, col int
+}
+
+func (l *lexer) advance() rune {
+	r := l.src[l.pos]
+	l.pos++
+	if r == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return r
+}
+
+func (l *lexer) skipSpaceAndComments() {
+	for l.pos < len(l.src) {
+		switch {
+		case unicode.IsSpace(l.src[l.pos]):
+			l.advance()
+		case l.src[l.pos] == '/' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '/':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpaceAndComments()
+	tok := token{line: l.line, col: l.col}
+	if l.pos >= len(l.src) {
+		tok.kind = tokenEOF
+		return tok, nil
+	}
+
+	r := l.src[l.pos]
+	switch {
+	case r == '_' || unicode.IsLetter(r):
+		var ident strings.Builder
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || unicode.IsLetter(l.src[l.pos]) ||
+			unicode.IsDigit(l.src[l.pos])) {
+			ident.WriteRune(l.advance())
+		}
+		tok.kind, tok.text = tokenIdent, ident.String()
+	case r == '-' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '>':
+		l.advance()
+		l.advance()
+		tok.kind, tok.text = tokenSymbol, "->"
+	case strings.ContainsRune("{}=|&-()", r):
+		l.advance()
+		tok.kind, tok.text = tokenSymbol, string(r)
+	default:
+		return tok, fmt.Errorf("%d:%d: unexpected character %q", tok.line, tok.col, r)
+	}
+	return tok, nil
+}
+
+type parser struct {
+	lexer lexer
+	tok   token
+	err   error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	tok, err := p.lexer.next()
+	if err != nil {
+		p.err = err
+		p.tok = token{kind: tokenEOF}
+		return
+	}
+	p.tok = tok
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	return fmt.Errorf("%d:%d: %s", p.tok.line, p.tok.col, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) describe() string {
+	if p.tok.kind == tokenEOF {
+		return "end of schema"
+	}
+	return fmt.Sprintf("%q", p.tok.text)
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	if p.tok.kind != tokenSymbol || p.tok.text != symbol {
+		return p.errorf("expected %q, found %s", symbol, p.describe())
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if p.tok.kind != tokenIdent || p.tok.text != keyword {
+		return p.errorf("expected %q, found %s", keyword, p.describe())
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) name(what string) (string, error) {
+	if p.tok.kind != tokenIdent || !validName(p.tok.text) || p.tok.text == "this" {
+		return "", p.errorf("expected %s name, found %s", what, p.describe())
+	}
+	name := p.tok.text
+	p.next()
+	return name, nil
+}
+
+func (p *parser) namespace() (*Namespace, error) {
+	if err := p.expectKeyword("namespace"); err != nil {
+		return nil, err
+	}
+	name, err := p.name("namespace")
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expectSymbol("{"); err != nil {
+		return nil, err
+	}
+
+	ns := &Namespace{Name: name, Relations: make(map[string]*Relation)}
+	for !(p.tok.kind == tokenSymbol && p.tok.text == "}") {
+		rel, err := p.relation()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := ns.Relations[rel.Name]; ok {
+			return nil, fmt.Errorf("relation %q is declared twice in namespace %q", rel.Name, ns.Name)
+		}
+		ns.Relations[rel.Name] = rel
+	}
+	p.next()
+	return ns, p.err
+}
+
+func (p *parser) relation() (*Relation, error) {
+	if err := p.expectKeyword("relation"); err != nil {
+		return nil, err
+	}
+	name, err := p.name("relation")
+	if err != nil {
+		return nil, err
+	}
+	rel := &Relation{Name: name, Rewrite: This{}}
+	if p.tok.kind == tokenSymbol && p.tok.text == "=" {
+		p.next()
+		if rel.Rewrite, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	return rel, nil
+}
+
+func (p *parser) expr() (Expr, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokenSymbol && (p.tok.text == "|" || p.tok.text == "&" || p.tok.text == "-") {
+		op := Operator(p.tok.text)
+		p.next()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = SetOperation{Op: op, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) term() (Expr, error) {
+	if p.tok.kind == tokenSymbol && p.tok.text == "(" {
+		p.next()
+		expr, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expectSymbol(")")
+	}
+	if p.tok.kind == tokenIdent && p.tok.text == "this" {
+		p.next()
+		return This{}, nil
+	}
+
+	relation, err := p.name("relation")
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenSymbol && p.tok.text == "->" {
+		p.next()
+		target, err := p.name("relation")
+		if err != nil {
+			return nil, err
+		}
+		return TupleToUserset{Tupleset: relation, Relation: target}, nil
+	}
+	return ComputedUserset{Relation: relation}, nil
+}
@@ -0,0 +1,129 @@
+package authz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Object is a resource, written namespace:id, e.g. document:readme.
+type Object struct {
+	Namespace string
+	ID        string
+}
+
+func ParseObject(s string) (Object, error) {
+	namespace, id, ok := strings.Cut(s, ":")
+	if !ok || !validName(namespace) || !validID(id) {
+		return Object{}, fmt.Errorf("object %q must have the form namespace:id", s)
+	}
+	return Object{Namespace: namespace, ID: id}, nil
+}
+
+func (o Object) String() string {
+	return o.Namespace + ":" + o.ID
+}
+
+func (o Object) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+func (o *Object) UnmarshalText(text []byte) error {
+	object, err := ParseObject(string(text))
+	if err != nil {
+		return err
+	}
+	*o = object
+	return nil
+}
+
+// Subject is either an object, e.g. user:42, or the set of subjects having a relation
+// on an object, e.g. group:admins#member.
+type Subject struct {
+	Object
+	Relation string
+}
+
+func ParseSubject(s string) (Subject, error) {
+	object, relation, hasRelation := strings.Cut(s, "#")
+	parsed, err := ParseObject(object)
+	if err != nil {
+		return Subject{}, fmt.Errorf("subject %q must have the form namespace:id or namespace:id#relation", s)
+	}
+	if hasRelation && !validName(relation) {
+		return Subject{}, fmt.Errorf("subject %q has an invalid relation", s)
+	}
+	return Subject{Object: parsed, Relation: relation}, nil
+}
+
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Object.String()
+	}
+	return s.Object.String() + "#" + s.Relation
+}
+
+func (s Subject) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Subject) UnmarshalText(text []byte) error {
+	subject, err := ParseSubject(string(text))
+	if err != nil {
+		return err
+	}
+	*s = subject
+	return nil
+}
+
+// Tuple states that the subject has the relation on the object,
+// written object#relation@subject, e.g. document:readme#viewer@group:admins#member.
+type Tuple struct {
+	Object   Object  `json:"object"`
+	Relation string  `json:"relation"`
+	Subject  Subject `json:"subject"`
+}
+
+func ParseTuple(s string) (Tuple, error) {
+	objectRelation, subject, ok := strings.Cut(s, "@")
+	if !ok {
+		return Tuple{}, fmt.Errorf("tuple %q must have the form object#relation@subject", s)
+	}
+	object, relation, ok := strings.Cut(objectRelation, "#")
+	if !ok || !validName(relation) {
+		return Tuple{}, fmt.Errorf("tuple %q must have the form object#relation@subject", s)
+	}
+	parsedObject, err := ParseObject(object)
+	if err != nil {
+		return Tuple{}, err
+	}
+	parsedSubject, err := ParseSubject(subject)
+	if err != nil {
+		return Tuple{}, err
+	}
+	return Tuple{Object: parsedObject, Relation: relation, Subject: parsedSubject}, nil
+}
+
+func (t Tuple) String() string {
+	return t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// validName accepts the identifiers of namespaces and relations.
+func validName(s string) bool {
+	if s == "" || len(s) > 64 {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c == '_':
+		case (c >= '0' && c <= '9') && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validID accepts object ids, which must not contain the tuple separators.
+func validID(s string) bool {
+	return s != "" && len(s) <= 255 && !strings.ContainsAny(s, ":#@ \t\r\n")
+}
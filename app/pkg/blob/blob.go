@@ -0,0 +1,46 @@
+package blob
+
+import (
+	"Users/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// Storage keeps binary objects under slash separated keys.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get returns ErrNotFound if there is no object with the key.
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	// Delete ignores missing objects.
+	Delete(ctx context.Context, key string) error
+}
+
+func NewStorage(ctx context.Context, cfg config.Config) (Storage, error) {
+	switch cfg.Blob.Driver {
+	case "local":
+		return NewLocalStorage(cfg.Blob.Local.Path)
+	case "s3":
+		return NewS3Storage(ctx, S3Options{
+			Endpoint:  cfg.Blob.S3.Endpoint,
+			Region:    cfg.Blob.S3.Region,
+			Bucket:    cfg.Blob.S3.Bucket,
+			AccessKey: cfg.Blob.S3.AccessKey,
+			SecretKey: cfg.Blob.S3.SecretKey,
+			UseSSL:    cfg.Blob.S3.UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob storage driver %q", cfg.Blob.Driver)
+	}
+}
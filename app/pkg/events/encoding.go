@@ -0,0 +1,74 @@
+package events
+
+import (
+	usersv1 "Users/api/users/v1"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Encoding string
+
+const (
+	EncodingJSON     Encoding = "json"
+	EncodingProtobuf Encoding = "protobuf"
+)
+
+func ParseEncoding(value string) (Encoding, error) {
+	switch encoding := Encoding(value); encoding {
+	case EncodingJSON, EncodingProtobuf:
+		return encoding, nil
+	}
+	return "", fmt.Errorf("unknown events encoding %q", value)
+}
+
+// ContentType is sent along with the encoded events.
+func (e Encoding) ContentType() string {
+	if e == EncodingProtobuf {
+		return "application/x-protobuf; messageType=" + protobufMessageType
+	}
+	return "application/json"
+}
+
+// protobufMessageType is the message of the protobuf encoding, defined in api/users/v1/events.proto
+var protobufMessageType = string((&usersv1.UserEvent{}).ProtoReflect().Descriptor().FullName())
+
+// Encode returns the event in the encoding, the JSON encoding is the one written by the file driver.
+func (e Encoding) Encode(event Event) ([]byte, error) {
+	if e != EncodingProtobuf {
+		return json.Marshal(event)
+	}
+
+	message, err := NewProto(event)
+	if err != nil {
+		return nil, err
+	}
+	b, err := proto.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return b, nil
+}
+
+// NewProto returns the event as the message of the protobuf encoding.
+func NewProto(event Event) (*usersv1.UserEvent, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(event.Payload, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event payload: %w", err)
+	}
+	payload, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert event payload: %w", err)
+	}
+	return &usersv1.UserEvent{
+		Id:         event.ID,
+		TenantId:   event.TenantID,
+		Type:       event.Type,
+		UserUuid:   event.UserUUID,
+		Payload:    payload,
+		OccurredAt: timestamppb.New(event.OccurredAt),
+		Position:   event.Position,
+	}, nil
+}
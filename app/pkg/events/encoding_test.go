@@ -0,0 +1,74 @@
+package events
+
+import (
+	usersv1 "Users/api/users/v1"
+	"encoding/json"
+	"google.golang.org/protobuf/proto"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testEvent() Event {
+	return Event{
+		ID:         17,
+		TenantID:   "5d0c8a47-1e2b-4c6f-9a3d-8b7e6f5a4c21",
+		Type:       "UserUpdated",
+		UserUUID:   "3f1c1c5e-4b2a-4f4e-9a43-0c1d2e3f4a5b",
+		Payload:    json.RawMessage(`{"name":"Joe","phone":{"verified":true}}`),
+		OccurredAt: time.Date(2024, 8, 1, 12, 30, 0, 500, time.UTC),
+	}
+}
+
+// checkUserEvent checks that data is the protobuf encoding of event.
+func checkUserEvent(t *testing.T, data []byte, event Event) {
+	t.Helper()
+	var message usersv1.UserEvent
+	if err := proto.Unmarshal(data, &message); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", protobufMessageType, err)
+	}
+	if message.GetId() != event.ID || message.GetTenantId() != event.TenantID ||
+		message.GetType() != event.Type || message.GetUserUuid() != event.UserUUID {
+		t.Errorf("decoded %v, want event %d %s of user %s in tenant %s",
+			&message, event.ID, event.Type, event.UserUUID, event.TenantID)
+	}
+	if !message.GetOccurredAt().AsTime().Equal(event.OccurredAt) {
+		t.Errorf("occurred at %s, want %s", message.GetOccurredAt().AsTime(), event.OccurredAt)
+	}
+	var want map[string]interface{}
+	if err := json.Unmarshal(event.Payload, &want); err != nil {
+		t.Fatal(err)
+	}
+	if got := message.GetPayload().AsMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("payload %v, want %v", got, want)
+	}
+}
+
+func TestEncodeProtobuf(t *testing.T) {
+	event := testEvent()
+	data, err := EncodingProtobuf.Encode(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkUserEvent(t, data, event)
+
+	want := "application/x-protobuf; messageType=users.v1.UserEvent"
+	if got := EncodingProtobuf.ContentType(); got != want {
+		t.Errorf("content type %q, want %q", got, want)
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	event := testEvent()
+	data, err := EncodingJSON.Encode(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Event
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID != event.ID || decoded.Type != event.Type || !decoded.OccurredAt.Equal(event.OccurredAt) {
+		t.Errorf("decoded %+v, want %+v", decoded, event)
+	}
+}
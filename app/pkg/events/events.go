@@ -0,0 +1,110 @@
+package events
+
+import (
+	"Users/internal/config"
+	"Users/pkg/logging"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Event is a domain event of the outbox. IDs grow with every event of a tenant, so
+// consumers can drop events delivered again.
+type Event struct {
+	ID int64 `json:"id"`
+	// Position orders the events of a tenant by the commit of their changes, watches
+	// resume after it. It is only set on watched events.
+	Position   int64           `json:"position,omitempty"`
+	TenantID   string          `json:"tenant_id"`
+	Type       string          `json:"type"`
+	UserUUID   string          `json:"user_uuid"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+type Publisher interface {
+	// Publish returns once the event is accepted, an error makes the relay retry it.
+	Publish(ctx context.Context, event Event) error
+	Close() error
+}
+
+func NewPublisher(cfg config.Config, logger *logging.Logger) (Publisher, error) {
+	switch cfg.Events.Driver {
+	case "log":
+		return &logPublisher{logger: logger}, nil
+	case "file":
+		return NewFilePublisher(cfg.Events.File.Path)
+	case "kafka":
+		encoding, err := ParseEncoding(cfg.Events.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		return NewKafkaPublisher(cfg.Events.Kafka.Brokers, cfg.Events.Kafka.Topic, encoding)
+	case "nats":
+		encoding, err := ParseEncoding(cfg.Events.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		return NewNATSPublisher(cfg.Events.NATS.URL, cfg.Events.NATS.Subject, encoding)
+	default:
+		return nil, fmt.Errorf("unknown events driver %q", cfg.Events.Driver)
+	}
+}
+
+// logPublisher writes events to the log instead of publishing them, for local development.
+type logPublisher struct {
+	logger *logging.Logger
+}
+
+func (p *logPublisher) Publish(_ context.Context, event Event) error {
+	p.logger.Infof("event %d %s of user %s: %s", event.ID, event.Type, event.UserUUID, event.Payload)
+	return nil
+}
+
+func (p *logPublisher) Close() error {
+	return nil
+}
+
+// filePublisher appends events to a file as JSON lines, so that local tools and
+// manual tests can follow them.
+type filePublisher struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFilePublisher(path string) (Publisher, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create events directory: %w", err)
+	}
+	return &filePublisher{path: path}, nil
+}
+
+func (p *filePublisher) Publish(ctx context.Context, event Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	file, err := os.OpenFile(p.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open events file: %w", err)
+	}
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return file.Close()
+}
+
+func (p *filePublisher) Close() error {
+	return nil
+}
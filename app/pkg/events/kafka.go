@@ -0,0 +1,62 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"strconv"
+	"time"
+)
+
+// kafkaPublisher writes events to a topic keyed by the user UUID, so that the events
+// of a user land on one partition and keep their order.
+type kafkaPublisher struct {
+	writer   *kafka.Writer
+	encoding Encoding
+}
+
+func NewKafkaPublisher(brokers []string, topic string, encoding Encoding) (Publisher, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("kafka brokers must not be empty")
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("kafka topic must not be empty")
+	}
+	return &kafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			// the relay publishes one event at a time and waits for it
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		encoding: encoding,
+	}, nil
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, event Event) error {
+	value, err := p.encoding.Encode(event)
+	if err != nil {
+		return err
+	}
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.UserUUID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte(p.encoding.ContentType())},
+			{Key: "event-id", Value: []byte(strconv.FormatInt(event.ID, 10))},
+			{Key: "event-type", Value: []byte(event.Type)},
+			{Key: "tenant-id", Value: []byte(event.TenantID)},
+		},
+		Time: event.OccurredAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write event to kafka: %w", err)
+	}
+	return nil
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
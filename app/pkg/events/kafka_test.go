@@ -0,0 +1,106 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/segmentio/kafka-go"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func randomName(t *testing.T, prefix string) string {
+	t.Helper()
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return prefix + hex.EncodeToString(b)
+}
+
+// newKafkaTestTopic creates a topic of its own on the Kafka of docker-compose.yml, or the
+// brokers at TEST_KAFKA_BROKERS, separated by commas.
+func newKafkaTestTopic(t *testing.T) ([]string, string) {
+	t.Helper()
+	value := os.Getenv("TEST_KAFKA_BROKERS")
+	if value == "" {
+		t.Skip("TEST_KAFKA_BROKERS is not set, e.g. localhost:9092 for the Kafka of docker-compose.yml")
+	}
+	brokers := strings.Split(value, ",")
+	topic := randomName(t, "users-events-test-")
+
+	conn, err := kafka.Dial("tcp", brokers[0])
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", brokers[0], err)
+	}
+	defer conn.Close()
+	controller, err := conn.Controller()
+	if err != nil {
+		t.Fatalf("failed to find the kafka controller: %v", err)
+	}
+	controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		t.Fatalf("failed to connect to the kafka controller: %v", err)
+	}
+	defer controllerConn.Close()
+	err = controllerConn.CreateTopics(kafka.TopicConfig{Topic: topic, NumPartitions: 1, ReplicationFactor: 1})
+	if err != nil {
+		t.Fatalf("failed to create topic %s: %v", topic, err)
+	}
+	t.Cleanup(func() {
+		if conn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port))); err == nil {
+			_ = conn.DeleteTopics(topic)
+			_ = conn.Close()
+		}
+	})
+	return brokers, topic
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	brokers, topic := newKafkaTestTopic(t)
+	publisher, err := NewKafkaPublisher(brokers, topic, EncodingProtobuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer publisher.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	event := testEvent()
+	if err = publisher.Publish(ctx, event); err != nil {
+		t.Fatalf("failed to publish: %v", err)
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{Brokers: brokers, Topic: topic, Partition: 0})
+	defer reader.Close()
+	message, err := reader.ReadMessage(ctx)
+	if err != nil {
+		t.Fatalf("failed to read the event: %v", err)
+	}
+
+	if string(message.Key) != event.UserUUID {
+		t.Errorf("key %q, want the user uuid %q", message.Key, event.UserUUID)
+	}
+	wantHeaders := map[string]string{
+		"content-type": EncodingProtobuf.ContentType(),
+		"event-id":     strconv.FormatInt(event.ID, 10),
+		"event-type":   event.Type,
+		"tenant-id":    event.TenantID,
+	}
+	for _, header := range message.Headers {
+		if want, ok := wantHeaders[header.Key]; ok {
+			if string(header.Value) != want {
+				t.Errorf("header %s is %q, want %q", header.Key, header.Value, want)
+			}
+			delete(wantHeaders, header.Key)
+		}
+	}
+	for key := range wantHeaders {
+		t.Errorf("header %s is missing", key)
+	}
+	checkUserEvent(t, message.Value, event)
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"strconv"
+)
+
+// natsPublisher publishes events to JetStream on the subject followed by the event type.
+// The message id lets JetStream drop events the relay publishes again.
+type natsPublisher struct {
+	conn     *nats.Conn
+	js       nats.JetStreamContext
+	subject  string
+	encoding Encoding
+}
+
+func NewNATSPublisher(url, subject string, encoding Encoding) (Publisher, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("nats subject must not be empty")
+	}
+	conn, err := nats.Connect(url, nats.Name("users"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+	return &natsPublisher{
+		conn:     conn,
+		js:       js,
+		subject:  subject,
+		encoding: encoding,
+	}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, event Event) error {
+	data, err := p.encoding.Encode(event)
+	if err != nil {
+		return err
+	}
+	msg := nats.NewMsg(p.subject + "." + event.Type)
+	msg.Data = data
+	msg.Header.Set("Content-Type", p.encoding.ContentType())
+	msg.Header.Set("Event-Type", event.Type)
+	msg.Header.Set("Tenant-ID", event.TenantID)
+	msg.Header.Set("User-UUID", event.UserUUID)
+
+	_, err = p.js.PublishMsg(msg, nats.Context(ctx), nats.MsgId(event.TenantID+":"+strconv.FormatInt(event.ID, 10)))
+	if err != nil {
+		return fmt.Errorf("failed to publish event to nats: %w", err)
+	}
+	return nil
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"context"
+	"github.com/nats-io/nats.go"
+	"os"
+	"testing"
+	"time"
+)
+
+// newNATSTestStream creates a JetStream stream of its own on the NATS of docker-compose.yml,
+// or the server at TEST_NATS_URL, and returns the subject it stores.
+func newNATSTestStream(t *testing.T) (string, string, nats.JetStreamContext) {
+	t.Helper()
+	url := os.Getenv("TEST_NATS_URL")
+	if url == "" {
+		t.Skip("TEST_NATS_URL is not set, e.g. nats://localhost:4222 for the NATS of docker-compose.yml")
+	}
+	conn, err := nats.Connect(url)
+	if err != nil {
+		t.Fatalf("failed to connect to %s: %v", url, err)
+	}
+	t.Cleanup(conn.Close)
+	js, err := conn.JetStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := randomName(t, "USERS_EVENTS_TEST_")
+	subject := randomName(t, "users.events.test.")
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:       stream,
+		Subjects:   []string{subject + ".>"},
+		Storage:    nats.MemoryStorage,
+		Duplicates: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("failed to create stream %s: %v", stream, err)
+	}
+	t.Cleanup(func() { _ = js.DeleteStream(stream) })
+	return stream, subject, js
+}
+
+func TestNATSPublisher(t *testing.T) {
+	stream, subject, js := newNATSTestStream(t)
+	publisher, err := NewNATSPublisher(os.Getenv("TEST_NATS_URL"), subject, EncodingProtobuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer publisher.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	event := testEvent()
+	// the relay publishes an event again when it fails before marking it published
+	for i := 0; i < 2; i++ {
+		if err = publisher.Publish(ctx, event); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	info, err := js.StreamInfo(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.State.Msgs != 1 {
+		t.Errorf("stream holds %d messages, want the event once", info.State.Msgs)
+	}
+	message, err := js.GetMsg(stream, 1)
+	if err != nil {
+		t.Fatalf("failed to get the event: %v", err)
+	}
+
+	if want := subject + "." + event.Type; message.Subject != want {
+		t.Errorf("subject %q, want %q", message.Subject, want)
+	}
+	wantHeaders := map[string]string{
+		"Content-Type": EncodingProtobuf.ContentType(),
+		"Event-Type":   event.Type,
+		"Tenant-ID":    event.TenantID,
+		"User-UUID":    event.UserUUID,
+	}
+	for key, want := range wantHeaders {
+		if got := message.Header.Get(key); got != want {
+			t.Errorf("header %s is %q, want %q", key, got, want)
+		}
+	}
+	checkUserEvent(t, message.Data, event)
+}
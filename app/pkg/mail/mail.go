@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"Users/internal/config"
+	"Users/pkg/logging"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+func NewSender(cfg config.Config, logger *logging.Logger) (Sender, error) {
+	switch cfg.Mail.Driver {
+	case "log":
+		return &logSender{logger: logger}, nil
+	case "smtp":
+		sender := &smtpSender{
+			addr: net.JoinHostPort(cfg.Mail.SMTP.Host, strconv.Itoa(cfg.Mail.SMTP.Port)),
+			from: cfg.Mail.From,
+		}
+		if cfg.Mail.SMTP.Username != "" {
+			sender.auth = smtp.PlainAuth("", cfg.Mail.SMTP.Username, cfg.Mail.SMTP.Password, cfg.Mail.SMTP.Host)
+		}
+		return sender, nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Mail.Driver)
+	}
+}
+
+// logSender writes messages to the log instead of sending them, for local development.
+type logSender struct {
+	logger *logging.Logger
+}
+
+func (s *logSender) Send(_ context.Context, msg Message) error {
+	s.logger.Infof("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+type smtpSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func (s *smtpSender) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("mail headers must not contain line breaks")
+	}
+
+	var body strings.Builder
+	body.WriteString("From: " + s.from + "\r\n")
+	body.WriteString("To: " + msg.To + "\r\n")
+	body.WriteString("Subject: " + msg.Subject + "\r\n")
+	body.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, []byte(body.String()))
+}
@@ -0,0 +1,126 @@
+package postgresql
+
+import (
+	"Users/pkg/logging"
+	"context"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"sync"
+	"time"
+)
+
+// Listener receives the notifications of a channel on a dedicated connection and wakes
+// up the subscribers of their payload. Notifications are signals only, subscribers read
+// what changed from the database, so a lost or folded notification loses no data.
+type Listener struct {
+	pool    *pgxpool.Pool
+	channel string
+	logger  *logging.Logger
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+func NewListener(pool *pgxpool.Pool, channel string, logger *logging.Logger) *Listener {
+	return &Listener{
+		pool:        pool,
+		channel:     channel,
+		logger:      logger,
+		subscribers: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribe returns a channel signalled when a notification with the payload arrives.
+// Signals are coalesced, a subscriber busy while several arrive is signalled once.
+// The returned function ends the subscription.
+func (l *Listener) Subscribe(payload string) (<-chan struct{}, func()) {
+	signal := make(chan struct{}, 1)
+
+	l.mu.Lock()
+	if l.subscribers[payload] == nil {
+		l.subscribers[payload] = make(map[chan struct{}]struct{})
+	}
+	l.subscribers[payload][signal] = struct{}{}
+	l.mu.Unlock()
+
+	return signal, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.subscribers[payload], signal)
+		if len(l.subscribers[payload]) == 0 {
+			delete(l.subscribers, payload)
+		}
+	}
+}
+
+// Run listens until the context is done and reconnects when the connection fails.
+func (l *Listener) Run(ctx context.Context) error {
+	l.logger.Infof("listener of channel %s started", l.channel)
+
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			l.logger.Infof("listener of channel %s stopped", l.channel)
+			return nil
+		}
+		l.logger.Errorf("listener of channel %s failed: %v", l.channel, err)
+
+		select {
+		case <-ctx.Done():
+			l.logger.Infof("listener of channel %s stopped", l.channel)
+			return nil
+		case <-time.After(waitTime):
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// the listening connection never goes back to the pool
+	conn := pooled.Hijack()
+	defer func() {
+		_ = conn.Close(context.Background())
+	}()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return err
+	}
+	// notifications sent while not listening are lost
+	l.wakeAll()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		l.wake(notification.Payload)
+	}
+}
+
+func (l *Listener) wake(payload string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for signal := range l.subscribers[payload] {
+		notify(signal)
+	}
+}
+
+func (l *Listener) wakeAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, subscribers := range l.subscribers {
+		for signal := range subscribers {
+			notify(signal)
+		}
+	}
+}
+
+func notify(signal chan struct{}) {
+	select {
+	case signal <- struct{}{}:
+	default:
+	}
+}
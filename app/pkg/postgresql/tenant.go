@@ -0,0 +1,145 @@
+package postgresql
+
+import (
+	"Users/pkg/tenant"
+	"context"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// setTenantQuery sets the tenant the row level security policies compare tenant_id
+// with and switches to appRole, which the policies apply to even if the connection
+// is a superuser's. The settings are local to the transaction, so they never leak to
+// the next user of the pooled connection.
+const setTenantQuery = `SELECT set_config('app.tenant_id', $1, true), set_config('role', $2, true)`
+
+// appRole is created by migrations/021_app_role.sql.
+const appRole = "users_app"
+
+type tenantClient struct {
+	pool *pgxpool.Pool
+}
+
+// NewTenantClient returns a client that runs every statement in a transaction scoped
+// to the tenant of the context, see tenant.WithID. Without a tenant in the context
+// the policies match no rows.
+func NewTenantClient(pool *pgxpool.Pool) Client {
+	return &tenantClient{pool: pool}
+}
+
+func (c *tenantClient) Begin(ctx context.Context) (pgx.Tx, error) {
+	return c.BeginTx(ctx, pgx.TxOptions{})
+}
+
+func (c *tenantClient) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	tx, err := c.pool.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := tenant.FromContext(ctx)
+	if _, err = tx.Exec(ctx, setTenantQuery, id, appRole); err != nil {
+		_ = tx.Rollback(context.Background())
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (c *tenantClient) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := tx.Exec(ctx, sql, arguments...)
+	if err != nil {
+		_ = tx.Rollback(context.Background())
+		return nil, err
+	}
+	return tag, tx.Commit(ctx)
+}
+
+func (c *tenantClient) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		_ = tx.Rollback(context.Background())
+		return nil, err
+	}
+	return &tenantRows{Rows: rows, ctx: ctx, tx: tx}, nil
+}
+
+func (c *tenantClient) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return errRow{err: err}
+	}
+	return &tenantRow{row: tx.QueryRow(ctx, sql, args...), ctx: ctx, tx: tx}
+}
+
+// tenantRows ends the transaction of the query once the rows are read or closed,
+// a failed commit is reported by Err.
+type tenantRows struct {
+	pgx.Rows
+	ctx context.Context
+	tx  pgx.Tx
+	err error
+}
+
+func (r *tenantRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.end()
+	return false
+}
+
+func (r *tenantRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tenantRows) Err() error {
+	if err := r.Rows.Err(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+func (r *tenantRows) end() {
+	if r.tx == nil {
+		return
+	}
+	tx := r.tx
+	r.tx = nil
+	if r.Rows.Err() != nil {
+		_ = tx.Rollback(context.Background())
+		return
+	}
+	r.err = tx.Commit(r.ctx)
+}
+
+// tenantRow ends the transaction of the query when the row is scanned.
+type tenantRow struct {
+	row pgx.Row
+	ctx context.Context
+	tx  pgx.Tx
+}
+
+func (r *tenantRow) Scan(dest ...interface{}) error {
+	if err := r.row.Scan(dest...); err != nil {
+		_ = r.tx.Rollback(context.Background())
+		return err
+	}
+	return r.tx.Commit(r.ctx)
+}
+
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(...interface{}) error {
+	return r.err
+}
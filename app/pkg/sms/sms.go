@@ -0,0 +1,83 @@
+package sms
+
+import (
+	"Users/internal/config"
+	"Users/pkg/logging"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	// To is an E.164 phone number.
+	To   string `json:"to"`
+	Body string `json:"body"`
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+func NewSender(cfg config.Config, logger *logging.Logger) (Sender, error) {
+	switch cfg.SMS.Driver {
+	case "log":
+		return &logSender{logger: logger}, nil
+	case "file":
+		return NewFileSender(cfg.SMS.File.Path)
+	default:
+		return nil, fmt.Errorf("unknown sms driver %q", cfg.SMS.Driver)
+	}
+}
+
+// logSender writes messages to the log instead of sending them, for local development.
+type logSender struct {
+	logger *logging.Logger
+}
+
+func (s *logSender) Send(_ context.Context, msg Message) error {
+	s.logger.Infof("sms to %s: %s", msg.To, msg.Body)
+	return nil
+}
+
+// fileSender appends messages to a file as JSON lines, so that local tools and
+// manual tests can pick up the sent codes.
+type fileSender struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSender(path string) (Sender, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create sms directory: %w", err)
+	}
+	return &fileSender{path: path}, nil
+}
+
+func (s *fileSender) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{msg, time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal sms: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open sms file: %w", err)
+	}
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write sms: %w", err)
+	}
+	return file.Close()
+}
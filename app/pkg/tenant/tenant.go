@@ -0,0 +1,21 @@
+package tenant
+
+import (
+	"context"
+)
+
+// DefaultID is the tenant of the users that existed before tenants were introduced.
+const DefaultID = "00000000-0000-0000-0000-000000000000"
+
+type ctxKey struct{}
+
+// WithID returns a copy of ctx carrying the tenant the request acts on behalf of.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the tenant of the request, false if there is none.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,11 @@
+package utils
+
+import (
+	"regexp"
+)
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func IsValidUUID(uuid string) bool {
+	return uuidRegexp.MatchString(uuid)
+}
@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a webhook request. The signature header has the form t=<unix seconds>,v1=<hex>,
+// where v1 is the HMAC-SHA256 of "<t>.<body>" keyed with the secret of the subscription.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	IDHeader        = "X-Webhook-ID"
+	EventHeader     = "X-Webhook-Event"
+)
+
+// maxResponseExcerpt is how much of a failed response is kept for the delivery log,
+// maxDrain how much more is read before the connection is given up.
+const (
+	maxResponseExcerpt = 512
+	maxDrain           = 64 << 10
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header of the body sent at the given time.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac(secret, t, body))
+}
+
+// Verify checks the signature header of a received webhook. Timestamps further than
+// tolerance from now are rejected, so a captured request cannot be replayed later.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			if signature, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, signature)
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+
+	expected := mac(secret, t, body)
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func mac(secret, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+type Request struct {
+	URL    string
+	Secret string
+	// ID identifies the delivery, it stays the same when the delivery is retried.
+	ID    string
+	Event string
+	Body  []byte
+}
+
+// Client sends signed webhook requests. Redirects are not followed, a receiver that
+// moved has to be updated by an admin.
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		http: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Timeout bounds a single request.
+func (c *Client) Timeout() time.Duration {
+	return c.http.Timeout
+}
+
+// Send posts the request and returns the status code of the response, any status
+// outside 2xx is an error.
+func (c *Client) Send(ctx context.Context, req Request, now time.Time) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "Users-Webhooks/1.0")
+	httpReq.Header.Set(IDHeader, req.ID)
+	httpReq.Header.Set(EventHeader, req.Event)
+	httpReq.Header.Set(SignatureHeader, Sign(req.Secret, now, req.Body))
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseExcerpt))
+	// drain a little more so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d: %s", resp.StatusCode,
+			strings.TrimSpace(string(excerpt)))
+	}
+	return resp.StatusCode, nil
+}